@@ -47,8 +47,6 @@ func setAppEnvProfile(e *aah.Event) {
 	aah.AppConfig().SetString("env.active", *profile)
 }
 
-
-
 func main() {
 	log.Infof("aah framework v%s, requires ≥ go1.8", aah.Version)
 	flag.Parse()
@@ -82,131 +80,437 @@ func main() {
 	aah.Init("github.com/keiwi/api")
 
 	// Adding all the application controllers which refers 'aah.Context' directly
-	// or indirectly from app/controllers/** 
+	// or indirectly from app/controllers/**
 	aah.AddController(
 		(*controllers.GroupsController)(nil),
-	  []*aah.MethodInfo{
-	    &aah.MethodInfo{
-	      Name: "RenameGroup",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "rename", Type: reflect.TypeOf((*models.GroupRename)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "CreateGroup",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.GroupCreate)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "EditGroup",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "edit", Type: reflect.TypeOf((*models.EditRequest)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "DeleteGroup",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.GroupID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "DeleteGroupWithName",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.GroupName)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetGroups",
-	      Parameters: []*aah.ParameterInfo{ 
-	      },
-	    },&aah.MethodInfo{
-	      Name: "ExistsGroup",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "group", Type: reflect.TypeOf((*models.GroupName)(nil))},
-	      },
-	    },
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "RenameGroup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "rename", Type: reflect.TypeOf((*models.GroupRename)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "CreateGroup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.GroupCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "EditGroup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "edit", Type: reflect.TypeOf((*models.EditRequest)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "DeleteGroup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.GroupID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "DeleteGroupWithName",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.GroupName)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetGroups",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "ExistsGroup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "group", Type: reflect.TypeOf((*models.GroupName)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "PatchGroup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			},
 		},
 	)
 	aah.AddController(
 		(*controllers.UsersController)(nil),
-	  []*aah.MethodInfo{
-	    &aah.MethodInfo{
-	      Name: "UserSignup",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "signup", Type: reflect.TypeOf((*models.User)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "UserLogin",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "login", Type: reflect.TypeOf((*models.User)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "UserInfo",
-	      Parameters: []*aah.ParameterInfo{ 
-	      },
-	    },
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "UserSignup",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "signup", Type: reflect.TypeOf((*models.User)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "UserLogin",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "login", Type: reflect.TypeOf((*models.User)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "UserRefresh",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "refresh", Type: reflect.TypeOf((*models.RefreshRequest)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "UserLogout",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "UserInfo",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "ChangePassword",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "change", Type: reflect.TypeOf((*models.PasswordChange)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "ChangeEmail",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "change", Type: reflect.TypeOf((*models.EmailChange)(nil))}},
+			},
 		},
 	)
 	aah.AddController(
 		(*controllers.ChecksController)(nil),
-	  []*aah.MethodInfo{
-	    &aah.MethodInfo{
-	      Name: "DeleteCheck",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.ChecksID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetChecks",
-	      Parameters: []*aah.ParameterInfo{ 
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetCheckWithID",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "check", Type: reflect.TypeOf((*models.ChecksID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetWithClientIDAndCommandID",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "c", Type: reflect.TypeOf((*models.ChecksWithClientCommandID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetWithChecksBetweenDateClient",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "c", Type: reflect.TypeOf((*models.ChecksBetweenDateClient)(nil))},
-	      },
-	    },
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "DeleteCheck",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.ChecksID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetChecks",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "GetCheckWithID",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "check", Type: reflect.TypeOf((*models.ChecksID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetWithClientIDAndCommandID",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "c", Type: reflect.TypeOf((*models.ChecksWithClientCommandID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetWithChecksBetweenDateClient",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "c", Type: reflect.TypeOf((*models.ChecksBetweenDateClient)(nil))}},
+			},
 		},
 	)
 	aah.AddController(
 		(*controllers.ClientsController)(nil),
-	  []*aah.MethodInfo{
-	    &aah.MethodInfo{
-	      Name: "CreateClient",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.ClientCreate)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "DeleteClient",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.ClientID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetClients",
-	      Parameters: []*aah.ParameterInfo{ 
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetClientWithID",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "client", Type: reflect.TypeOf((*models.ClientID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "EditClient",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "edit", Type: reflect.TypeOf((*models.EditRequest)(nil))},
-	      },
-	    },
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "CreateClient",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.ClientCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "DeleteClient",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.ClientID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetClients",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "GetClientWithID",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "client", Type: reflect.TypeOf((*models.ClientID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "EditClient",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "edit", Type: reflect.TypeOf((*models.EditRequest)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "PatchClient",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			},
 		},
 	)
 	aah.AddController(
 		(*controllers.CommandsController)(nil),
-	  []*aah.MethodInfo{
-	    &aah.MethodInfo{
-	      Name: "CreateCommand",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.CommandCreate)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "EditCommand",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "edit", Type: reflect.TypeOf((*models.EditRequest)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "DeleteCommand",
-	      Parameters: []*aah.ParameterInfo{ &aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.CommandID)(nil))},
-	      },
-	    },&aah.MethodInfo{
-	      Name: "GetCommands",
-	      Parameters: []*aah.ParameterInfo{ 
-	      },
-	    },
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "CreateCommand",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.CommandCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "EditCommand",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "edit", Type: reflect.TypeOf((*models.EditRequest)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "DeleteCommand",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.CommandID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetCommands",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "PatchCommand",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.WellKnownController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "JWKS",
+				Parameters: []*aah.ParameterInfo{},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.APIKeysController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "CreateAPIKey",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.APIKeyCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetAPIKeys",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "RevokeAPIKey",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "revoke", Type: reflect.TypeOf((*models.APIKeyID)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.MFAController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "EnrollMFA",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "EnableMFA",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "confirm", Type: reflect.TypeOf((*models.MFACode)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "DisableMFA",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "disable", Type: reflect.TypeOf((*models.MFADisable)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "RegenerateRecoveryCodes",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "confirm", Type: reflect.TypeOf((*models.MFACode)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "LoginMFA",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "login", Type: reflect.TypeOf((*models.MFALogin)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.UserAdminController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "GetUsers",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "list", Type: reflect.TypeOf((*models.UserList)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "SetUserDisabled",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "disable", Type: reflect.TypeOf((*models.UserDisable)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "ResetUserPassword",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "reset", Type: reflect.TypeOf((*models.UserPasswordReset)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "SetUserRoles",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "roles", Type: reflect.TypeOf((*models.UserRoles)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "DeleteUser",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.UserID)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.InvitesController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "CreateInvite",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.InviteCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetInvites",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "RevokeInvite",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "revoke", Type: reflect.TypeOf((*models.InviteID)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.AccountController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "ForgotPassword",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "forgot", Type: reflect.TypeOf((*models.PasswordForgot)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "ResetPassword",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "reset", Type: reflect.TypeOf((*models.PasswordResetConfirm)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "VerifyEmail",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "verify", Type: reflect.TypeOf((*models.EmailVerify)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "ResendVerification",
+				Parameters: []*aah.ParameterInfo{},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.SSOController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "StartSSO",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "FinishSSO",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "callback", Type: reflect.TypeOf((*models.SSOCallback)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.SessionsController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "GetSessions",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "RevokeSession",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "revoke", Type: reflect.TypeOf((*models.SessionID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "RevokeOtherSessions",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "GetUserSessions",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "user", Type: reflect.TypeOf((*models.UserID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "RevokeUserSession",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "revoke", Type: reflect.TypeOf((*models.SessionID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "RevokeUserSessions",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "user", Type: reflect.TypeOf((*models.UserID)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.AuditController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "GetAudit",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "query", Type: reflect.TypeOf((*models.AuditQuery)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.OrgsController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "CreateOrg",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.OrgCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetOrgs",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "DeleteOrg",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "delete", Type: reflect.TypeOf((*models.OrgID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "GetOrgMembers",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "org", Type: reflect.TypeOf((*models.OrgID)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "AddOrgMember",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "add", Type: reflect.TypeOf((*models.OrgMemberAdd)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "SetOrgMemberRole",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "role", Type: reflect.TypeOf((*models.OrgMemberRole)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "RemoveOrgMember",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "remove", Type: reflect.TypeOf((*models.OrgMemberID)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.ACLController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "GetACL",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "entity", Type: reflect.TypeOf((*models.ACLEntity)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "SetACL",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "set", Type: reflect.TypeOf((*models.ACLSet)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.ClientsV2Controller)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "List",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "Get",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Create",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.ClientCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Update",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Delete",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Checks",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkCreate",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkClientCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkUpdate",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkUpdate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkDelete",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkDelete)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.CommandsV2Controller)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "List",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "Get",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Create",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.CommandCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Update",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Delete",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkCreate",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkCommandCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkUpdate",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkUpdate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkDelete",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkDelete)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.GroupsV2Controller)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "List",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "Get",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Create",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "create", Type: reflect.TypeOf((*models.GroupCreate)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Update",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Delete",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkAddMembers",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkGroupMembers)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "BulkRemoveMembers",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "bulk", Type: reflect.TypeOf((*models.BulkGroupMembers)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.ChecksV2Controller)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "List",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "Get",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			}, &aah.MethodInfo{
+				Name:       "Delete",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "id", Type: reflect.TypeOf((*string)(nil))}},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.OpenAPIController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "Spec",
+				Parameters: []*aah.ParameterInfo{},
+			}, &aah.MethodInfo{
+				Name:       "Docs",
+				Parameters: []*aah.ParameterInfo{},
+			},
+		},
+	)
+	aah.AddController(
+		(*controllers.GraphQLController)(nil),
+		[]*aah.MethodInfo{
+			&aah.MethodInfo{
+				Name:       "Query",
+				Parameters: []*aah.ParameterInfo{&aah.ParameterInfo{Name: "req", Type: reflect.TypeOf((*models.GraphQLRequest)(nil))}},
+			},
 		},
 	)
 
@@ -220,12 +524,9 @@ func main() {
 	if err := secMgr.GetAuthScheme("generic_auth").SetAuthorizer(&generic_authsec.AuthorizationProvider{}); err != nil {
 		log.Fatal(err)
 	}
-	
 
 	log.Info("aah application initialized successfully")
 
-	
-
 	go aah.Start()
 
 	// Listen to OS signal's SIGINT & SIGTERM for aah server Shutdown
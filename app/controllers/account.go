@@ -0,0 +1,219 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/mailer"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/api/app/service"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AccountController controller for password resets and email verification
+type AccountController struct {
+	*aah.Context
+}
+
+// ForgotPassword emails a password reset link to the user with the email.
+// The reply is the same whether or not such a user exists.
+func (a *AccountController) ForgotPassword(forgot models.PasswordForgot) {
+	if forgot.Email == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Email is missing"})
+		return
+	}
+
+	reply := models.Response{Success: true, Message: "If the email belongs to an account, a reset link has been sent to it"}
+
+	// Limit the emails sent to an address the same way as failed logins
+	key := "reset:" + strings.ToLower(forgot.Email)
+	wait, err := security.Logins.Reserve(key)
+	if err != nil {
+		log.Errorf("error recording reset request: %v", err)
+	}
+	if err != nil || wait > 0 {
+		a.Reply().Ok().JSON(reply)
+		return
+	}
+
+	user, err := models.FindUser(utils.Filter{"email": forgot.Email})
+	if err != nil {
+		if err != models.ErrUserNotFound {
+			log.Debugf("error finding user: %v", err)
+		}
+		a.Reply().Ok().JSON(reply)
+		return
+	}
+
+	// Sending is done in the background, so the reply takes as long for
+	// unknown emails
+	go func() {
+		err := sendUserToken(user, user.Email, models.PasswordResetToken)
+		if err != nil {
+			log.Errorf("error sending password reset: %v", err)
+		}
+	}()
+
+	a.Reply().Ok().JSON(reply)
+}
+
+// ResetPassword sets a new password with a token from ForgotPassword
+func (a *AccountController) ResetPassword(reset models.PasswordResetConfirm) {
+	if reset.Token == "" || reset.Password == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Missing token or password"})
+		return
+	}
+
+	token, user, ok := a.useToken(models.PasswordResetToken, reset.Token)
+	if !ok {
+		return
+	}
+
+	// Receiving the reset link proves the email belongs to the user, unless
+	// the email changed since it was sent
+	set := bson.M{"password": models.HashPassword(user.Username, reset.Password)}
+	if user.Email != "" && strings.EqualFold(user.Email, token.Email) {
+		set["email_verified"] = true
+	}
+	err := models.UpdateUser(user.ID, set)
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	if err := security.Logins.Reset(security.UserKey(user.ID)); err != nil {
+		log.Errorf("error resetting failed logins: %v", err)
+	}
+	service.RevokeUserSessions(user.ID)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully reset the password"})
+}
+
+// VerifyEmail marks the email of a user as verified with a token sent to it
+func (a *AccountController) VerifyEmail(verify models.EmailVerify) {
+	if verify.Token == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Missing token"})
+		return
+	}
+
+	token, user, ok := a.useToken(models.VerifyEmailToken, verify.Token)
+	if !ok {
+		return
+	}
+	// The token is for an email the user no longer has
+	if !strings.EqualFold(user.Email, token.Email) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Invalid or expired token"})
+		return
+	}
+
+	err := models.UpdateUser(user.ID, bson.M{"email_verified": true})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully verified the email"})
+}
+
+// ResendVerification sends a new verification email to the authenticated user
+func (a *AccountController) ResendVerification() {
+	user, err := GetUserFromContext(a.Context)
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if user.Email == "" || user.EmailVerified {
+		a.Reply().BadRequest().JSON(models.Response{Message: "There is no email to verify"})
+		return
+	}
+
+	if err := sendUserToken(user, user.Email, models.VerifyEmailToken); err != nil {
+		log.Errorf("error sending email verification: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully sent the verification email"})
+}
+
+// useToken consumes the user token and looks up its user, replying with an
+// error if the token is invalid
+func (a *AccountController) useToken(purpose, value string) (*models.UserToken, *storageModel.User, bool) {
+	token, err := models.UseUserToken(purpose, security.HashToken(value))
+	if err == nil {
+		var user *storageModel.User
+		user, err = models.FindUserWithID(token.UserID.Hex())
+		if err == nil {
+			return token, user, true
+		}
+	}
+
+	if err == models.ErrUserTokenInvalid || err == models.ErrUserNotFound {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Invalid or expired token"})
+		return nil, nil, false
+	}
+	log.Debugf("error using token: %v", err)
+	a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	return nil, nil, false
+}
+
+// userTokenMails are the subjects, link paths and bodies of the emails with user tokens
+var userTokenMails = map[string]struct {
+	Subject string
+	Path    string
+	Body    string
+}{
+	models.PasswordResetToken: {
+		Subject: "Reset your password",
+		Path:    "/reset-password",
+		Body:    "Hi %s,\n\nSomeone asked to reset the password of your account. Use the link below to choose a new password:\n\n%s\n\nThe link expires in %s. If you didn't ask for this, you can ignore this email.\n",
+	},
+	models.VerifyEmailToken: {
+		Subject: "Verify your email",
+		Path:    "/verify-email",
+		Body:    "Hi %s,\n\nPlease verify your email by opening the link below:\n\n%s\n\nThe link expires in %s.\n",
+	},
+}
+
+// sendUserToken creates a user token with the purpose and emails a link with it to the address
+func sendUserToken(user *storageModel.User, email, purpose string) error {
+	cfg := aah.AppConfig()
+	ttl, err := time.ParseDuration(cfg.StringDefault("security.account."+purpose+"_ttl", "1h"))
+	if err != nil {
+		return err
+	}
+
+	value, err := security.RandomToken(32)
+	if err != nil {
+		return err
+	}
+
+	err = models.CreateUserToken(models.UserToken{
+		ID:        bson.NewObjectId(),
+		UserID:    user.ID,
+		Purpose:   purpose,
+		TokenHash: security.HashToken(value),
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+
+	tmpl := userTokenMails[purpose]
+	link := strings.TrimSuffix(cfg.StringDefault("mail.link_url", "http://localhost:8080"), "/") + tmpl.Path + "?token=" + value
+	return mailer.Send(mailer.Message{
+		To:      email,
+		Subject: tmpl.Subject,
+		Body:    fmt.Sprintf(tmpl.Body, user.Username, link, ttl),
+	})
+}
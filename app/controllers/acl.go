@@ -0,0 +1,209 @@
+package controllers
+
+import (
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/service"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ACLController controller for the owners and access lists of clients,
+// commands and groups
+type ACLController struct {
+	*aah.Context
+}
+
+// GetACL returns the access list of an entity the authenticated user can read
+func (a *ACLController) GetACL(entity models.ACLEntity) {
+	id, ok := a.findEntity(entity.Entity, entity.ID)
+	if !ok {
+		return
+	}
+
+	acls, err := models.FindEntityACLs(entity.Entity, []bson.ObjectId{id})
+	if err != nil {
+		log.Debugf("error finding access lists: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if !permitACL(a.Context, entity.Entity, models.ACLRead, acls[id]) {
+		return
+	}
+
+	acl := acls[id]
+	if acl == nil {
+		acl = &models.ACL{Entity: entity.Entity, EntityID: id, Entries: []models.ACLEntry{}}
+	}
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found the access list", Data: acl})
+}
+
+// SetACL replaces the access list, and optionally the owner, of an entity
+// the authenticated user administrates
+func (a *ACLController) SetACL(set models.ACLSet) {
+	if set.OwnerID != "" && !bson.IsObjectIdHex(set.OwnerID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Owner ID is not a valid ObjectId"})
+		return
+	}
+
+	roles := aah.AppConfig().KeysByPath("security.roles")
+	for _, e := range set.Entries {
+		if models.ACLLevel(e.Level) <= 0 {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Unknown access level " + e.Level})
+			return
+		}
+		if (e.UserID == "") == (e.Role == "") {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Every entry needs either a user ID or a role"})
+			return
+		}
+		if e.Role != "" && !containsString(roles, e.Role) {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Unknown role " + e.Role})
+			return
+		}
+	}
+
+	id, ok := a.findEntity(set.Entity, set.ID)
+	if !ok {
+		return
+	}
+
+	acls, err := models.FindEntityACLs(set.Entity, []bson.ObjectId{id})
+	if err != nil {
+		log.Debugf("error finding access lists: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if !permitACL(a.Context, set.Entity, models.ACLAdmin, acls[id]) {
+		return
+	}
+
+	acl := models.ACL{Entity: set.Entity, EntityID: id}
+	var before interface{}
+	if old := acls[id]; old != nil {
+		before = *old
+		acl.ID = old.ID
+		acl.OwnerID = old.OwnerID
+		acl.CreatedAt = old.CreatedAt
+	}
+	if set.OwnerID != "" {
+		acl.OwnerID = bson.ObjectIdHex(set.OwnerID)
+	}
+	acl.Entries = set.Entries
+	if acl.Entries == nil {
+		acl.Entries = []models.ACLEntry{}
+	}
+
+	if err := models.SaveACL(&acl); err != nil {
+		log.Debugf("error saving access list: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditEdit, "acl", acl.ID.Hex(), before, acl)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully saved the access list", Data: acl})
+}
+
+// findEntity checks that the entity exists in the organization of the
+// request, replying with an error if not
+func (a *ACLController) findEntity(entity, id string) (bson.ObjectId, bool) {
+	if !models.IsACLEntity(entity) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Unknown entity " + entity})
+		return "", false
+	}
+	if !bson.IsObjectIdHex(id) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return "", false
+	}
+
+	n := models.Conn
+	if n == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return "", false
+	}
+
+	filter, ok := orgFilter(a.Context, utils.Filter{"_id": bson.ObjectIdHex(id)})
+	if !ok {
+		return "", false
+	}
+
+	data, err := bson.MarshalJSON(utils.HasOptions{Filter: filter})
+	if err != nil {
+		log.Debugf("error marshaling data: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return "", false
+	}
+
+	has := map[string]func(*nats.Conn, []byte) (bool, error){
+		models.ACLClient:  utilNats.HasClient,
+		models.ACLCommand: utilNats.HasCommand,
+		models.ACLGroup:   utilNats.HasGroup,
+	}[entity]
+	exists, err := has(n, data)
+	if err != nil {
+		log.Debugf("error finding %s: %v", entity, err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return "", false
+	}
+	if !exists {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Can't find a " + entity + " with this ID"})
+		return "", false
+	}
+	return bson.ObjectIdHex(id), true
+}
+
+// aclLevel returns the access level of the authenticated user on an entity
+// with the access lists, see service.Level
+func aclLevel(ctx *aah.Context, entity string, acls ...*models.ACL) int {
+	return service.Level(callerOf(ctx), entity, acls...)
+}
+
+// permitACL checks that the access lists grant the authenticated user the
+// level on the entity, replying with an error if not
+func permitACL(ctx *aah.Context, entity, level string, acls ...*models.ACL) bool {
+	if err := service.Permit(callerOf(ctx), entity, level, acls...); err != nil {
+		replyError(ctx, err)
+		return false
+	}
+	return true
+}
+
+// checkACL checks that the authenticated user has the level on each of the
+// entities, replying with an error if not
+func checkACL(ctx *aah.Context, entity, level string, ids ...bson.ObjectId) bool {
+	if err := service.CheckACL(callerOf(ctx), entity, level, ids...); err != nil {
+		replyError(ctx, err)
+		return false
+	}
+	return true
+}
+
+// clientACLs returns the access list of each client followed by those of its
+// groups, replying with an error if they can't be found
+func clientACLs(ctx *aah.Context, clients []storageModel.Client) (map[bson.ObjectId][]*models.ACL, bool) {
+	acls, err := service.ClientACLs(clients)
+	if err != nil {
+		replyError(ctx, err)
+		return nil, false
+	}
+	return acls, true
+}
+
+// findReadable returns which of the entities the authenticated user can read
+func findReadable(ctx *aah.Context, entity string, ids []bson.ObjectId) (map[bson.ObjectId]bool, error) {
+	return service.Readable(callerOf(ctx), entity, ids)
+}
+
+// createACL makes the authenticated user the owner of a new entity
+func createACL(ctx *aah.Context, entity string, id bson.ObjectId) {
+	service.CreateACL(callerOf(ctx), entity, id)
+}
+
+// deleteACLs removes the access lists of deleted entities
+func deleteACLs(entity string, ids ...bson.ObjectId) {
+	service.DeleteACLs(entity, ids...)
+}
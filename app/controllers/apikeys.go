@@ -0,0 +1,128 @@
+package controllers
+
+import (
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// APIKeysController controller for the api keys of the authenticated user
+type APIKeysController struct {
+	*aah.Context
+}
+
+// CreateAPIKey creates a new api key, the key itself is only returned once
+func (a *APIKeysController) CreateAPIKey(create models.APIKeyCreate) {
+	if create.Name == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Name is missing"})
+		return
+	}
+	if len(create.Permissions) <= 0 {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Permissions are missing"})
+		return
+	}
+
+	// API keys can't be used to create more keys
+	if security.PrincipalClaim(a.Subject().AuthenticationInfo, security.APIKeyClaim) != "" {
+		a.Reply().Forbidden().JSON(models.Response{Message: "API keys can't create API keys"})
+		return
+	}
+
+	var expiresAt *time.Time
+	if create.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, create.ExpiresAt)
+		if err != nil || t.Before(time.Now()) {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid expiry (expires_at)"})
+			return
+		}
+		expiresAt = &t
+	}
+
+	key, prefix, hash, err := security.GenerateAPIKey()
+	if err != nil {
+		log.Errorf("error generating api key: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	apiKey := models.APIKey{
+		ID:          bson.NewObjectId(),
+		UserID:      bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value),
+		Name:        create.Name,
+		Prefix:      prefix,
+		Hash:        hash,
+		Permissions: create.Permissions,
+		ExpiresAt:   expiresAt,
+		CreatedAt:   time.Now(),
+	}
+
+	err = models.Create(models.APIKeysCollection, apiKey)
+	if err != nil {
+		log.Debugf("error creating api key: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditCreate, "api_key", apiKey.ID.Hex(), nil, apiKey)
+
+	apiKey.Hash = ""
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully created the api key", Data: map[string]interface{}{
+		"key":     key,
+		"api_key": apiKey,
+	}})
+}
+
+// GetAPIKeys returns all api keys of the authenticated user
+func (a *APIKeysController) GetAPIKeys() {
+	keys, err := models.FindAPIKeys(utils.Filter{"user_id": bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value)})
+	if err != nil {
+		log.Debugf("error finding api keys: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	for i := range keys {
+		keys[i].Hash = ""
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found all api keys", Data: keys})
+}
+
+// RevokeAPIKey deletes an api key of the authenticated user
+func (a *APIKeysController) RevokeAPIKey(revoke models.APIKeyID) {
+	if !bson.IsObjectIdHex(revoke.ID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return
+	}
+
+	filter := utils.Filter{
+		"_id":     bson.ObjectIdHex(revoke.ID),
+		"user_id": bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value),
+	}
+
+	// keep the api key for the audit log
+	keys, err := models.FindAPIKeys(filter)
+	if err != nil {
+		log.Debugf("error finding api key: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if len(keys) <= 0 {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Can't find an api key with this ID"})
+		return
+	}
+
+	err = models.Delete(models.APIKeysCollection, utils.DeleteOptions{Filter: filter})
+	if err != nil {
+		log.Debugf("error deleting api key: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditDelete, "api_key", revoke.ID, keys[0], nil)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully revoked the api key"})
+}
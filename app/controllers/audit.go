@@ -0,0 +1,89 @@
+package controllers
+
+import (
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/service"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	auditDefaultLimit = 100
+	auditMaxLimit     = 1000
+)
+
+// AuditController controller for searching the audit log
+type AuditController struct {
+	*aah.Context
+}
+
+// GetAudit returns the audit records matching the query, newest first
+func (a *AuditController) GetAudit(query models.AuditQuery) {
+	filter := utils.Filter{}
+	if query.Actor != "" {
+		if !bson.IsObjectIdHex(query.Actor) {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Actor is not a valid ObjectId"})
+			return
+		}
+		filter["actor"] = query.Actor
+	}
+	if query.OrgID != "" {
+		filter["org_id"] = query.OrgID
+	}
+	if query.Entity != "" {
+		filter["entity"] = query.Entity
+	}
+	if query.EntityID != "" {
+		filter["entity_id"] = query.EntityID
+	}
+	if query.Action != "" {
+		filter["action"] = query.Action
+	}
+
+	created := bson.M{}
+	if query.From != "" {
+		from, err := time.Parse(time.RFC3339, query.From)
+		if err != nil {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid time format (from)"})
+			return
+		}
+		created["$gte"] = from
+	}
+	if query.To != "" {
+		to, err := time.Parse(time.RFC3339, query.To)
+		if err != nil {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid time format (to)"})
+			return
+		}
+		created["$lte"] = to
+	}
+	if len(created) > 0 {
+		filter["created_at"] = created
+	}
+
+	limit := query.Limit
+	if limit <= 0 {
+		limit = auditDefaultLimit
+	}
+	if limit > auditMaxLimit {
+		limit = auditMaxLimit
+	}
+
+	records, err := models.FindAuditRecords(filter, limit)
+	if err != nil {
+		log.Debugf("error finding audit records: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found the audit records", Data: records})
+}
+
+// audit records a change made by the request, see service.Audit
+func audit(ctx *aah.Context, action, entity, id string, before, after interface{}) {
+	service.Audit(callerOf(ctx), action, entity, id, before, after)
+}
@@ -0,0 +1,780 @@
+package controllers
+
+import (
+	"fmt"
+	"reflect"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/service"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// bulkResults holds the result of each item of a bulk request, items are
+// successful once applied and failed once they have an error
+type bulkResults []models.BulkResult
+
+func newBulkResults(n int) bulkResults {
+	results := make(bulkResults, n)
+	for i := range results {
+		results[i].Index = i
+	}
+	return results
+}
+
+func (r bulkResults) fail(i int, message string) {
+	r[i].Success = false
+	r[i].Error = message
+}
+
+func (r bulkResults) failed() bool {
+	for _, result := range r {
+		if result.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// abort fails every item that hasn't failed yet
+func (r bulkResults) abort(message string) {
+	for i := range r {
+		if r[i].Error == "" {
+			r.fail(i, message)
+		}
+	}
+}
+
+// checkValid replies with the results of an atomic request with invalid
+// items, nothing has been applied then
+func (r bulkResults) checkValid(ctx *aah.Context, atomic bool) bool {
+	if !atomic || !r.failed() {
+		return true
+	}
+
+	r.abort("Not applied, other items are invalid")
+	ctx.Reply().BadRequest().JSON(models.Response{Message: "Nothing was applied, some items are invalid", Data: r})
+	return false
+}
+
+// reply replies with the results of a bulk request, an atomic request that
+// failed while applying the items has been rolled back
+func (r bulkResults) reply(ctx *aah.Context, atomic bool, action string) {
+	if atomic && r.failed() {
+		r.abort("Rolled back, other items failed")
+		ctx.Reply().InternalServerError().JSON(models.Response{Message: "Nothing was " + action + ", some items failed", Data: r})
+		return
+	}
+
+	succeeded := 0
+	for _, result := range r {
+		if result.Success {
+			succeeded++
+		}
+	}
+	ctx.Reply().Ok().JSON(models.Response{
+		Success: succeeded == len(r),
+		Message: fmt.Sprintf("Successfully %s %d of %d items", action, succeeded, len(r)),
+		Data:    r,
+	})
+}
+
+// checkBulkSize checks that a bulk request has between one and
+// `api.bulk.max_items` items, replying with an error if not
+func checkBulkSize(ctx *aah.Context, n int) bool {
+	max := aah.AppConfig().IntDefault("api.bulk.max_items", 500)
+	if n <= 0 || n > max {
+		ctx.Reply().BadRequest().JSON(models.Response{Message: fmt.Sprintf("A bulk request has to have between 1 and %d items", max)})
+		return false
+	}
+	return true
+}
+
+// bulkIDs validates the IDs of the items, failing invalid and repeated ones,
+// and returns the valid IDs
+func bulkIDs(results bulkResults, ids []string) []bson.ObjectId {
+	valid := make([]bson.ObjectId, 0, len(ids))
+	seen := make(map[string]bool)
+	for i, id := range ids {
+		results[i].ID = id
+		switch {
+		case !bson.IsObjectIdHex(id):
+			results.fail(i, "ID is not a valid ObjectId")
+		case seen[id]:
+			results.fail(i, "ID is repeated")
+		default:
+			seen[id] = true
+			valid = append(valid, bson.ObjectIdHex(id))
+		}
+	}
+	return valid
+}
+
+// bulkPatch applies the merge patch of an item to entity, a pointer to the
+// entity struct, failing the item if it can't be applied
+func bulkPatch(results bulkResults, i int, entity interface{}, schema patch.Schema, data []byte) bson.M {
+	set, err := service.Patch(entity, schema, patch.MergePatchType, data)
+	if err != nil {
+		results.fail(i, err.Error())
+		return nil
+	}
+	return set
+}
+
+// bulkUpdates saves the changed fields of each valid item with update. When
+// an atomic request fails, the fields of the updated items are restored
+// from befores, which holds pointers to the entities before the patches.
+func bulkUpdates(results bulkResults, ids []bson.ObjectId, sets []bson.M, befores []interface{}, atomic bool, update func(data []byte) error) {
+	var applied []int
+	for i, set := range sets {
+		if results[i].Error != "" {
+			continue
+		}
+		if len(set) > 0 {
+			if err := bulkUpdate(ids[i], set, update); err != nil {
+				log.Errorf("error updating %s: %v", ids[i].Hex(), err)
+				results.fail(i, "internal error")
+				if atomic {
+					break
+				}
+				continue
+			}
+			applied = append(applied, i)
+		}
+		results[i].Success = true
+	}
+
+	if !atomic || !results.failed() {
+		return
+	}
+	for _, i := range applied {
+		restore := bson.M{}
+		for name := range sets[i] {
+			restore[name] = service.FieldByJSONName(reflect.ValueOf(befores[i]).Elem(), name).Interface()
+		}
+		if err := bulkUpdate(ids[i], restore, update); err != nil {
+			log.Errorf("error rolling back the update of %s: %v", ids[i].Hex(), err)
+		}
+	}
+}
+
+func bulkUpdate(id bson.ObjectId, set bson.M, update func(data []byte) error) error {
+	data, err := bson.MarshalJSON(utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": id},
+		Updates: utils.Updates{"$set": set},
+	})
+	if err != nil {
+		return err
+	}
+	return update(data)
+}
+
+// bulkDelete deletes the entities of the valid items at once
+func bulkDelete(results bulkResults, ids []bson.ObjectId, del func(data []byte) error) {
+	var valid []bson.ObjectId
+	for i := range results {
+		if results[i].Error == "" {
+			valid = append(valid, ids[i])
+		}
+	}
+	if len(valid) <= 0 {
+		return
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{Filter: utils.Filter{"_id": bson.M{"$in": valid}}})
+	if err == nil {
+		err = del(data)
+	}
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		if err != nil {
+			results.fail(i, "internal error")
+		} else {
+			results[i].Success = true
+		}
+	}
+	if err != nil {
+		log.Errorf("error deleting entities: %v", err)
+	}
+}
+
+// findClientsByID returns the clients of the organization of the request
+// with the IDs, replying with an error if they can't be found
+func findClientsByID(ctx *aah.Context, ids []bson.ObjectId) (map[bson.ObjectId]storageModel.Client, []storageModel.Client, bool) {
+	filter, ok := orgFilter(ctx, utils.Filter{"_id": bson.M{"$in": ids}})
+	if !ok {
+		return nil, nil, false
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: filter})
+	if err == nil {
+		var clients []storageModel.Client
+		if clients, err = utilNats.FindClient(models.Conn, data); err == nil {
+			byID := make(map[bson.ObjectId]storageModel.Client, len(clients))
+			for _, c := range clients {
+				byID[c.ID] = c
+			}
+			return byID, clients, true
+		}
+	}
+
+	log.Debugf("error finding clients: %v", err)
+	ctx.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	return nil, nil, false
+}
+
+// findCommandsByID returns the commands of the organization of the request
+// with the IDs and their access levels, replying with an error if they
+// can't be found
+func findCommandsByID(ctx *aah.Context, ids []bson.ObjectId) (map[bson.ObjectId]storageModel.Command, map[bson.ObjectId]int, bool) {
+	filter, ok := orgFilter(ctx, utils.Filter{"_id": bson.M{"$in": ids}})
+	if !ok {
+		return nil, nil, false
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: filter})
+	if err == nil {
+		var commands []storageModel.Command
+		if commands, err = utilNats.FindCommand(models.Conn, data); err == nil {
+			var acls map[bson.ObjectId]*models.ACL
+			if acls, err = models.FindEntityACLs(models.ACLCommand, ids); err == nil {
+				byID := make(map[bson.ObjectId]storageModel.Command, len(commands))
+				levels := make(map[bson.ObjectId]int, len(commands))
+				for _, c := range commands {
+					byID[c.ID] = c
+					levels[c.ID] = aclLevel(ctx, models.ACLCommand, acls[c.ID])
+				}
+				return byID, levels, true
+			}
+		}
+	}
+
+	log.Debugf("error finding commands: %v", err)
+	ctx.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	return nil, nil, false
+}
+
+// BulkCreate creates several clients
+func (a *ClientsV2Controller) BulkCreate(bulk models.BulkClientCreate) {
+	if !checkBulkSize(a.Context, len(bulk.Items)) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	org, ok := activeOrg(a.Context)
+	if !ok {
+		return
+	}
+
+	results := newBulkResults(len(bulk.Items))
+	clients := make([]storageModel.Client, len(bulk.Items))
+	for i, item := range bulk.Items {
+		switch {
+		case item.IP == "":
+			results.fail(i, "IP is missing")
+		case item.Name == "":
+			results.fail(i, "Name is missing")
+		default:
+			clients[i] = storageModel.Client{ID: bson.NewObjectId(), Name: item.Name, IP: item.IP}
+			results[i].ID = clients[i].ID.Hex()
+		}
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	var created []bson.ObjectId
+	for i, client := range clients {
+		if results[i].Error != "" {
+			continue
+		}
+
+		data, err := models.MarshalWithOrg(client, org)
+		if err == nil {
+			err = utilNats.CreateClient(models.Conn, data)
+		}
+		if err != nil {
+			log.Errorf("error creating a client: %v", err)
+			results.fail(i, "internal error")
+			if bulk.Atomic {
+				break
+			}
+			continue
+		}
+		created = append(created, client.ID)
+		results[i].Success = true
+	}
+
+	if bulk.Atomic && results.failed() {
+		bulkDelete(newBulkResults(len(created)), created, func(data []byte) error {
+			return utilNats.DeleteClient(models.Conn, data)
+		})
+	} else {
+		for i, client := range clients {
+			if results[i].Success {
+				createACL(a.Context, models.ACLClient, client.ID)
+				audit(a.Context, models.AuditCreate, "client", client.ID.Hex(), nil, client)
+			}
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "created")
+}
+
+// BulkUpdate applies a JSON Merge Patch to each of several clients, group
+// membership is changed with the group member endpoints
+func (a *ClientsV2Controller) BulkUpdate(bulk models.BulkUpdate) {
+	if !checkBulkSize(a.Context, len(bulk.Items)) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	results := newBulkResults(len(bulk.Items))
+	rawIDs := make([]string, len(bulk.Items))
+	for i, item := range bulk.Items {
+		rawIDs[i] = item.ID
+	}
+	found, list, ok := findClientsByID(a.Context, bulkIDs(results, rawIDs))
+	if !ok {
+		return
+	}
+	acls, ok := clientACLs(a.Context, list)
+	if !ok {
+		return
+	}
+
+	ids := make([]bson.ObjectId, len(bulk.Items))
+	sets := make([]bson.M, len(bulk.Items))
+	befores := make([]interface{}, len(bulk.Items))
+	afters := make([]storageModel.Client, len(bulk.Items))
+	for i, item := range bulk.Items {
+		if results[i].Error != "" {
+			continue
+		}
+
+		client, exists := found[bson.ObjectIdHex(item.ID)]
+		if !exists {
+			results.fail(i, "Can't find a client with this ID")
+			continue
+		}
+		if aclLevel(a.Context, models.ACLClient, acls[client.ID]...) < models.ACLLevel(models.ACLWrite) {
+			results.fail(i, "You don't have write access to this client")
+			continue
+		}
+
+		before := client
+		before.GroupIDs = append([]bson.ObjectId(nil), client.GroupIDs...)
+		set := bulkPatch(results, i, &client, models.ClientSchema, item.Patch)
+		if set == nil {
+			continue
+		}
+		if _, changed := set["group_ids"]; changed {
+			results.fail(i, "Groups are changed with the group member endpoints")
+			continue
+		}
+		ids[i], sets[i], befores[i], afters[i] = client.ID, set, &before, client
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	bulkUpdates(results, ids, sets, befores, bulk.Atomic, func(data []byte) error {
+		return utilNats.UpdateClient(models.Conn, data)
+	})
+	if !bulk.Atomic || !results.failed() {
+		for i := range results {
+			if results[i].Success && len(sets[i]) > 0 {
+				audit(a.Context, models.AuditEdit, "client", ids[i].Hex(), befores[i], afters[i])
+			}
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "updated")
+}
+
+// BulkDelete deletes several clients
+func (a *ClientsV2Controller) BulkDelete(bulk models.BulkDelete) {
+	if !checkBulkSize(a.Context, len(bulk.IDs)) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	results := newBulkResults(len(bulk.IDs))
+	found, list, ok := findClientsByID(a.Context, bulkIDs(results, bulk.IDs))
+	if !ok {
+		return
+	}
+	acls, ok := clientACLs(a.Context, list)
+	if !ok {
+		return
+	}
+
+	ids := make([]bson.ObjectId, len(bulk.IDs))
+	for i, id := range bulk.IDs {
+		if results[i].Error != "" {
+			continue
+		}
+
+		client, exists := found[bson.ObjectIdHex(id)]
+		if !exists {
+			results.fail(i, "Can't find a client with this ID")
+			continue
+		}
+		if aclLevel(a.Context, models.ACLClient, acls[client.ID]...) < models.ACLLevel(models.ACLWrite) {
+			results.fail(i, "You don't have write access to this client")
+			continue
+		}
+		ids[i] = client.ID
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	bulkDelete(results, ids, func(data []byte) error {
+		return utilNats.DeleteClient(models.Conn, data)
+	})
+	for i := range results {
+		if results[i].Success {
+			deleteACLs(models.ACLClient, ids[i])
+			audit(a.Context, models.AuditDelete, "client", ids[i].Hex(), found[ids[i]], nil)
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "deleted")
+}
+
+// BulkCreate creates several commands
+func (a *CommandsV2Controller) BulkCreate(bulk models.BulkCommandCreate) {
+	if !checkBulkSize(a.Context, len(bulk.Items)) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	org, ok := activeOrg(a.Context)
+	if !ok {
+		return
+	}
+
+	results := newBulkResults(len(bulk.Items))
+	commands := make([]storageModel.Command, len(bulk.Items))
+	for i, item := range bulk.Items {
+		switch {
+		case item.Command == "":
+			results.fail(i, "Command is missing")
+		case item.Name == "":
+			results.fail(i, "Name is missing")
+		case item.Description == "":
+			results.fail(i, "Description is missing")
+		default:
+			commands[i] = storageModel.Command{
+				ID:          bson.NewObjectId(),
+				Command:     item.Command,
+				Name:        item.Name,
+				Description: item.Description,
+				Format:      item.Format,
+			}
+			results[i].ID = commands[i].ID.Hex()
+		}
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	var created []bson.ObjectId
+	for i, cmd := range commands {
+		if results[i].Error != "" {
+			continue
+		}
+
+		data, err := models.MarshalWithOrg(cmd, org)
+		if err == nil {
+			err = utilNats.CreateCommand(models.Conn, data)
+		}
+		if err != nil {
+			log.Errorf("error creating a command: %v", err)
+			results.fail(i, "internal error")
+			if bulk.Atomic {
+				break
+			}
+			continue
+		}
+		created = append(created, cmd.ID)
+		results[i].Success = true
+	}
+
+	if bulk.Atomic && results.failed() {
+		bulkDelete(newBulkResults(len(created)), created, func(data []byte) error {
+			return utilNats.DeleteCommand(models.Conn, data)
+		})
+	} else {
+		for i, cmd := range commands {
+			if results[i].Success {
+				createACL(a.Context, models.ACLCommand, cmd.ID)
+				audit(a.Context, models.AuditCreate, "command", cmd.ID.Hex(), nil, cmd)
+			}
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "created")
+}
+
+// BulkUpdate applies a JSON Merge Patch to each of several commands
+func (a *CommandsV2Controller) BulkUpdate(bulk models.BulkUpdate) {
+	if !checkBulkSize(a.Context, len(bulk.Items)) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	results := newBulkResults(len(bulk.Items))
+	rawIDs := make([]string, len(bulk.Items))
+	for i, item := range bulk.Items {
+		rawIDs[i] = item.ID
+	}
+	found, levels, ok := findCommandsByID(a.Context, bulkIDs(results, rawIDs))
+	if !ok {
+		return
+	}
+
+	ids := make([]bson.ObjectId, len(bulk.Items))
+	sets := make([]bson.M, len(bulk.Items))
+	befores := make([]interface{}, len(bulk.Items))
+	afters := make([]storageModel.Command, len(bulk.Items))
+	for i, item := range bulk.Items {
+		if results[i].Error != "" {
+			continue
+		}
+
+		cmd, exists := found[bson.ObjectIdHex(item.ID)]
+		if !exists {
+			results.fail(i, "Can't find a command with this ID")
+			continue
+		}
+		if levels[cmd.ID] < models.ACLLevel(models.ACLWrite) {
+			results.fail(i, "You don't have write access to this command")
+			continue
+		}
+
+		before := cmd
+		set := bulkPatch(results, i, &cmd, models.CommandSchema, item.Patch)
+		if set == nil {
+			continue
+		}
+		ids[i], sets[i], befores[i], afters[i] = cmd.ID, set, &before, cmd
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	bulkUpdates(results, ids, sets, befores, bulk.Atomic, func(data []byte) error {
+		return utilNats.UpdateCommand(models.Conn, data)
+	})
+	if !bulk.Atomic || !results.failed() {
+		for i := range results {
+			if results[i].Success && len(sets[i]) > 0 {
+				audit(a.Context, models.AuditEdit, "command", ids[i].Hex(), befores[i], afters[i])
+			}
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "updated")
+}
+
+// BulkDelete deletes several commands
+func (a *CommandsV2Controller) BulkDelete(bulk models.BulkDelete) {
+	if !checkBulkSize(a.Context, len(bulk.IDs)) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	results := newBulkResults(len(bulk.IDs))
+	found, levels, ok := findCommandsByID(a.Context, bulkIDs(results, bulk.IDs))
+	if !ok {
+		return
+	}
+
+	ids := make([]bson.ObjectId, len(bulk.IDs))
+	for i, id := range bulk.IDs {
+		if results[i].Error != "" {
+			continue
+		}
+
+		cmd, exists := found[bson.ObjectIdHex(id)]
+		if !exists {
+			results.fail(i, "Can't find a command with this ID")
+			continue
+		}
+		if levels[cmd.ID] < models.ACLLevel(models.ACLWrite) {
+			results.fail(i, "You don't have write access to this command")
+			continue
+		}
+		ids[i] = cmd.ID
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	bulkDelete(results, ids, func(data []byte) error {
+		return utilNats.DeleteCommand(models.Conn, data)
+	})
+	for i := range results {
+		if results[i].Success {
+			deleteACLs(models.ACLCommand, ids[i])
+			audit(a.Context, models.AuditDelete, "command", ids[i].Hex(), found[ids[i]], nil)
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "deleted")
+}
+
+// BulkAddMembers adds several clients to a group
+func (a *GroupsV2Controller) BulkAddMembers(bulk models.BulkGroupMembers) {
+	a.bulkMembers(bulk, true)
+}
+
+// BulkRemoveMembers removes several clients from a group
+func (a *GroupsV2Controller) BulkRemoveMembers(bulk models.BulkGroupMembers) {
+	a.bulkMembers(bulk, false)
+}
+
+// bulkMembers adds the clients to the group or removes them from it with a
+// single update, clients that already are or aren't members are left as is
+func (a *GroupsV2Controller) bulkMembers(bulk models.BulkGroupMembers, add bool) {
+	if !checkBulkSize(a.Context, len(bulk.ClientIDs)) {
+		return
+	}
+	if !validID(a.Context, bulk.GroupID) {
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	filter, ok := orgFilter(a.Context, utils.Filter{"_id": bson.ObjectIdHex(bulk.GroupID)})
+	if !ok {
+		return
+	}
+	groups, err := service.FindGroups(models.Conn, filter)
+	if err != nil {
+		log.Debugf("error finding groups: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if len(groups) <= 0 {
+		a.Reply().NotFound().JSON(models.Response{Message: "Can't find a group with this ID"})
+		return
+	}
+	group := groups[0].ID
+	if !checkACL(a.Context, models.ACLGroup, models.ACLWrite, group) {
+		return
+	}
+
+	results := newBulkResults(len(bulk.ClientIDs))
+	found, list, ok := findClientsByID(a.Context, bulkIDs(results, bulk.ClientIDs))
+	if !ok {
+		return
+	}
+	acls, ok := clientACLs(a.Context, list)
+	if !ok {
+		return
+	}
+
+	var changed []bson.ObjectId
+	for i, id := range bulk.ClientIDs {
+		if results[i].Error != "" {
+			continue
+		}
+
+		client, exists := found[bson.ObjectIdHex(id)]
+		if !exists {
+			results.fail(i, "Can't find a client with this ID")
+			continue
+		}
+		if aclLevel(a.Context, models.ACLClient, acls[client.ID]...) < models.ACLLevel(models.ACLWrite) {
+			results.fail(i, "You don't have write access to this client")
+			continue
+		}
+
+		member := false
+		for _, g := range client.GroupIDs {
+			member = member || g == group
+		}
+		if member != add {
+			changed = append(changed, client.ID)
+		}
+	}
+	if !results.checkValid(a.Context, bulk.Atomic) {
+		return
+	}
+
+	op := "$pull"
+	if add {
+		op = "$addToSet"
+	}
+	if len(changed) > 0 {
+		data, err := bson.MarshalJSON(utils.UpdateOptions{
+			Filter:  utils.Filter{"_id": bson.M{"$in": changed}},
+			Updates: utils.Updates{op: bson.M{"group_ids": group}},
+		})
+		if err == nil {
+			err = utilNats.UpdateClient(models.Conn, data)
+		}
+		if err != nil {
+			log.Errorf("error updating the groups of clients: %v", err)
+			for i := range results {
+				if results[i].Error == "" {
+					results.fail(i, "internal error")
+				}
+			}
+			results.reply(a.Context, bulk.Atomic, "updated")
+			return
+		}
+	}
+
+	for _, id := range changed {
+		before := found[id]
+		after := before
+		after.GroupIDs = nil
+		for _, g := range before.GroupIDs {
+			if g != group {
+				after.GroupIDs = append(after.GroupIDs, g)
+			}
+		}
+		if add {
+			after.GroupIDs = append(after.GroupIDs, group)
+		}
+		audit(a.Context, models.AuditEdit, "client", id.Hex(), before, after)
+	}
+	for i := range results {
+		if results[i].Error == "" {
+			results[i].Success = true
+		}
+	}
+	results.reply(a.Context, bulk.Atomic, "updated")
+}
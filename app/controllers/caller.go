@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"net/http"
+
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/api/app/service"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// callerKey is the context key caching the caller of the request
+const callerKey = "caller"
+
+// callerOf returns the caller of the request for the service layer, its
+// organization isn't resolved yet
+func callerOf(ctx *aah.Context) *service.Caller {
+	if c, ok := ctx.Get(callerKey).(*service.Caller); ok {
+		return c
+	}
+
+	subject := ctx.Subject()
+	c := &service.Caller{
+		Subject:   subject,
+		IP:        security.RequestIP(ctx),
+		RequestID: ctx.Req.Header.Get(aah.AppConfig().StringDefault("request.id.header", "X-Request-Id")),
+	}
+	if subject.IsAuthenticated() && bson.IsObjectIdHex(subject.PrimaryPrincipal().Value) {
+		c.UserID = bson.ObjectIdHex(subject.PrimaryPrincipal().Value)
+		c.Username = security.PrincipalClaim(subject.AuthenticationInfo, "username")
+		if security.PrincipalClaim(subject.AuthenticationInfo, security.APIKeyClaim) != "" {
+			c.Via = security.APIKeyClaim
+		} else if security.PrincipalClaim(subject.AuthenticationInfo, security.CertClaim) != "" {
+			c.Via = security.CertClaim
+		}
+	}
+
+	ctx.Set(callerKey, c)
+	return c
+}
+
+// requestCaller returns the caller of the request with its active
+// organization, replying with an error if there is none
+func requestCaller(ctx *aah.Context) (*service.Caller, bool) {
+	c := callerOf(ctx)
+	if err := service.ResolveOrg(c, ctx.Req.Header.Get(OrgHeader)); err != nil {
+		replyError(ctx, err)
+		return nil, false
+	}
+	return c, true
+}
+
+// replyError replies with the status matching the error of the service layer
+func replyError(ctx *aah.Context, err error) {
+	e, ok := err.(*service.Error)
+	if !ok {
+		e = service.ErrInternal
+	}
+
+	status := http.StatusInternalServerError
+	switch e.Kind {
+	case service.Invalid:
+		status = http.StatusBadRequest
+	case service.NotFound:
+		status = http.StatusNotFound
+	case service.Forbidden:
+		status = http.StatusForbidden
+	case service.Unsupported:
+		status = http.StatusUnsupportedMediaType
+	}
+	ctx.Reply().Status(status).JSON(models.Response{Message: e.Message})
+}
@@ -0,0 +1,460 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/keiwi/api/app/graph"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/api/app/service"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// graphSchema is the parsed GraphQL schema, parsed on start
+var graphSchema *graphql.Schema
+
+// errGraphInternal is returned by resolvers failing for reasons other than
+// the query, the cause is logged
+var errGraphInternal = errors.New("internal error")
+
+// errGraphForbidden is returned by resolvers of entities the user isn't
+// permitted to read
+var errGraphForbidden = errors.New("permission denied")
+
+// LoadGraphQL parses the GraphQL schema, the endpoint replies with an error
+// if it can't be parsed
+func LoadGraphQL(_ *aah.Event) {
+	cfg := aah.AppConfig()
+	schema, err := graphql.ParseSchema(graph.Schema, &graphRoot{},
+		graphql.MaxDepth(cfg.IntDefault("api.graphql.max_depth", 8)),
+		graphql.MaxParallelism(cfg.IntDefault("api.graphql.max_parallelism", 10)),
+	)
+	if err != nil {
+		log.Errorf("unable to parse the GraphQL schema: %v", err)
+		return
+	}
+	graphSchema = schema
+}
+
+// GraphQLController serves the GraphQL endpoint
+type GraphQLController struct {
+	*aah.Context
+}
+
+// Query executes a GraphQL query, the reply is the GraphQL response
+func (a *GraphQLController) Query(req models.GraphQLRequest) {
+	if graphSchema == nil {
+		log.Error("the GraphQL schema is not loaded")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if req.Query == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Query is missing"})
+		return
+	}
+	if security.PrincipalClaim(a.Subject().AuthenticationInfo, security.MFAPendingClaim) != "" {
+		a.Reply().Forbidden().JSON(models.Response{Message: "Two-factor authentication is required"})
+		return
+	}
+
+	org, ok := activeOrg(a.Context)
+	if !ok {
+		return
+	}
+
+	ctx := context.WithValue(context.Background(), graphRequestKey{}, newGraphRequest(a.Context, org))
+	a.Reply().Ok().JSON(graphSchema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+type graphRequestKey struct{}
+
+// graphRequest is the state of a GraphQL query, the loaders batch the
+// storage requests of the entities referred to by the resolved ones. The
+// batch functions of a loader may store values in the loaders listed before
+// it, never after it, so their locks are always taken in the same order.
+type graphRequest struct {
+	ctx *aah.Context
+	org bson.ObjectId
+
+	clients       *graph.Loader // Clients by ID
+	commands      *graph.Loader // Commands by ID
+	groups        *graph.Loader // Groups by ID
+	groupClients  *graph.Loader // Clients of the groups by group ID
+	commandGroups *graph.Loader // Groups running the commands by command ID
+	latestChecks  *graph.Loader // Latest checks by client and command ID
+
+	mu     sync.Mutex
+	seen   []storageModel.Client // Clients resolved so far
+	primed int                   // Clients whose latest checks are queued
+}
+
+func newGraphRequest(ctx *aah.Context, org bson.ObjectId) *graphRequest {
+	r := &graphRequest{ctx: ctx, org: org}
+	r.clients = graph.NewLoader(r.fetchClients)
+	r.commands = graph.NewLoader(r.fetchCommands)
+	r.groups = graph.NewLoader(r.fetchGroups)
+	r.groupClients = graph.NewLoader(r.fetchGroupClients)
+	r.commandGroups = graph.NewLoader(r.fetchCommandGroups)
+	r.latestChecks = graph.NewLoader(r.fetchLatestChecks)
+	return r
+}
+
+// graphRequestOf returns the state of the query of the context
+func graphRequestOf(ctx context.Context) *graphRequest {
+	return ctx.Value(graphRequestKey{}).(*graphRequest)
+}
+
+// permit returns an error unless the user holds the permission, API keys
+// and certificates may lack the read permission of some entities
+func (r *graphRequest) permit(permission string) error {
+	if !r.ctx.Subject().IsPermitted(permission) {
+		return errGraphForbidden
+	}
+	return nil
+}
+
+// scope scopes the filter to the organization of the request
+func (r *graphRequest) scope(filter utils.Filter) utils.Filter {
+	if r.org != "" {
+		filter["org_id"] = r.org
+	}
+	return filter
+}
+
+// findClients returns the clients matching the filter that the user can read
+func (r *graphRequest) findClients(filter utils.Filter, sort utils.Sort, limit int) ([]storageModel.Client, error) {
+	if err := r.permit("clients:read"); err != nil {
+		return nil, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: r.scope(filter), Sort: sort, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	clients, err := utilNats.FindClient(models.Conn, data)
+	if err != nil {
+		return nil, err
+	}
+
+	acls, err := service.ClientACLs(clients)
+	if err != nil {
+		return nil, err
+	}
+	readable := make([]storageModel.Client, 0, len(clients))
+	for _, c := range clients {
+		if aclLevel(r.ctx, models.ACLClient, acls[c.ID]...) >= models.ACLLevel(models.ACLRead) {
+			readable = append(readable, c)
+		}
+	}
+	return readable, nil
+}
+
+// findCommands returns the commands matching the filter that the user can
+// read
+func (r *graphRequest) findCommands(filter utils.Filter, sort utils.Sort, limit int) ([]storageModel.Command, error) {
+	if err := r.permit("commands:read"); err != nil {
+		return nil, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: r.scope(filter), Sort: sort, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	commands, err := utilNats.FindCommand(models.Conn, data)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]bson.ObjectId, 0, len(commands))
+	for _, c := range commands {
+		ids = append(ids, c.ID)
+	}
+	readable, err := findReadable(r.ctx, models.ACLCommand, ids)
+	if err != nil {
+		return nil, err
+	}
+	visible := commands[:0]
+	for _, c := range commands {
+		if readable[c.ID] {
+			visible = append(visible, c)
+		}
+	}
+	return visible, nil
+}
+
+// findGroups returns the groups matching the filter that the user can read
+func (r *graphRequest) findGroups(filter utils.Filter, sort utils.Sort, limit int) ([]storageModel.Group, error) {
+	if err := r.permit("groups:read"); err != nil {
+		return nil, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: r.scope(filter), Sort: sort, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	groups, err := utilNats.FindGroup(models.Conn, data)
+	if err != nil {
+		return nil, err
+	}
+
+	readable, err := findReadable(r.ctx, models.ACLGroup, service.GroupIDs(groups))
+	if err != nil {
+		return nil, err
+	}
+	visible := groups[:0]
+	for _, g := range groups {
+		if readable[g.ID] {
+			visible = append(visible, g)
+		}
+	}
+	return visible, nil
+}
+
+func (r *graphRequest) fetchClients(keys []string) (map[string]interface{}, error) {
+	clients, err := r.findClients(utils.Filter{"_id": bson.M{"$in": objectIDs(keys)}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(clients))
+	for _, c := range clients {
+		values[c.ID.Hex()] = c
+	}
+	return values, nil
+}
+
+func (r *graphRequest) fetchCommands(keys []string) (map[string]interface{}, error) {
+	commands, err := r.findCommands(utils.Filter{"_id": bson.M{"$in": objectIDs(keys)}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(commands))
+	for _, c := range commands {
+		values[c.ID.Hex()] = c
+	}
+	return values, nil
+}
+
+func (r *graphRequest) fetchGroups(keys []string) (map[string]interface{}, error) {
+	groups, err := r.findGroups(utils.Filter{"_id": bson.M{"$in": objectIDs(keys)}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(groups))
+	for _, g := range groups {
+		values[g.ID.Hex()] = g
+	}
+	return values, nil
+}
+
+func (r *graphRequest) fetchGroupClients(keys []string) (map[string]interface{}, error) {
+	clients, err := r.findClients(utils.Filter{"group_ids": bson.M{"$in": objectIDs(keys)}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	members := make(map[string][]storageModel.Client, len(keys))
+	for _, c := range clients {
+		r.clients.Set(c.ID.Hex(), c)
+		for _, g := range c.GroupIDs {
+			members[g.Hex()] = append(members[g.Hex()], c)
+		}
+	}
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		values[key] = members[key]
+	}
+	return values, nil
+}
+
+func (r *graphRequest) fetchCommandGroups(keys []string) (map[string]interface{}, error) {
+	groups, err := r.findGroups(utils.Filter{"commands.command_id": bson.M{"$in": objectIDs(keys)}}, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	running := make(map[string][]storageModel.Group, len(keys))
+	for _, g := range groups {
+		r.groups.Set(g.ID.Hex(), g)
+		added := make(map[bson.ObjectId]bool)
+		for _, gc := range g.Commands {
+			if !added[gc.CommandID] {
+				added[gc.CommandID] = true
+				running[gc.CommandID.Hex()] = append(running[gc.CommandID.Hex()], g)
+			}
+		}
+	}
+	values := make(map[string]interface{}, len(keys))
+	for _, key := range keys {
+		values[key] = running[key]
+	}
+	return values, nil
+}
+
+// fetchLatestChecks finds the latest check of each client and command. The
+// latest checks of every pair are scanned for at once, `latest_checks_scan`
+// checks per pair, and pairs that aren't among them are found one by one.
+func (r *graphRequest) fetchLatestChecks(keys []string) (map[string]interface{}, error) {
+	var clients, commands []bson.ObjectId
+	seen := make(map[string]bool)
+	for _, key := range keys {
+		client, command := splitCheckKey(key)
+		if !seen[client.Hex()] {
+			seen[client.Hex()] = true
+			clients = append(clients, client)
+		}
+		if !seen[command.Hex()] {
+			seen[command.Hex()] = true
+			commands = append(commands, command)
+		}
+	}
+
+	scan := aah.AppConfig().IntDefault("api.graphql.latest_checks_scan", 20)
+	checks, err := r.findChecks(utils.Filter{
+		"client_id":  bson.M{"$in": clients},
+		"command_id": bson.M{"$in": commands},
+	}, len(keys)*scan)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]interface{}, len(keys))
+	for _, c := range checks {
+		key := checkKey(c.ClientID, c.CommandID)
+		if _, found := values[key]; !found {
+			values[key] = c
+		}
+	}
+
+	for _, key := range keys {
+		if _, found := values[key]; found {
+			continue
+		}
+		client, command := splitCheckKey(key)
+		checks, err := r.findChecks(utils.Filter{"client_id": client, "command_id": command}, 1)
+		if err != nil {
+			return nil, err
+		}
+		if len(checks) > 0 {
+			values[key] = checks[0]
+		}
+	}
+	return values, nil
+}
+
+// findChecks returns the latest checks matching the filter
+func (r *graphRequest) findChecks(filter utils.Filter, limit int) ([]storageModel.Check, error) {
+	if err := r.permit("checks:read"); err != nil {
+		return nil, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: filter, Sort: utils.Sort{"-created_at"}, Limit: limit})
+	if err != nil {
+		return nil, err
+	}
+	return utilNats.FindCheck(models.Conn, data)
+}
+
+// client returns the client if the user can read it, or nil
+func (r *graphRequest) client(id bson.ObjectId) (*storageModel.Client, error) {
+	v, err := r.clients.Load(id.Hex())
+	if err != nil || v == nil {
+		return nil, err
+	}
+	c := v.(storageModel.Client)
+	return &c, nil
+}
+
+// clientGroups returns the groups of the client the user can read
+func (r *graphRequest) clientGroups(c storageModel.Client) ([]storageModel.Group, error) {
+	values, err := r.groups.LoadMany(hexIDs(c.GroupIDs))
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]storageModel.Group, 0, len(values))
+	for _, v := range values {
+		groups = append(groups, v.(storageModel.Group))
+	}
+	return groups, nil
+}
+
+// latestCheckKeys returns the keys of the latest checks of the commands of
+// the groups of the client
+func (r *graphRequest) latestCheckKeys(c storageModel.Client) ([]string, error) {
+	groups, err := r.clientGroups(c)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	seen := make(map[bson.ObjectId]bool)
+	for _, g := range groups {
+		for _, gc := range g.Commands {
+			if !seen[gc.CommandID] {
+				seen[gc.CommandID] = true
+				keys = append(keys, checkKey(c.ID, gc.CommandID))
+			}
+		}
+	}
+	return keys, nil
+}
+
+// primeLatestChecks queues the latest checks of the clients resolved since
+// the last call, so that they are found with a single batch
+func (r *graphRequest) primeLatestChecks() error {
+	r.mu.Lock()
+	seen := append([]storageModel.Client(nil), r.seen[r.primed:]...)
+	r.primed = len(r.seen)
+	r.mu.Unlock()
+
+	for _, c := range seen {
+		keys, err := r.latestCheckKeys(c)
+		if err != nil {
+			return err
+		}
+		r.latestChecks.Prime(keys...)
+	}
+	return nil
+}
+
+func checkKey(client, command bson.ObjectId) string {
+	return client.Hex() + ":" + command.Hex()
+}
+
+func splitCheckKey(key string) (client, command bson.ObjectId) {
+	ids := strings.SplitN(key, ":", 2)
+	return bson.ObjectIdHex(ids[0]), bson.ObjectIdHex(ids[1])
+}
+
+func objectIDs(keys []string) []bson.ObjectId {
+	ids := make([]bson.ObjectId, 0, len(keys))
+	for _, key := range keys {
+		ids = append(ids, bson.ObjectIdHex(key))
+	}
+	return ids
+}
+
+func hexIDs(ids []bson.ObjectId) []string {
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, id.Hex())
+	}
+	return keys
+}
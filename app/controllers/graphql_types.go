@@ -0,0 +1,481 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/graph-gophers/graphql-go"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/query"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// graphRoot resolves the fields of the `Query` type
+type graphRoot struct{}
+
+// graphListArgs are the arguments of the lists of the `Query` type
+type graphListArgs struct {
+	Filter *string
+	Sort   *string
+	First  *int32
+}
+
+// options converts the arguments to the filter, storage sort and limit of
+// the entities of the fields
+func (args graphListArgs) options(fields query.Fields) (utils.Filter, utils.Sort, int, error) {
+	filter := utils.Filter{}
+	if args.Filter != nil {
+		f, err := fields.Filter(*args.Filter)
+		if err != nil {
+			return nil, nil, 0, errors.New("invalid filter: " + err.Error())
+		}
+		filter = f
+	}
+
+	sort := models.DefaultSort
+	if args.Sort != nil {
+		s, err := fields.Sort(*args.Sort)
+		if err != nil {
+			return nil, nil, 0, errors.New("invalid sort: " + err.Error())
+		}
+		sort = s
+	}
+
+	cfg := aah.AppConfig()
+	limit := cfg.IntDefault("api.pagination.default_limit", 100)
+	if args.First != nil {
+		if *args.First <= 0 {
+			return nil, nil, 0, errors.New("first has to be a positive number")
+		}
+		limit = int(*args.First)
+	}
+	if max := cfg.IntDefault("api.pagination.max_limit", 1000); limit > max {
+		limit = max
+	}
+	return filter, models.Cursor{Sort: sort}.StorageSort(fields), limit, nil
+}
+
+// graphID converts an ID argument to an ObjectId
+func graphID(id graphql.ID) (bson.ObjectId, error) {
+	if !bson.IsObjectIdHex(string(id)) {
+		return "", errors.New("ID is not a valid ObjectId")
+	}
+	return bson.ObjectIdHex(string(id)), nil
+}
+
+// graphError logs the cause of an internal error and returns the error
+// shown to the user
+func graphError(err error) error {
+	if err == errGraphForbidden {
+		return err
+	}
+	log.Errorf("error resolving a GraphQL query: %v", err)
+	return errGraphInternal
+}
+
+func (*graphRoot) Clients(ctx context.Context, args graphListArgs) ([]*clientResolver, error) {
+	filter, sort, limit, err := args.options(models.ClientFields)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	clients, err := r.findClients(filter, sort, limit)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	resolvers := make([]*clientResolver, 0, len(clients))
+	for _, c := range clients {
+		r.clients.Set(c.ID.Hex(), c)
+		resolvers = append(resolvers, newClientResolver(r, c))
+	}
+	return resolvers, nil
+}
+
+func (*graphRoot) Client(ctx context.Context, args struct{ ID graphql.ID }) (*clientResolver, error) {
+	id, err := graphID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	c, err := r.client(id)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return newClientResolver(r, *c), nil
+}
+
+func (*graphRoot) Commands(ctx context.Context, args graphListArgs) ([]*commandResolver, error) {
+	filter, sort, limit, err := args.options(models.CommandFields)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	commands, err := r.findCommands(filter, sort, limit)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	resolvers := make([]*commandResolver, 0, len(commands))
+	for _, c := range commands {
+		r.commands.Set(c.ID.Hex(), c)
+		resolvers = append(resolvers, newCommandResolver(r, c))
+	}
+	return resolvers, nil
+}
+
+func (*graphRoot) Command(ctx context.Context, args struct{ ID graphql.ID }) (*commandResolver, error) {
+	id, err := graphID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	v, err := r.commands.Load(id.Hex())
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return newCommandResolver(r, v.(storageModel.Command)), nil
+}
+
+func (*graphRoot) Groups(ctx context.Context, args graphListArgs) ([]*groupResolver, error) {
+	filter, sort, limit, err := args.options(models.GroupFields)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	groups, err := r.findGroups(filter, sort, limit)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	resolvers := make([]*groupResolver, 0, len(groups))
+	for _, g := range groups {
+		r.groups.Set(g.ID.Hex(), g)
+		resolvers = append(resolvers, newGroupResolver(r, g))
+	}
+	return resolvers, nil
+}
+
+func (*graphRoot) Group(ctx context.Context, args struct{ ID graphql.ID }) (*groupResolver, error) {
+	id, err := graphID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	v, err := r.groups.Load(id.Hex())
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return newGroupResolver(r, v.(storageModel.Group)), nil
+}
+
+// Check returns a check of a client the user can read
+func (*graphRoot) Check(ctx context.Context, args struct{ ID graphql.ID }) (*checkResolver, error) {
+	id, err := graphID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	r := graphRequestOf(ctx)
+	if err := r.permit("checks:read"); err != nil {
+		return nil, err
+	}
+	checks, err := r.findChecks(utils.Filter{"_id": id}, 1)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if len(checks) <= 0 {
+		return nil, nil
+	}
+	c, err := r.client(checks[0].ClientID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if c == nil {
+		return nil, nil
+	}
+	return newCheckResolver(r, checks[0]), nil
+}
+
+// clientResolver resolves the fields of a client
+type clientResolver struct {
+	r *graphRequest
+	c storageModel.Client
+}
+
+// newClientResolver returns the resolver of a client and queues its groups
+// to be loaded with those of the other clients
+func newClientResolver(r *graphRequest, c storageModel.Client) *clientResolver {
+	r.groups.Prime(hexIDs(c.GroupIDs)...)
+	r.mu.Lock()
+	r.seen = append(r.seen, c)
+	r.mu.Unlock()
+	return &clientResolver{r: r, c: c}
+}
+
+func (c *clientResolver) ID() graphql.ID          { return graphql.ID(c.c.ID.Hex()) }
+func (c *clientResolver) Name() string            { return c.c.Name }
+func (c *clientResolver) IP() string              { return c.c.IP }
+func (c *clientResolver) CreatedAt() graphql.Time { return graphql.Time{Time: c.c.CreatedAt} }
+func (c *clientResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: c.c.UpdatedAt} }
+
+func (c *clientResolver) Groups() ([]*groupResolver, error) {
+	groups, err := c.r.clientGroups(c.c)
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	resolvers := make([]*groupResolver, 0, len(groups))
+	for _, g := range groups {
+		resolvers = append(resolvers, newGroupResolver(c.r, g))
+	}
+	return resolvers, nil
+}
+
+func (c *clientResolver) LatestChecks() ([]*checkResolver, error) {
+	if err := c.r.permit("checks:read"); err != nil {
+		return nil, err
+	}
+	if err := c.r.primeLatestChecks(); err != nil {
+		return nil, graphError(err)
+	}
+	keys, err := c.r.latestCheckKeys(c.c)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	values, err := c.r.latestChecks.LoadMany(keys)
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	resolvers := make([]*checkResolver, 0, len(values))
+	for _, v := range values {
+		resolvers = append(resolvers, newCheckResolver(c.r, v.(storageModel.Check)))
+	}
+	return resolvers, nil
+}
+
+func (c *clientResolver) Checks(args struct {
+	CommandID *graphql.ID
+	First     *int32
+}) ([]*checkResolver, error) {
+	if err := c.r.permit("checks:read"); err != nil {
+		return nil, err
+	}
+
+	filter := utils.Filter{"client_id": c.c.ID}
+	if args.CommandID != nil {
+		id, err := graphID(*args.CommandID)
+		if err != nil {
+			return nil, err
+		}
+		filter["command_id"] = id
+	}
+
+	listArgs := graphListArgs{First: args.First}
+	_, _, limit, err := listArgs.options(models.CheckFields)
+	if err != nil {
+		return nil, err
+	}
+
+	checks, err := c.r.findChecks(filter, limit)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	resolvers := make([]*checkResolver, 0, len(checks))
+	for _, check := range checks {
+		resolvers = append(resolvers, newCheckResolver(c.r, check))
+	}
+	return resolvers, nil
+}
+
+// groupResolver resolves the fields of a group
+type groupResolver struct {
+	r *graphRequest
+	g storageModel.Group
+}
+
+// newGroupResolver returns the resolver of a group and queues its commands
+// and clients to be loaded with those of the other groups
+func newGroupResolver(r *graphRequest, g storageModel.Group) *groupResolver {
+	for _, gc := range g.Commands {
+		r.commands.Prime(gc.CommandID.Hex())
+	}
+	r.groupClients.Prime(g.ID.Hex())
+	return &groupResolver{r: r, g: g}
+}
+
+func (g *groupResolver) ID() graphql.ID          { return graphql.ID(g.g.ID.Hex()) }
+func (g *groupResolver) Name() string            { return g.g.Name }
+func (g *groupResolver) CreatedAt() graphql.Time { return graphql.Time{Time: g.g.CreatedAt} }
+func (g *groupResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: g.g.UpdatedAt} }
+
+func (g *groupResolver) Commands() []*groupCommandResolver {
+	resolvers := make([]*groupCommandResolver, 0, len(g.g.Commands))
+	for _, gc := range g.g.Commands {
+		resolvers = append(resolvers, &groupCommandResolver{r: g.r, gc: gc})
+	}
+	return resolvers
+}
+
+func (g *groupResolver) Clients() ([]*clientResolver, error) {
+	v, err := g.r.groupClients.Load(g.g.ID.Hex())
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	clients, _ := v.([]storageModel.Client)
+	resolvers := make([]*clientResolver, 0, len(clients))
+	for _, c := range clients {
+		resolvers = append(resolvers, newClientResolver(g.r, c))
+	}
+	return resolvers, nil
+}
+
+// groupCommandResolver resolves the fields of a command of a group
+type groupCommandResolver struct {
+	r  *graphRequest
+	gc storageModel.GroupCommand
+}
+
+func (gc *groupCommandResolver) ID() graphql.ID   { return graphql.ID(gc.gc.ID.Hex()) }
+func (gc *groupCommandResolver) NextCheck() int32 { return int32(gc.gc.NextCheck) }
+func (gc *groupCommandResolver) StopError() bool  { return gc.gc.StopError }
+
+// Command returns the command if the user can read it
+func (gc *groupCommandResolver) Command() (*commandResolver, error) {
+	v, err := gc.r.commands.Load(gc.gc.CommandID.Hex())
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return newCommandResolver(gc.r, v.(storageModel.Command)), nil
+}
+
+// commandResolver resolves the fields of a command
+type commandResolver struct {
+	r *graphRequest
+	c storageModel.Command
+}
+
+// newCommandResolver returns the resolver of a command and queues the
+// groups running it to be loaded with those of the other commands
+func newCommandResolver(r *graphRequest, c storageModel.Command) *commandResolver {
+	r.commandGroups.Prime(c.ID.Hex())
+	return &commandResolver{r: r, c: c}
+}
+
+func (c *commandResolver) ID() graphql.ID          { return graphql.ID(c.c.ID.Hex()) }
+func (c *commandResolver) Command() string         { return c.c.Command }
+func (c *commandResolver) Name() string            { return c.c.Name }
+func (c *commandResolver) Description() string     { return c.c.Description }
+func (c *commandResolver) Format() string          { return c.c.Format }
+func (c *commandResolver) CreatedAt() graphql.Time { return graphql.Time{Time: c.c.CreatedAt} }
+func (c *commandResolver) UpdatedAt() graphql.Time { return graphql.Time{Time: c.c.UpdatedAt} }
+
+func (c *commandResolver) Groups() ([]*groupResolver, error) {
+	v, err := c.r.commandGroups.Load(c.c.ID.Hex())
+	if err != nil {
+		return nil, graphError(err)
+	}
+
+	groups, _ := v.([]storageModel.Group)
+	resolvers := make([]*groupResolver, 0, len(groups))
+	for _, g := range groups {
+		resolvers = append(resolvers, newGroupResolver(c.r, g))
+	}
+	return resolvers, nil
+}
+
+// LatestCheck returns the latest check of the command on a client the user
+// can read
+func (c *commandResolver) LatestCheck(args struct{ ClientID graphql.ID }) (*checkResolver, error) {
+	if err := c.r.permit("checks:read"); err != nil {
+		return nil, err
+	}
+
+	id, err := graphID(args.ClientID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := c.r.client(id)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if client == nil {
+		return nil, nil
+	}
+
+	v, err := c.r.latestChecks.Load(checkKey(id, c.c.ID))
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return newCheckResolver(c.r, v.(storageModel.Check)), nil
+}
+
+// checkResolver resolves the fields of a check
+type checkResolver struct {
+	r *graphRequest
+	c storageModel.Check
+}
+
+// newCheckResolver returns the resolver of a check and queues its client
+// and command to be loaded with those of the other checks
+func newCheckResolver(r *graphRequest, c storageModel.Check) *checkResolver {
+	r.clients.Prime(c.ClientID.Hex())
+	r.commands.Prime(c.CommandID.Hex())
+	return &checkResolver{r: r, c: c}
+}
+
+func (c *checkResolver) ID() graphql.ID          { return graphql.ID(c.c.ID.Hex()) }
+func (c *checkResolver) Response() string        { return c.c.Response }
+func (c *checkResolver) Error() bool             { return c.c.Error }
+func (c *checkResolver) Finished() bool          { return c.c.Finished }
+func (c *checkResolver) Checked() bool           { return c.c.Checked }
+func (c *checkResolver) CreatedAt() graphql.Time { return graphql.Time{Time: c.c.CreatedAt} }
+
+// Client returns the client of the check if the user can read it
+func (c *checkResolver) Client() (*clientResolver, error) {
+	client, err := c.r.client(c.c.ClientID)
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if client == nil {
+		return nil, nil
+	}
+	return newClientResolver(c.r, *client), nil
+}
+
+// Command returns the command of the check if the user can read it
+func (c *checkResolver) Command() (*commandResolver, error) {
+	v, err := c.r.commands.Load(c.c.CommandID.Hex())
+	if err != nil {
+		return nil, graphError(err)
+	}
+	if v == nil {
+		return nil, nil
+	}
+	return newCommandResolver(c.r, v.(storageModel.Command)), nil
+}
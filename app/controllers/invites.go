@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// defaultInviteTTL is how long an invite is valid unless told otherwise
+const defaultInviteTTL = 72 * time.Hour
+
+// InvitesController controller for signup invitations
+type InvitesController struct {
+	*aah.Context
+}
+
+// CreateInvite creates a single-use invitation, the token is only returned once
+func (a *InvitesController) CreateInvite(create models.InviteCreate) {
+	if !containsString(aah.AppConfig().KeysByPath("security.roles"), create.Role) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Unknown role " + create.Role})
+		return
+	}
+
+	ttl := defaultInviteTTL
+	if create.ExpiresIn != "" {
+		d, err := time.ParseDuration(create.ExpiresIn)
+		if err != nil || d <= 0 {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid expiry (expires_in)"})
+			return
+		}
+		ttl = d
+	}
+
+	token, err := security.RandomToken(32)
+	if err != nil {
+		log.Errorf("error generating invite token: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	invite := models.Invite{
+		ID:        bson.NewObjectId(),
+		TokenHash: security.HashToken(token),
+		Email:     create.Email,
+		Role:      create.Role,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedBy: bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value),
+		CreatedAt: time.Now(),
+	}
+
+	err = models.Create(models.InvitesCollection, invite)
+	if err != nil {
+		log.Debugf("error creating invite: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditCreate, "invite", invite.ID.Hex(), nil, invite)
+
+	invite.TokenHash = ""
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully created the invite", Data: map[string]interface{}{
+		"token":  token,
+		"invite": invite,
+	}})
+}
+
+// GetInvites returns all invites
+func (a *InvitesController) GetInvites() {
+	invites, err := models.FindInvites(utils.Filter{})
+	if err != nil {
+		log.Debugf("error finding invites: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	for i := range invites {
+		invites[i].TokenHash = ""
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found all invites", Data: invites})
+}
+
+// RevokeInvite deletes an invite so it can no longer be used
+func (a *InvitesController) RevokeInvite(revoke models.InviteID) {
+	if !bson.IsObjectIdHex(revoke.ID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return
+	}
+
+	// keep the invite for the audit log
+	invites, err := models.FindInvites(utils.Filter{"_id": bson.ObjectIdHex(revoke.ID)})
+	if err != nil {
+		log.Debugf("error finding invite: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if len(invites) <= 0 {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Can't find an invite with this ID"})
+		return
+	}
+
+	del := utils.DeleteOptions{
+		Filter: utils.Filter{"_id": bson.ObjectIdHex(revoke.ID)},
+	}
+
+	err = models.Delete(models.InvitesCollection, del)
+	if err != nil {
+		log.Debugf("error deleting invite: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditDelete, "invite", revoke.ID, invites[0], nil)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully revoked the invite"})
+}
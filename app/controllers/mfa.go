@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// MFAController controller for TOTP two-factor authentication
+type MFAController struct {
+	*aah.Context
+}
+
+// EnrollMFA generates a new TOTP secret for the authenticated user, it is not
+// used for logins until it has been confirmed with EnableMFA
+func (a *MFAController) EnrollMFA() {
+	user, err := GetUserFromContext(a.Context)
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if user.TOTPEnabled {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Two-factor authentication is already enabled"})
+		return
+	}
+
+	secret, err := security.GenerateTOTPSecret()
+	if err != nil {
+		log.Errorf("error generating totp secret: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	err = models.UpdateUser(user.ID, bson.M{"totp_secret": secret, "totp_enabled": false})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Confirm the enrollment with a code from the authenticator", Data: models.MFAEnrollment{
+		Secret: secret,
+		URI:    security.TOTPURI(user.Username, secret),
+	}})
+}
+
+// EnableMFA confirms the enrollment with a TOTP code and returns the recovery
+// codes. When the request completes a pending login, a token pair is returned as well.
+func (a *MFAController) EnableMFA(confirm models.MFACode) {
+	user, err := GetUserFromContext(a.Context)
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if user.TOTPEnabled {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Two-factor authentication is already enabled"})
+		return
+	}
+	if user.TOTPSecret == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Start the enrollment first"})
+		return
+	}
+	if !checkCode(a.Context, user, func() (bool, error) {
+		return security.ValidateTOTP(user, confirm.Code)
+	}) {
+		return
+	}
+
+	codes, hashes, err := security.GenerateRecoveryCodes()
+	if err != nil {
+		log.Errorf("error generating recovery codes: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	err = models.UpdateUser(user.ID, bson.M{"totp_enabled": true, "recovery_codes": hashes})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	enabled := models.MFAEnabled{RecoveryCodes: codes}
+	if security.PrincipalClaim(a.Subject().AuthenticationInfo, security.MFAPendingClaim) != "" {
+		if claims, err := security.ParseToken(security.BearerToken(a.Req.Header.Get("Authorization")), security.MFAToken); err == nil {
+			if err := security.RevokeToken(claims); err != nil {
+				log.Errorf("error revoking mfa token: %v", err)
+			}
+		}
+
+		enabled.Token, err = startSession(a.Context, user, models.SessionMFA)
+		if err != nil {
+			log.Errorf("error starting session: %v", err)
+			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully enabled two-factor authentication", Data: enabled})
+}
+
+// DisableMFA turns off two-factor authentication, unless a role of the user requires it
+func (a *MFAController) DisableMFA(disable models.MFADisable) {
+	user, err := GetUserFromContext(a.Context)
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if security.MFARequired(user) {
+		a.Reply().Forbidden().JSON(models.Response{Message: "Two-factor authentication is required for your role"})
+		return
+	}
+	if !checkCode(a.Context, user, func() (bool, error) {
+		if !models.CheckPassword(user.Password, disable.Password) {
+			return false, nil
+		}
+		return security.VerifyMFA(user, disable.Code)
+	}) {
+		return
+	}
+
+	err = models.UpdateUser(user.ID, bson.M{"totp_enabled": false, "totp_secret": "", "recovery_codes": []string{}})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully disabled two-factor authentication"})
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes of the authenticated user
+func (a *MFAController) RegenerateRecoveryCodes(confirm models.MFACode) {
+	user, err := GetUserFromContext(a.Context)
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if !user.TOTPEnabled {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Two-factor authentication has to be enabled first"})
+		return
+	}
+	if !checkCode(a.Context, user, func() (bool, error) {
+		return security.ValidateTOTP(user, confirm.Code)
+	}) {
+		return
+	}
+
+	codes, hashes, err := security.GenerateRecoveryCodes()
+	if err != nil {
+		log.Errorf("error generating recovery codes: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	err = models.UpdateUser(user.ID, bson.M{"recovery_codes": hashes})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully generated new recovery codes", Data: models.MFAEnabled{RecoveryCodes: codes}})
+}
+
+// LoginMFA exchanges the mfa token of a login and a TOTP or recovery code for a token pair
+func (a *MFAController) LoginMFA(login models.MFALogin) {
+	if login.MFAToken == "" || login.Code == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Missing mfa token or code"})
+		return
+	}
+
+	claims, err := security.ParseToken(login.MFAToken, security.MFAToken)
+	if err != nil {
+		a.Reply().Unauthorized().JSON(models.Response{Message: "Invalid mfa token"})
+		return
+	}
+
+	id, _ := claims["uuid"].(string)
+	user, err := models.FindUserWithID(id)
+	if err != nil {
+		a.Reply().Unauthorized().JSON(models.Response{Message: "Invalid mfa token"})
+		return
+	}
+	if user.IsLocked || user.IsExpired {
+		a.Reply().Forbidden().JSON(models.Response{Message: "This account is locked or expired"})
+		return
+	}
+	if !user.TOTPEnabled {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Two-factor authentication has to be enabled first"})
+		return
+	}
+	if !checkCode(a.Context, user, func() (bool, error) {
+		return security.VerifyMFA(user, login.Code)
+	}) {
+		return
+	}
+
+	if err := security.RevokeToken(claims); err != nil {
+		log.Errorf("error revoking mfa token: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	jsontoken, err := startSession(a.Context, user, models.SessionMFA)
+	if err != nil {
+		log.Errorf("error starting session: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully logged in", Data: jsontoken})
+}
+
+// checkCode runs verify, which checks a code of the user, and replies when
+// it fails. Codes are short, so the failed attempts are limited like
+// passwords, shared by all the endpoints taking a code.
+func checkCode(ctx *aah.Context, user *storageModel.User, verify func() (bool, error)) bool {
+	key := "mfa:" + user.ID.Hex()
+	wait, err := security.Logins.Reserve(key)
+	if err != nil {
+		log.Errorf("error checking login limits: %v", err)
+		ctx.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return false
+	}
+	if wait > 0 {
+		ctx.Reply().Header("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		ctx.Reply().Status(http.StatusTooManyRequests).JSON(models.Response{Message: "Too many failed attempts, try again later"})
+		return false
+	}
+
+	ok, err := verify()
+	if err != nil {
+		if err := security.Logins.Release(key); err != nil {
+			log.Errorf("error releasing login attempt: %v", err)
+		}
+		log.Debugf("error verifying code: %v", err)
+		ctx.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return false
+	}
+	if !ok {
+		ctx.Reply().BadRequest().JSON(models.Response{Message: "Invalid code"})
+		return false
+	}
+	if err := security.Logins.Reset(key); err != nil {
+		log.Errorf("error resetting failed logins: %v", err)
+	}
+	return true
+}
@@ -0,0 +1,193 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"html/template"
+	"path/filepath"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/openapi"
+	storageModel "github.com/keiwi/utils/models"
+)
+
+// checksBetweenQuery are the query parameters limiting the checks of a
+// client to a command and dates
+var checksBetweenQuery = []*openapi.Parameter{
+	{Name: "command_id", In: "query", Schema: &openapi.Schema{Type: "string"}, Description: "Only the checks of the command, created between `from` and `to`."},
+	{Name: "from", In: "query", Schema: &openapi.Schema{Type: "string", Format: "date-time"}, Description: "Required with `command_id`."},
+	{Name: "to", In: "query", Schema: &openapi.Schema{Type: "string", Format: "date-time"}, Description: "Required with `command_id`."},
+}
+
+// openAPISpec is the OpenAPI document of the routes, generated on start
+var openAPISpec []byte
+
+// openAPIActions describes the replies of the actions returning entities and
+// the actions taking a list query or a patch
+var openAPIActions = map[string]openapi.Action{
+	"ClientsController.CreateClient":    {Data: storageModel.Client{}},
+	"ClientsController.GetClients":      {Data: []storageModel.Client{}, List: models.ClientFields},
+	"ClientsController.GetClientWithID": {Data: storageModel.Client{}},
+	"ClientsController.EditClient":      {Data: storageModel.Client{}},
+	"ClientsV2Controller.List":          {Summary: "List clients", Data: []storageModel.Client{}, List: models.ClientFields},
+	"ClientsV2Controller.Get":           {Summary: "Get a client", Data: storageModel.Client{}},
+	"ClientsV2Controller.Create":        {Summary: "Create a client", Data: storageModel.Client{}},
+	"ClientsV2Controller.Update":        {Summary: "Patch a client", Data: storageModel.Client{}, Patch: models.ClientSchema},
+	"ClientsV2Controller.Delete":        {Summary: "Delete a client"},
+	"ClientsV2Controller.Checks":        {Summary: "List the checks of a client", Data: []storageModel.Check{}, List: models.CheckFields, Query: checksBetweenQuery},
+	"ClientsV2Controller.BulkCreate":    {Data: []models.BulkResult{}},
+	"ClientsV2Controller.BulkUpdate":    {Data: []models.BulkResult{}},
+	"ClientsV2Controller.BulkDelete":    {Data: []models.BulkResult{}},
+
+	"CommandsController.CreateCommand":    {Data: storageModel.Command{}},
+	"CommandsController.GetCommands":      {Data: []storageModel.Command{}, List: models.CommandFields},
+	"CommandsController.GetCommandWithID": {Data: storageModel.Command{}},
+	"CommandsController.EditCommand":      {Data: storageModel.Command{}},
+	"CommandsV2Controller.List":           {Summary: "List commands", Data: []storageModel.Command{}, List: models.CommandFields},
+	"CommandsV2Controller.Get":            {Summary: "Get a command", Data: storageModel.Command{}},
+	"CommandsV2Controller.Create":         {Summary: "Create a command", Data: storageModel.Command{}},
+	"CommandsV2Controller.Update":         {Summary: "Patch a command", Data: storageModel.Command{}, Patch: models.CommandSchema},
+	"CommandsV2Controller.Delete":         {Summary: "Delete a command"},
+	"CommandsV2Controller.BulkCreate":     {Data: []models.BulkResult{}},
+	"CommandsV2Controller.BulkUpdate":     {Data: []models.BulkResult{}},
+	"CommandsV2Controller.BulkDelete":     {Data: []models.BulkResult{}},
+
+	"GroupsController.CreateGroup":         {Data: storageModel.Group{}},
+	"GroupsController.EditGroup":           {Data: storageModel.Group{}},
+	"GroupsController.GetGroups":           {Data: []storageModel.Group{}, List: models.GroupFields},
+	"GroupsController.GetGroupWithID":      {Data: storageModel.Group{}},
+	"GroupsController.ExistsGroup":         {Data: false},
+	"GroupsV2Controller.List":              {Summary: "List groups", Data: []storageModel.Group{}, List: models.GroupFields},
+	"GroupsV2Controller.Get":               {Summary: "Get a group", Data: storageModel.Group{}},
+	"GroupsV2Controller.Create":            {Summary: "Add a command to a group", Data: storageModel.Group{}},
+	"GroupsV2Controller.Update":            {Summary: "Patch a group", Data: storageModel.Group{}, Patch: models.GroupSchema},
+	"GroupsV2Controller.Delete":            {Summary: "Delete a group"},
+	"GroupsV2Controller.BulkAddMembers":    {Data: []models.BulkResult{}},
+	"GroupsV2Controller.BulkRemoveMembers": {Data: []models.BulkResult{}},
+
+	"ChecksController.GetChecks":                      {Data: []storageModel.Check{}, List: models.CheckFields},
+	"ChecksController.GetCheckWithID":                 {Data: storageModel.Check{}},
+	"ChecksController.GetWithClientID":                {Data: []storageModel.Check{}, List: models.CheckFields},
+	"ChecksController.GetWithClientIDAndCommandID":    {Data: []storageModel.Check{}},
+	"ChecksController.GetWithChecksBetweenDateClient": {Data: []storageModel.Check{}, List: models.CheckFields},
+	"ChecksV2Controller.List":                         {Summary: "List checks", Data: []storageModel.Check{}, List: models.CheckFields},
+	"ChecksV2Controller.Get":                          {Summary: "Get a check", Data: storageModel.Check{}},
+	"ChecksV2Controller.Delete":                       {Summary: "Delete a check"},
+}
+
+// openAPIControllers are nil pointers to the controllers of the routes
+var openAPIControllers = []interface{}{
+	(*ACLController)(nil),
+	(*APIKeysController)(nil),
+	(*AccountController)(nil),
+	(*AuditController)(nil),
+	(*ChecksController)(nil),
+	(*ChecksV2Controller)(nil),
+	(*ClientsController)(nil),
+	(*ClientsV2Controller)(nil),
+	(*CommandsController)(nil),
+	(*CommandsV2Controller)(nil),
+	(*GroupsController)(nil),
+	(*GraphQLController)(nil),
+	(*GroupsV2Controller)(nil),
+	(*InvitesController)(nil),
+	(*MFAController)(nil),
+	(*OpenAPIController)(nil),
+	(*OrgsController)(nil),
+	(*SSOController)(nil),
+	(*SessionsController)(nil),
+	(*UserAdminController)(nil),
+	(*UsersController)(nil),
+	(*WellKnownController)(nil),
+}
+
+// LoadOpenAPI generates the OpenAPI document of the routes, the document
+// is left out if it can't be generated
+func LoadOpenAPI(_ *aah.Event) {
+	routes, err := openapi.LoadRoutes(filepath.Join(aah.AppBaseDir(), "config", "routes.conf"))
+	if err != nil {
+		log.Errorf("unable to generate the OpenAPI document: %v", err)
+		return
+	}
+
+	g := &openapi.Generator{
+		Info: openapi.Info{
+			Title:       aah.AppConfig().StringDefault("api.docs.title", "keiwi API"),
+			Description: "Every reply is wrapped in a `Response`, the entities are its `data`.",
+			Version:     aah.AppBuildInfo().Version,
+		},
+		Controllers: openAPIControllers,
+		Actions:     openAPIActions,
+		Envelope:    models.Response{},
+		Deprecated:  legacyRoutes,
+		SecuritySchemes: map[string]*openapi.SecurityScheme{
+			"bearer": {Type: "http", Scheme: "bearer", BearerFormat: "JWT", Description: "Token issued on login"},
+			"apiKey": {Type: "apiKey", In: "header", Name: "Authorization", Description: "API key sent as `ApiKey <key>`"},
+			"basic":  {Type: "http", Scheme: "basic"},
+		},
+	}
+	doc, err := g.Generate(routes)
+	if err == nil {
+		openAPISpec, err = json.Marshal(doc)
+	}
+	if err != nil {
+		log.Errorf("unable to generate the OpenAPI document: %v", err)
+	}
+}
+
+// redocPage renders the OpenAPI document with Redoc
+var redocPage = template.Must(template.New("redoc").Parse(`<!DOCTYPE html>
+<html>
+  <head>
+    <title>{{.Title}}</title>
+    <meta charset="utf-8">
+    <meta name="viewport" content="width=device-width, initial-scale=1">
+  </head>
+  <body>
+    <redoc spec-url="{{.Spec}}"></redoc>
+    <script src="{{.Script}}"></script>
+  </body>
+</html>
+`))
+
+// OpenAPIController serves the OpenAPI document of the API and its docs
+type OpenAPIController struct {
+	*aah.Context
+}
+
+// Spec returns the OpenAPI document
+func (a *OpenAPIController) Spec() {
+	if !aah.AppConfig().BoolDefault("api.docs.enable", true) {
+		a.Reply().NotFound().JSON(models.Response{Message: "The API docs are disabled"})
+		return
+	}
+	if openAPISpec == nil {
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	a.Reply().Ok().Bytes("application/json; charset=utf-8", openAPISpec)
+}
+
+// Docs returns the page rendering the OpenAPI document with Redoc
+func (a *OpenAPIController) Docs() {
+	cfg := aah.AppConfig()
+	if !cfg.BoolDefault("api.docs.enable", true) {
+		a.Reply().NotFound().JSON(models.Response{Message: "The API docs are disabled"})
+		return
+	}
+
+	var page bytes.Buffer
+	err := redocPage.Execute(&page, map[string]string{
+		"Title":  cfg.StringDefault("api.docs.title", "keiwi API"),
+		"Spec":   "/openapi.json",
+		"Script": cfg.StringDefault("api.docs.redoc_url", "https://cdn.jsdelivr.net/npm/redoc@2.0.0/bundles/redoc.standalone.js"),
+	})
+	if err != nil {
+		log.Errorf("unable to render the API docs: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	a.Reply().Ok().Bytes("text/html; charset=utf-8", page.Bytes())
+}
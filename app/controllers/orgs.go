@@ -0,0 +1,390 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/service"
+	"github.com/keiwi/utils"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// OrgHeader is the request header selecting the active organization of the
+// request, without it the oldest membership of the user is used
+const OrgHeader = service.OrgHeader
+
+// OrgsController controller for organizations and their members
+type OrgsController struct {
+	*aah.Context
+}
+
+// CreateOrg creates an organization with the authenticated user as its admin
+func (a *OrgsController) CreateOrg(create models.OrgCreate) {
+	create.Name = strings.TrimSpace(create.Name)
+	if create.Name == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Name is missing"})
+		return
+	}
+
+	has, err := models.Has(models.OrgsCollection, utils.HasOptions{Filter: utils.Filter{"name": create.Name}})
+	if err != nil {
+		log.Debugf("error finding organization: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if has {
+		a.Reply().BadRequest().JSON(models.Response{Message: "An organization with this name already exists"})
+		return
+	}
+
+	user := bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value)
+	org := models.Org{
+		ID:        bson.NewObjectId(),
+		Name:      create.Name,
+		CreatedBy: user,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := models.Create(models.OrgsCollection, org); err != nil {
+		log.Debugf("error creating organization: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditCreate, "org", org.ID.Hex(), nil, org)
+
+	member := models.OrgMembership{
+		ID:        bson.NewObjectId(),
+		OrgID:     org.ID,
+		UserID:    user,
+		Role:      models.OrgAdmin,
+		CreatedAt: time.Now(),
+	}
+	if err := models.Create(models.OrgMembersCollection, member); err != nil {
+		log.Debugf("error creating organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully created the organization", Data: org})
+}
+
+// GetOrgs returns the organizations of the authenticated user, or every
+// organization for users permitted to read them
+func (a *OrgsController) GetOrgs() {
+	filter := utils.Filter{}
+	if !a.Subject().IsPermitted("orgs:read") {
+		members, err := models.FindOrgMembers(utils.Filter{"user_id": bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value)})
+		if err != nil {
+			log.Debugf("error finding organization members: %v", err)
+			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+
+		ids := make([]bson.ObjectId, 0, len(members))
+		for _, m := range members {
+			ids = append(ids, m.OrgID)
+		}
+		filter["_id"] = bson.M{"$in": ids}
+	}
+
+	orgs, err := models.FindOrgs(filter)
+	if err != nil {
+		log.Debugf("error finding organizations: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found all organizations", Data: orgs})
+}
+
+// DeleteOrg deletes an organization that no longer owns any clients,
+// commands or groups
+func (a *OrgsController) DeleteOrg(delete models.OrgID) {
+	org, ok := a.findOrg(delete.ID, true)
+	if !ok {
+		return
+	}
+
+	n := models.Conn
+	if n == nil {
+		log.Error("nats is not initialized")
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	data, err := bson.MarshalJSON(utils.HasOptions{Filter: utils.Filter{"org_id": org.ID}})
+	if err != nil {
+		log.Debugf("error marshaling data: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	for _, has := range []func(*nats.Conn, []byte) (bool, error){utilNats.HasClient, utilNats.HasCommand, utilNats.HasGroup} {
+		owns, err := has(n, data)
+		if err != nil {
+			log.Debugf("error finding organization entities: %v", err)
+			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+		if owns {
+			a.Reply().BadRequest().JSON(models.Response{Message: "The organization still owns clients, commands or groups"})
+			return
+		}
+	}
+
+	if err := models.Delete(models.OrgMembersCollection, utils.DeleteOptions{Filter: utils.Filter{"org_id": org.ID}}); err != nil {
+		log.Debugf("error deleting organization members: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if err := models.Delete(models.OrgsCollection, utils.DeleteOptions{Filter: utils.Filter{"_id": org.ID}}); err != nil {
+		log.Debugf("error deleting organization: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditDelete, "org", org.ID.Hex(), org, nil)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully deleted the organization"})
+}
+
+// GetOrgMembers returns the members of an organization
+func (a *OrgsController) GetOrgMembers(org models.OrgID) {
+	o, ok := a.findOrg(org.ID, false)
+	if !ok {
+		return
+	}
+
+	members, err := models.FindOrgMembers(utils.Filter{"org_id": o.ID})
+	if err != nil {
+		log.Debugf("error finding organization members: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	views := make([]models.OrgMemberView, 0, len(members))
+	for _, m := range members {
+		user, err := models.FindUserWithID(m.UserID.Hex())
+		if err == models.ErrUserNotFound {
+			continue
+		}
+		if err != nil {
+			log.Debugf("error finding user: %v", err)
+			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+		views = append(views, models.OrgMemberView{User: models.NewUserView(user), Role: m.Role, CreatedAt: m.CreatedAt})
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found all members", Data: views})
+}
+
+// AddOrgMember adds an existing user to an organization
+func (a *OrgsController) AddOrgMember(add models.OrgMemberAdd) {
+	if !models.IsOrgRole(add.Role) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Unknown organization role " + add.Role})
+		return
+	}
+
+	org, ok := a.findOrg(add.OrgID, true)
+	if !ok {
+		return
+	}
+
+	user, err := models.FindUserWithLogin(add.Login)
+	if err == models.ErrUserNotFound {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Can't find a user with this username or email"})
+		return
+	}
+	if err != nil {
+		log.Debugf("error finding user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	_, err = models.FindOrgMember(org.ID, user.ID)
+	if err == nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "The user is already a member of the organization"})
+		return
+	}
+	if err != models.ErrOrgMemberNotFound {
+		log.Debugf("error finding organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	member := models.OrgMembership{
+		ID:        bson.NewObjectId(),
+		OrgID:     org.ID,
+		UserID:    user.ID,
+		Role:      add.Role,
+		CreatedAt: time.Now(),
+	}
+	if err := models.Create(models.OrgMembersCollection, member); err != nil {
+		log.Debugf("error creating organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditCreate, "org_member", member.ID.Hex(), nil, member)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully added the member", Data: models.OrgMemberView{
+		User:      models.NewUserView(user),
+		Role:      member.Role,
+		CreatedAt: member.CreatedAt,
+	}})
+}
+
+// SetOrgMemberRole changes the role of a member of an organization
+func (a *OrgsController) SetOrgMemberRole(role models.OrgMemberRole) {
+	if !models.IsOrgRole(role.Role) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Unknown organization role " + role.Role})
+		return
+	}
+
+	member, ok := a.findMember(role.OrgID, role.UserID)
+	if !ok {
+		return
+	}
+	if member.Role == models.OrgAdmin && role.Role != models.OrgAdmin && !a.hasOtherAdmin(member) {
+		return
+	}
+
+	err := models.Update(models.OrgMembersCollection, utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": member.ID},
+		Updates: utils.Updates{"$set": bson.M{"role": role.Role}},
+	})
+	if err != nil {
+		log.Debugf("error updating organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	before := *member
+	member.Role = role.Role
+	audit(a.Context, models.AuditEdit, "org_member", member.ID.Hex(), before, member)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully saved the changes for the member", Data: member})
+}
+
+// RemoveOrgMember removes a member from an organization
+func (a *OrgsController) RemoveOrgMember(remove models.OrgMemberID) {
+	member, ok := a.findMember(remove.OrgID, remove.UserID)
+	if !ok {
+		return
+	}
+	if member.Role == models.OrgAdmin && !a.hasOtherAdmin(member) {
+		return
+	}
+
+	if err := models.Delete(models.OrgMembersCollection, utils.DeleteOptions{Filter: utils.Filter{"_id": member.ID}}); err != nil {
+		log.Debugf("error deleting organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditDelete, "org_member", member.ID.Hex(), member, nil)
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully removed the member"})
+}
+
+// findOrg looks up the organization and checks that the authenticated user
+// may see it, or manage its members, replying with an error if not. Users
+// permitted to read or write organizations may do so for every organization,
+// other users have to be a member, or an admin to manage it.
+func (a *OrgsController) findOrg(id string, manage bool) (*models.Org, bool) {
+	org, err := models.FindOrg(id)
+	if err == models.ErrOrgNotFound {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Can't find an organization with this ID"})
+		return nil, false
+	}
+	if err != nil {
+		log.Debugf("error finding organization: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return nil, false
+	}
+
+	permission := "orgs:read"
+	if manage {
+		permission = "orgs:write"
+	}
+	if a.Subject().IsPermitted(permission) {
+		return org, true
+	}
+
+	member, err := models.FindOrgMember(org.ID, bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value))
+	if err != nil && err != models.ErrOrgMemberNotFound {
+		log.Debugf("error finding organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return nil, false
+	}
+	if member == nil || manage && member.Role != models.OrgAdmin {
+		a.Reply().Forbidden().JSON(models.Response{Message: "You can't manage this organization"})
+		return nil, false
+	}
+	return org, true
+}
+
+// findMember looks up a member of an organization the authenticated user
+// manages, replying with an error if it can't be found
+func (a *OrgsController) findMember(orgID, userID string) (*models.OrgMembership, bool) {
+	if !bson.IsObjectIdHex(userID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "User ID is not a valid ObjectId"})
+		return nil, false
+	}
+
+	org, ok := a.findOrg(orgID, true)
+	if !ok {
+		return nil, false
+	}
+
+	member, err := models.FindOrgMember(org.ID, bson.ObjectIdHex(userID))
+	if err == models.ErrOrgMemberNotFound {
+		a.Reply().BadRequest().JSON(models.Response{Message: "The user is not a member of the organization"})
+		return nil, false
+	}
+	if err != nil {
+		log.Debugf("error finding organization member: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return nil, false
+	}
+	return member, true
+}
+
+// hasOtherAdmin checks that the organization of the admin has another admin,
+// replying with an error if not, so it can't be left without one
+func (a *OrgsController) hasOtherAdmin(admin *models.OrgMembership) bool {
+	admins, err := models.FindOrgMembers(utils.Filter{"org_id": admin.OrgID, "role": models.OrgAdmin})
+	if err != nil {
+		log.Debugf("error finding organization members: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return false
+	}
+	if len(admins) <= 1 {
+		a.Reply().BadRequest().JSON(models.Response{Message: "The organization must keep at least one admin"})
+		return false
+	}
+	return true
+}
+
+// activeOrg returns the organization the request is scoped to, the one
+// selected with OrgHeader or the oldest membership of the user, replying with
+// an error if there is none. It is empty when organizations are disabled.
+func activeOrg(ctx *aah.Context) (bson.ObjectId, bool) {
+	c, ok := requestCaller(ctx)
+	if !ok {
+		return "", false
+	}
+	return c.OrgID, true
+}
+
+// orgFilter scopes the filter to the active organization of the request,
+// replying with an error if there is none
+func orgFilter(ctx *aah.Context, filter utils.Filter) (utils.Filter, bool) {
+	c, ok := requestCaller(ctx)
+	if !ok {
+		return filter, false
+	}
+	return c.Scope(filter), true
+}
@@ -0,0 +1,40 @@
+package controllers
+
+import (
+	"strconv"
+
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/service"
+)
+
+// listQuery reads the `filter`, `sort`, `fields`, `limit`, `cursor` and
+// `total` query parameters of a list, replying with an error if the limit or
+// total can't be parsed. The rest is checked by the service layer.
+func listQuery(ctx *aah.Context) (service.ListQuery, bool) {
+	q := service.ListQuery{
+		Filter: ctx.Req.QueryValue("filter"),
+		Sort:   ctx.Req.QueryValue("sort"),
+		Fields: ctx.Req.QueryValue("fields"),
+		Cursor: ctx.Req.QueryValue("cursor"),
+	}
+
+	if v := ctx.Req.QueryValue("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			ctx.Reply().BadRequest().JSON(models.Response{Message: "Limit has to be a positive number"})
+			return q, false
+		}
+		q.Limit = limit
+	}
+
+	if v := ctx.Req.QueryValue("total"); v != "" {
+		total, err := strconv.ParseBool(v)
+		if err != nil {
+			ctx.Reply().BadRequest().JSON(models.Response{Message: "Total has to be true or false"})
+			return q, false
+		}
+		q.Total = total
+	}
+	return q, true
+}
@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/models"
+)
+
+// maxPatchSize is the largest patch document accepted
+const maxPatchSize = 1 << 20
+
+// readPatch reads the patch in the request body and its content type,
+// replying with an error if it can't be read or is too large
+func readPatch(ctx *aah.Context) (string, []byte, bool) {
+	contentType, _, _ := mime.ParseMediaType(ctx.Req.Header.Get("Content-Type"))
+
+	data, err := ioutil.ReadAll(io.LimitReader(ctx.Req.Unwrap().Body, maxPatchSize+1))
+	if err != nil {
+		ctx.Reply().BadRequest().JSON(models.Response{Message: "Unable to read the patch"})
+		return "", nil, false
+	}
+	if len(data) > maxPatchSize {
+		ctx.Reply().Status(http.StatusRequestEntityTooLarge).JSON(models.Response{Message: "The patch is too large"})
+		return "", nil, false
+	}
+	return contentType, data, true
+}
@@ -0,0 +1,117 @@
+package controllers
+
+import (
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SessionsController controller for the login sessions of the authenticated
+// user, and of any user for admins
+type SessionsController struct {
+	*aah.Context
+}
+
+// GetSessions returns the active sessions of the authenticated user
+func (a *SessionsController) GetSessions() {
+	id := bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value)
+	sessions, err := models.FindSessions(models.ActiveSessions(id))
+	if err != nil {
+		log.Debugf("error finding sessions: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	current := security.PrincipalClaim(a.Subject().AuthenticationInfo, security.SessionClaim)
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.Hex() == current
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found all sessions", Data: sessions})
+}
+
+// RevokeSession revokes one of the sessions of the authenticated user
+func (a *SessionsController) RevokeSession(revoke models.SessionID) {
+	if !bson.IsObjectIdHex(revoke.ID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return
+	}
+
+	a.revoke(utils.Filter{
+		"_id":     bson.ObjectIdHex(revoke.ID),
+		"user_id": bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value),
+	}, "Successfully revoked the session")
+}
+
+// RevokeOtherSessions revokes every session of the authenticated user but
+// the one of the request
+func (a *SessionsController) RevokeOtherSessions() {
+	filter := models.ActiveSessions(bson.ObjectIdHex(a.Subject().PrimaryPrincipal().Value))
+	if sid := security.PrincipalClaim(a.Subject().AuthenticationInfo, security.SessionClaim); bson.IsObjectIdHex(sid) {
+		filter["_id"] = bson.M{"$ne": bson.ObjectIdHex(sid)}
+	}
+
+	a.revoke(filter, "Successfully revoked all other sessions")
+}
+
+// GetUserSessions returns the active sessions of a user
+func (a *SessionsController) GetUserSessions(user models.UserID) {
+	if !bson.IsObjectIdHex(user.ID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return
+	}
+
+	sessions, err := models.FindSessions(models.ActiveSessions(bson.ObjectIdHex(user.ID)))
+	if err != nil {
+		log.Debugf("error finding sessions: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found all sessions", Data: sessions})
+}
+
+// RevokeUserSession revokes any session
+func (a *SessionsController) RevokeUserSession(revoke models.SessionID) {
+	if !bson.IsObjectIdHex(revoke.ID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return
+	}
+
+	a.revoke(utils.Filter{"_id": bson.ObjectIdHex(revoke.ID)}, "Successfully revoked the session")
+}
+
+// RevokeUserSessions revokes all sessions of a user
+func (a *SessionsController) RevokeUserSessions(user models.UserID) {
+	if !bson.IsObjectIdHex(user.ID) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return
+	}
+
+	a.revoke(models.ActiveSessions(bson.ObjectIdHex(user.ID)), "Successfully revoked all sessions of the user")
+}
+
+// revoke revokes the sessions matching the filter and replies with the message
+func (a *SessionsController) revoke(filter utils.Filter, message string) {
+	if err := models.RevokeSessions(filter); err != nil {
+		log.Debugf("error revoking sessions: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: message})
+}
+
+// startSession starts a session of the user for the client of the request and
+// returns its token pair as JSON
+func startSession(ctx *aah.Context, user *storageModel.User, method string) (string, error) {
+	sid, refreshID, err := security.StartSession(user.ID, method, security.RequestIP(ctx), ctx.Req.Header.Get("User-Agent"))
+	if err != nil {
+		return "", err
+	}
+	return security.GetJSONToken(user, sid, refreshID)
+}
@@ -0,0 +1,64 @@
+package controllers
+
+import (
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+)
+
+// SSOController controller for single sign-on through OpenID Connect
+type SSOController struct {
+	*aah.Context
+}
+
+// StartSSO starts a login at the identity provider, the client sends the
+// user to the returned URL
+func (a *SSOController) StartSSO() {
+	if security.SSO == nil {
+		a.Reply().NotFound().JSON(models.Response{Message: "Single sign-on is not enabled"})
+		return
+	}
+
+	url, err := security.SSO.StartLogin()
+	if err != nil {
+		log.Errorf("error starting sso login: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Data: models.SSOStart{URL: url}})
+}
+
+// FinishSSO completes the login with the code and state the identity provider
+// redirected back with, and returns the usual login reply
+func (a *SSOController) FinishSSO(callback models.SSOCallback) {
+	if security.SSO == nil {
+		a.Reply().NotFound().JSON(models.Response{Message: "Single sign-on is not enabled"})
+		return
+	}
+	if callback.Code == "" || callback.State == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Missing code or state"})
+		return
+	}
+
+	user, err := security.SSO.FinishLogin(callback.Code, callback.State)
+	switch err {
+	case nil:
+	case models.ErrUserTokenInvalid:
+		a.Reply().BadRequest().JSON(models.Response{Message: "Invalid or expired login, please try again"})
+		return
+	case security.ErrIdentityDenied:
+		a.Reply().Forbidden().JSON(models.Response{Message: "Your account is not allowed to use keiwi"})
+		return
+	case security.ErrIdentityUserExists:
+		a.Reply().BadRequest().JSON(models.Response{Message: "A local user with your username or email already exists"})
+		return
+	default:
+		log.Errorf("error finishing sso login: %v", err)
+		a.Reply().Unauthorized().JSON(models.Response{Message: "Single sign-on failed"})
+		return
+	}
+
+	replyLogin(a.Context, user, models.SessionSSO)
+}
@@ -0,0 +1,74 @@
+package controllers
+
+import (
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/service"
+)
+
+// UserAdminController controller for administrating users
+type UserAdminController struct {
+	*aah.Context
+}
+
+// GetUsers returns a page of users ordered by ID
+func (a *UserAdminController) GetUsers(list models.UserList) {
+	page, err := service.ListUsers(callerOf(a.Context), list)
+	if err != nil {
+		replyError(a.Context, err)
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully found users", Data: page})
+}
+
+// SetUserDisabled disables or enables a user, disabled users can't log in
+func (a *UserAdminController) SetUserDisabled(disable models.UserDisable) {
+	user, err := service.SetUserDisabled(callerOf(a.Context), disable)
+	if err != nil {
+		replyError(a.Context, err)
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully saved the changes for the user", Data: user})
+}
+
+// ResetUserPassword sets a new password for a user
+func (a *UserAdminController) ResetUserPassword(reset models.UserPasswordReset) {
+	if err := service.ResetUserPassword(callerOf(a.Context), reset); err != nil {
+		replyError(a.Context, err)
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully reset the password of the user"})
+}
+
+// SetUserRoles replaces the roles of a user
+func (a *UserAdminController) SetUserRoles(roles models.UserRoles) {
+	user, err := service.SetUserRoles(callerOf(a.Context), roles)
+	if err != nil {
+		replyError(a.Context, err)
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully saved the changes for the user", Data: user})
+}
+
+// DeleteUser deletes a user from the database
+func (a *UserAdminController) DeleteUser(delete models.UserID) {
+	if err := service.DeleteUser(callerOf(a.Context), delete.ID); err != nil {
+		replyError(a.Context, err)
+		return
+	}
+
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully deleted the user"})
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
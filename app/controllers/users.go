@@ -2,17 +2,19 @@ package controllers
 
 import (
 	"errors"
+	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"aahframework.org/aah.v0"
-	"github.com/auth0/go-jwt-middleware"
-	"github.com/dgrijalva/jwt-go"
+	"aahframework.org/log.v0"
 	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/api/app/service"
 	"github.com/keiwi/utils"
 	storageModel "github.com/keiwi/utils/models"
 	utilNats "github.com/keiwi/utils/nats"
-	"github.com/nats-io/go-nats"
-	"golang.org/x/crypto/bcrypt"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -26,15 +28,34 @@ func (a *UsersController) UserSignup(signup models.User) {
 		return
 	}
 
-	inter := a.Get("nats")
-	if inter == nil {
+	n := models.Conn
+	if n == nil {
+		log.Error("nats is not initialized")
 		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
 		return
 	}
 
-	n, ok := inter.(*nats.Conn)
-	if !ok {
-		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	role := aah.AppConfig().StringDefault("security.signup.default_role", "viewer")
+	var invite *models.Invite
+	if signup.Invite != "" {
+		invites, err := models.FindInvites(utils.Filter{"token_hash": security.HashToken(signup.Invite)})
+		if err != nil {
+			log.Debugf("error finding invite: %v", err)
+			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+		if len(invites) <= 0 || !invites[0].IsUsable() {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid or expired invitation"})
+			return
+		}
+		if invites[0].Email != "" && !strings.EqualFold(invites[0].Email, signup.Email) {
+			a.Reply().BadRequest().JSON(models.Response{Message: "The invitation is for another email"})
+			return
+		}
+		invite = &invites[0]
+		role = invite.Role
+	} else if !aah.AppConfig().BoolDefault("security.signup.open", true) {
+		a.Reply().Forbidden().JSON(models.Response{Message: "Signup requires an invitation"})
 		return
 	}
 
@@ -78,12 +99,14 @@ func (a *UsersController) UserSignup(signup models.User) {
 		return
 	}
 
-	passwordHash := HashPassword(signup.Username, signup.Password)
+	passwordHash := models.HashPassword(signup.Username, signup.Password)
 
 	user := storageModel.User{
+		ID:       bson.NewObjectId(),
 		Username: signup.Username,
 		Email:    signup.Email,
 		Password: passwordHash,
+		Roles:    []string{role},
 	}
 	user.CreatedAt = time.Now()
 	user.UpdatedAt = time.Now()
@@ -94,187 +117,291 @@ func (a *UsersController) UserSignup(signup models.User) {
 		return
 	}
 
+	// Use the invite before creating the user, so it can't sign up more
+	// than one user
+	if invite != nil {
+		err = models.UseInvite(invite.ID, user.ID)
+		if err == models.ErrInviteUnusable {
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid or expired invitation"})
+			return
+		}
+		if err != nil {
+			log.Errorf("error marking invite as used: %v", err)
+			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+	}
+
 	err = utilNats.CreateUser(n, data)
 	if err != nil {
+		if invite != nil {
+			if err := models.ReleaseInvite(invite.ID, user.ID); err != nil {
+				log.Errorf("error releasing invite: %v", err)
+			}
+		}
 		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
 		return
 	}
 
-	jsontoken := GetJSONToken(&user)
+	if user.Email != "" {
+		if err := sendUserToken(&user, user.Email, models.VerifyEmailToken); err != nil {
+			log.Errorf("error sending email verification: %v", err)
+		}
+	}
+
+	// A role requiring two-factor authentication has to enroll before it
+	// gets a session, like on login
+	if security.MFARequired(&user) {
+		replyLogin(a.Context, &user, models.SessionSignup)
+		return
+	}
+
+	jsontoken, err := startSession(a.Context, &user, models.SessionSignup)
+	if err != nil {
+		log.Errorf("error starting session: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
 	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully signed up", Data: jsontoken})
 }
 
 func (a *UsersController) UserLogin(login models.User) {
+	if login.Username == "" {
+		login.Username = login.Email
+	}
 	if login.Username == "" || login.Password == "" {
 		a.Reply().BadRequest().JSON(models.Response{Message: "Missing username or password"})
 		return
 	}
 
-	inter := a.Get("nats")
-	if inter == nil {
-		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	user, err := security.Login(login.Username, login.Password, security.IPKey(security.RequestIP(a.Context)))
+	if err != nil {
+		switch e := err.(type) {
+		case *security.TooManyAttemptsError:
+			a.Reply().Header("Retry-After", strconv.Itoa(int(e.RetryAfter.Seconds())+1))
+			a.Reply().Status(http.StatusTooManyRequests).JSON(models.Response{Message: "Too many failed login attempts, try again later"})
+		default:
+			if err != security.ErrInvalidLogin {
+				log.Errorf("error logging in: %v", err)
+			}
+			a.Reply().BadRequest().JSON(models.Response{Message: "Invalid username or password"})
+		}
 		return
 	}
+	replyLogin(a.Context, user, models.SessionPassword)
+}
 
-	n, ok := inter.(*nats.Conn)
-	if !ok {
-		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+// UserRefresh exchanges a refresh token for a new token pair, the refresh
+// token is rotated so every refresh token can only be used once. Using one
+// again revokes its session.
+func (a *UsersController) UserRefresh(refresh models.RefreshRequest) {
+	if refresh.RefreshToken == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Missing refresh token"})
 		return
 	}
 
-	findUser := utils.FindOptions{
-		Filter: utils.Filter{"username": login.Username},
-		Sort:   utils.Sort{"-created_at"},
-		Limit:  1,
+	claims, err := security.ParseToken(refresh.RefreshToken, security.RefreshToken)
+	if err != nil {
+		log.Debugf("error parsing refresh token: %v", err)
+		a.Reply().Unauthorized().JSON(models.Response{Message: "Invalid refresh token"})
+		return
 	}
 
-	data, err := bson.MarshalJSON(findUser)
+	id, _ := claims["uuid"].(string)
+	user, err := models.FindUserWithID(id)
 	if err != nil {
-		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		a.Reply().Unauthorized().JSON(models.Response{Message: "Invalid refresh token"})
+		return
+	}
+	if user.IsLocked || user.IsExpired {
+		a.Reply().Forbidden().JSON(models.Response{Message: "This account is locked or expired"})
+		return
+	}
+	if security.MFARequired(user) && !user.TOTPEnabled {
+		a.Reply().Forbidden().JSON(models.Response{Message: "Two-factor authentication is required, please log in again"})
 		return
 	}
 
-	users, err := utilNats.FindUser(n, data)
+	refreshID, err := security.RefreshSession(claims, security.RequestIP(a.Context), a.Req.Header.Get("User-Agent"))
+	if err == security.ErrRefreshTokenReused || err == security.ErrSessionRevoked {
+		a.Reply().Unauthorized().JSON(models.Response{Message: "Invalid refresh token"})
+		return
+	}
 	if err != nil {
+		log.Errorf("error refreshing session: %v", err)
 		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
 		return
 	}
 
-	var user storageModel.User
-	if len(users) <= 0 {
-		findUser := utils.FindOptions{
-			Filter: utils.Filter{"email": login.Email},
-			Sort:   utils.Sort{"-created_at"},
-			Limit:  1,
-		}
-
-		data, err := bson.MarshalJSON(findUser)
-		if err != nil {
-			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
-			return
-		}
-
-		users, err = utilNats.FindUser(n, data)
-		if err != nil {
-			a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
-			return
-		}
+	sid, _ := claims["sid"].(string)
+	jsontoken, err := security.GetJSONToken(user, sid, refreshID)
+	if err != nil {
+		log.Errorf("error signing token: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully refreshed the token", Data: jsontoken})
+}
 
-		if len(users) <= 0 {
-			a.Reply().BadRequest().JSON(models.Response{Message: "Username or email not found"})
-			return
-		} else {
-			user = users[0]
-		}
-	} else {
-		user = users[0]
+// UserLogout revokes the session of the request, along with all its tokens
+func (a *UsersController) UserLogout() {
+	sid := security.PrincipalClaim(a.Subject().AuthenticationInfo, security.SessionClaim)
+	if sid == "" || !bson.IsObjectIdHex(sid) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Logout requires a bearer token"})
+		return
 	}
 
-	if !CheckPassword(user.Password, login.Password) {
-		a.Reply().BadRequest().JSON(models.Response{Message: "Bad password"})
+	if err := models.RevokeSessions(utils.Filter{"_id": bson.ObjectIdHex(sid)}); err != nil {
+		log.Errorf("error revoking session: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
 		return
 	}
 
-	jsontoken := GetJSONToken(&user)
-	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully logged in", Data: jsontoken})
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully logged out"})
 }
 
-// UserInfo - example to get
+// UserInfo returns the authenticated user
 func (a *UsersController) UserInfo() {
-	inter := a.Get("nats")
-	if inter == nil {
-		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	user, err := service.CurrentUser(callerOf(a.Context))
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
 		return
 	}
 
-	n, ok := inter.(*nats.Conn)
-	if !ok {
-		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+	a.Reply().Ok().JSON(models.Response{Success: true, Data: user})
+}
+
+// ChangePassword changes the password of the authenticated user, the current
+// password has to be provided again
+func (a *UsersController) ChangePassword(change models.PasswordChange) {
+	if change.NewPassword == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "New password is missing"})
 		return
 	}
 
-	user, err := GetUserFromContext(n, a.Context)
+	user, err := GetUserFromContext(a.Context)
 	if err != nil {
 		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
 		return
 	}
+	if !models.CheckPassword(user.Password, change.CurrentPassword) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Invalid password"})
+		return
+	}
 
-	a.Reply().Ok().JSON(models.Response{Success: true, Data: user})
-}
+	before := *user
+	user.Password = models.HashPassword(user.Username, change.NewPassword)
+	err = models.UpdateUser(user.ID, bson.M{"password": user.Password})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditEdit, "user", user.ID.Hex(), before, user)
 
-// signinKey set up a global string for our secret
-var signinKey = []byte("kdsadsndadsafs")
-
-// JwtMiddleware handler for jwt tokens
-var JwtMiddleware = jwtmiddleware.New(jwtmiddleware.Options{
-	ValidationKeyGetter: func(token *jwt.Token) (interface{}, error) {
-		return signinKey, nil
-	},
-	UserProperty:  "user",
-	Debug:         false,
-	SigningMethod: jwt.SigningMethodHS256,
-})
-
-// GetToken create a jwt token with user claims
-func GetToken(user *storageModel.User) string {
-	token := jwt.New(jwt.SigningMethodHS256)
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uuid"] = user.ID
-	claims["exp"] = time.Now().Add(time.Hour * 24).Unix()
-	signedToken, _ := token.SignedString(signinKey)
-	return signedToken
-}
+	// Logins elsewhere may have been made with the old password
+	others := models.ActiveSessions(user.ID)
+	if sid := security.PrincipalClaim(a.Subject().AuthenticationInfo, security.SessionClaim); bson.IsObjectIdHex(sid) {
+		others["_id"] = bson.M{"$ne": bson.ObjectIdHex(sid)}
+	}
+	if err := models.RevokeSessions(others); err != nil {
+		log.Errorf("error revoking sessions: %v", err)
+	}
 
-// GetJSONToken create a JSON token string
-func GetJSONToken(user *storageModel.User) string {
-	token := GetToken(user)
-	jsontoken := "{\"id_token\": \"" + token + "\"}"
-	return jsontoken
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully changed the password"})
 }
 
-// GetUserClaimsFromContext return "user" claims as a map from request
-func GetUserClaimsFromContext(context *aah.Context) map[string]interface{} {
-	token := context.Get("user").(*jwt.Token)
-	claims := token.Claims.(jwt.MapClaims)
-	return claims
-}
+// ChangeEmail changes the email of the authenticated user, the password has
+// to be provided again
+func (a *UsersController) ChangeEmail(change models.EmailChange) {
+	if change.Email == "" {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Email is missing"})
+		return
+	}
 
-// HashPassword - Hash the password (takes a username as well, it can be used for salting).
-func HashPassword(username, password string) string {
-	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	user, err := GetUserFromContext(a.Context)
 	if err != nil {
-		panic("Permissions: bcrypt password hashing unsuccessful")
+		a.Reply().BadRequest().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if !models.CheckPassword(user.Password, change.Password) {
+		a.Reply().BadRequest().JSON(models.Response{Message: "Invalid password"})
+		return
 	}
-	return string(hash)
-}
 
-// CheckPassword - compare a hashed password with a possible plaintext equivalent
-func CheckPassword(hashedPassword, password string) bool {
-	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
-}
+	has, err := models.HasUser(utils.Filter{"email": change.Email})
+	if err != nil {
+		log.Debugf("error finding user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	if has {
+		a.Reply().BadRequest().JSON(models.Response{Message: "A user with this email already exists"})
+		return
+	}
 
-// GetUserFromContext - return User reference from header token
-func GetUserFromContext(n *nats.Conn, context *aah.Context) (*storageModel.User, error) {
-	userclaims := GetUserClaimsFromContext(context)
+	before := *user
+	user.Email = change.Email
+	user.EmailVerified = false
+	err = models.UpdateUser(user.ID, bson.M{"email": user.Email, "email_verified": user.EmailVerified})
+	if err != nil {
+		log.Debugf("error updating user: %v", err)
+		a.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
+	}
+	audit(a.Context, models.AuditEdit, "user", user.ID.Hex(), before, user)
 
-	find := utils.FindOptions{
-		Filter: utils.Filter{"_id": bson.ObjectIdHex(userclaims["uuid"].(string))},
-		Sort:   utils.Sort{"-created_at"},
-		Limit:  1,
+	// The links sent to the old email must not verify the new one
+	if err := models.DeleteUserTokens(user.ID); err != nil {
+		log.Errorf("error deleting user tokens: %v", err)
+	}
+	if err := sendUserToken(user, user.Email, models.VerifyEmailToken); err != nil {
+		log.Errorf("error sending email verification: %v", err)
 	}
 
-	data, err := bson.MarshalJSON(find)
-	if err != nil {
-		return nil, err
+	a.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully changed the email", Data: models.NewUserView(user)})
+}
+
+// replyLogin replies to a successful login of the user with the token pair of
+// a new session, or
+// with an mfa token when the login has to be completed with a second factor
+func replyLogin(ctx *aah.Context, user *storageModel.User, method string) {
+	if user.IsLocked || user.IsExpired {
+		ctx.Reply().Forbidden().JSON(models.Response{Message: "This account is locked or expired"})
+		return
+	}
+
+	// The login has to be completed with a second factor, or by enrolling
+	// when the role of the user requires it
+	if user.TOTPEnabled || security.MFARequired(user) {
+		token, err := security.GetMFAToken(user)
+		if err != nil {
+			log.Errorf("error signing token: %v", err)
+			ctx.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+			return
+		}
+		ctx.Reply().Ok().JSON(models.Response{Success: true, Message: "Two-factor authentication required", Data: models.MFAPending{
+			MFAToken: token,
+			Enroll:   !user.TOTPEnabled,
+		}})
+		return
 	}
 
-	users, err := utilNats.FindUser(n, data)
+	jsontoken, err := startSession(ctx, user, method)
 	if err != nil {
-		return nil, err
+		log.Errorf("error starting session: %v", err)
+		ctx.Reply().InternalServerError().JSON(models.Response{Message: "internal error"})
+		return
 	}
-	if len(users) <= 0 {
+	ctx.Reply().Ok().JSON(models.Response{Success: true, Message: "Successfully logged in", Data: jsontoken})
+}
+
+// GetUserFromContext - return User reference of the authenticated subject
+func GetUserFromContext(context *aah.Context) (*storageModel.User, error) {
+	principal := context.Subject().PrimaryPrincipal()
+	if principal == nil {
 		return nil, errors.New("invalid user")
 	}
 
-	return &users[0], nil
+	return models.FindUserWithID(principal.Value)
 }
@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"strings"
+	"time"
+
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// legacyRoutes maps the paths of the action-style routes to the resources
+// of the v2 API replacing them
+var legacyRoutes = map[string]string{
+	"/checks/delete":                 "/v2/checks/{id}",
+	"/checks/get/all":                "/v2/checks",
+	"/checks/get/id":                 "/v2/checks/{id}",
+	"/checks/get/client-cmd":         "/v2/clients/{id}/checks",
+	"/checks/get/checks-date-client": "/v2/clients/{id}/checks",
+	"/clients/create":                "/v2/clients",
+	"/clients/delete":                "/v2/clients/{id}",
+	"/clients/edit":                  "/v2/clients/{id}",
+	"/clients/get/all":               "/v2/clients",
+	"/clients/get/id":                "/v2/clients/{id}",
+	"/commands/create":               "/v2/commands",
+	"/commands/delete":               "/v2/commands/{id}",
+	"/commands/edit":                 "/v2/commands/{id}",
+	"/commands/get":                  "/v2/commands",
+	"/groups/create":                 "/v2/groups",
+	"/groups/delete/id":              "/v2/groups/{id}",
+	"/groups/delete/name":            "/v2/groups/{id}",
+	"/groups/edit":                   "/v2/groups/{id}",
+	"/groups/rename":                 "/v2/groups/{id}",
+	"/groups/get":                    "/v2/groups",
+	"/groups/exists":                 "/v2/groups",
+}
+
+// DeprecationMiddleware marks the replies of the action-style routes as
+// deprecated and links the v2 resource replacing them. The `Sunset` header
+// is sent once `api.legacy.sunset` is configured.
+func DeprecationMiddleware(ctx *aah.Context, m *aah.Middleware) {
+	if successor, found := legacyRoutes[strings.TrimSuffix(ctx.Req.Path, "/")]; found {
+		ctx.Reply().Header("Deprecation", "true")
+		ctx.Reply().Header("Link", "<"+successor+`>; rel="successor-version"`)
+		if sunset := aah.AppConfig().StringDefault("api.legacy.sunset", ""); sunset != "" {
+			ctx.Reply().Header("Sunset", sunset)
+		}
+	}
+
+	m.Next(ctx)
+}
+
+// ClientsV2Controller serves the clients resource of the v2 API with the
+// handlers of ClientsController
+type ClientsV2Controller struct {
+	ClientsController
+}
+
+// List returns all clients
+func (a *ClientsV2Controller) List() {
+	a.GetClients()
+}
+
+// Get returns a client
+func (a *ClientsV2Controller) Get(id string) {
+	if validID(a.Context, id) {
+		a.GetClientWithID(models.ClientID{ID: id})
+	}
+}
+
+// Create creates a new client
+func (a *ClientsV2Controller) Create(create models.ClientCreate) {
+	a.CreateClient(create)
+}
+
+// Update applies a JSON Merge Patch or JSON Patch to a client
+func (a *ClientsV2Controller) Update(id string) {
+	if validID(a.Context, id) {
+		a.PatchClient(id)
+	}
+}
+
+// Delete deletes a client
+func (a *ClientsV2Controller) Delete(id string) {
+	if validID(a.Context, id) {
+		a.DeleteClient(models.ClientID{ID: id})
+	}
+}
+
+// Checks returns the checks of a client, only those of the `command_id`
+// between `from` and `to` when it is set
+func (a *ClientsV2Controller) Checks(id string) {
+	if !validID(a.Context, id) {
+		return
+	}
+	checks := &ChecksController{Context: a.Context}
+
+	commandID := a.Req.QueryValue("command_id")
+	if commandID == "" {
+		checks.GetWithClientID(models.ClientID{ID: id})
+		return
+	}
+
+	from, err := time.Parse(time.RFC3339, a.Req.QueryValue("from"))
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "From has to be an RFC 3339 time"})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, a.Req.QueryValue("to"))
+	if err != nil {
+		a.Reply().BadRequest().JSON(models.Response{Message: "To has to be an RFC 3339 time"})
+		return
+	}
+	checks.checksBetween(id, commandID, from, to, 0)
+}
+
+// CommandsV2Controller serves the commands resource of the v2 API with the
+// handlers of CommandsController
+type CommandsV2Controller struct {
+	CommandsController
+}
+
+// List returns all commands
+func (a *CommandsV2Controller) List() {
+	a.GetCommands()
+}
+
+// Get returns a command
+func (a *CommandsV2Controller) Get(id string) {
+	if validID(a.Context, id) {
+		a.GetCommandWithID(models.CommandID{ID: id})
+	}
+}
+
+// Create creates a new command
+func (a *CommandsV2Controller) Create(create models.CommandCreate) {
+	a.CreateCommand(create)
+}
+
+// Update applies a JSON Merge Patch or JSON Patch to a command
+func (a *CommandsV2Controller) Update(id string) {
+	if validID(a.Context, id) {
+		a.PatchCommand(id)
+	}
+}
+
+// Delete deletes a command
+func (a *CommandsV2Controller) Delete(id string) {
+	if validID(a.Context, id) {
+		a.DeleteCommand(models.CommandID{ID: id})
+	}
+}
+
+// GroupsV2Controller serves the groups resource of the v2 API with the
+// handlers of GroupsController
+type GroupsV2Controller struct {
+	GroupsController
+}
+
+// List returns all groups
+func (a *GroupsV2Controller) List() {
+	a.GetGroups()
+}
+
+// Get returns a group
+func (a *GroupsV2Controller) Get(id string) {
+	if validID(a.Context, id) {
+		a.GetGroupWithID(models.GroupID{ID: id})
+	}
+}
+
+// Create adds a command to a group, the group is created if it doesn't exist
+func (a *GroupsV2Controller) Create(create models.GroupCreate) {
+	a.CreateGroup(create)
+}
+
+// Update applies a JSON Merge Patch or JSON Patch to a group, including
+// its commands
+func (a *GroupsV2Controller) Update(id string) {
+	if validID(a.Context, id) {
+		a.PatchGroup(id)
+	}
+}
+
+// Delete deletes a group
+func (a *GroupsV2Controller) Delete(id string) {
+	if validID(a.Context, id) {
+		a.DeleteGroup(models.GroupID{ID: id})
+	}
+}
+
+// ChecksV2Controller serves the checks resource of the v2 API with the
+// handlers of ChecksController
+type ChecksV2Controller struct {
+	ChecksController
+}
+
+// List returns all checks
+func (a *ChecksV2Controller) List() {
+	a.GetChecks()
+}
+
+// Get returns a check
+func (a *ChecksV2Controller) Get(id string) {
+	if validID(a.Context, id) {
+		a.GetCheckWithID(models.ChecksID{ID: id})
+	}
+}
+
+// Delete deletes a check
+func (a *ChecksV2Controller) Delete(id string) {
+	if validID(a.Context, id) {
+		a.DeleteCheck(models.ChecksID{ID: id})
+	}
+}
+
+// validID checks that the ID of a path is an ObjectId, replying with an
+// error if not
+func validID(ctx *aah.Context, id string) bool {
+	if !bson.IsObjectIdHex(id) {
+		ctx.Reply().BadRequest().JSON(models.Response{Message: "ID is not a valid ObjectId"})
+		return false
+	}
+	return true
+}
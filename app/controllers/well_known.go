@@ -0,0 +1,17 @@
+package controllers
+
+import (
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/security"
+)
+
+// WellKnownController serves the `/.well-known` discovery documents
+type WellKnownController struct {
+	*aah.Context
+}
+
+// JWKS returns the public keys used to sign tokens, so other services can
+// verify tokens without sharing a secret
+func (a *WellKnownController) JWKS() {
+	a.Reply().Ok().JSON(security.Keys.JWKS())
+}
@@ -0,0 +1,113 @@
+package graph
+
+import (
+	"sort"
+	"sync"
+)
+
+// BatchFunc fetches the values of several keys at once, keys without a
+// value are left out of the map
+type BatchFunc func(keys []string) (map[string]interface{}, error)
+
+// Loader batches the loads of the values of keys. Keys are queued with Prime
+// as the entities referring to them are resolved, and the first Load fetches
+// every queued key with a single call of the batch function. Values are kept
+// for the lifetime of the loader, which is a single request.
+type Loader struct {
+	fetch BatchFunc
+
+	mu      sync.Mutex
+	pending map[string]bool
+	values  map[string]interface{}
+	loaded  map[string]bool
+}
+
+// NewLoader returns a loader fetching values with the batch function
+func NewLoader(fetch BatchFunc) *Loader {
+	return &Loader{
+		fetch:   fetch,
+		pending: make(map[string]bool),
+		values:  make(map[string]interface{}),
+		loaded:  make(map[string]bool),
+	}
+}
+
+// Prime queues the keys to be fetched with the next batch
+func (l *Loader) Prime(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if !l.loaded[key] {
+			l.pending[key] = true
+		}
+	}
+}
+
+// Set stores the value of a key that is already known
+func (l *Loader) Set(key string, value interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.values[key] = value
+	l.loaded[key] = true
+	delete(l.pending, key)
+}
+
+// Load returns the value of the key, or nil if it has none
+func (l *Loader) Load(key string) (interface{}, error) {
+	values, err := l.LoadMany([]string{key})
+	if err != nil || len(values) <= 0 {
+		return nil, err
+	}
+	return values[0], nil
+}
+
+// LoadMany returns the values of the keys in order, keys without a value are
+// left out
+func (l *Loader) LoadMany(keys []string) ([]interface{}, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if !l.loaded[key] {
+			l.pending[key] = true
+		}
+	}
+	if len(l.pending) > 0 {
+		if err := l.flush(); err != nil {
+			return nil, err
+		}
+	}
+
+	values := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		if v, found := l.values[key]; found {
+			values = append(values, v)
+		}
+	}
+	return values, nil
+}
+
+// flush fetches the queued keys, the caller holds the lock
+func (l *Loader) flush() error {
+	keys := make([]string, 0, len(l.pending))
+	for key := range l.pending {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	values, err := l.fetch(keys)
+	if err != nil {
+		return err
+	}
+
+	for _, key := range keys {
+		if v, found := values[key]; found {
+			l.values[key] = v
+		}
+		l.loaded[key] = true
+		delete(l.pending, key)
+	}
+	return nil
+}
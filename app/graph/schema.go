@@ -0,0 +1,77 @@
+// Package graph holds the GraphQL schema of the clients, commands, groups and
+// checks, and the loaders batching the storage requests of a query.
+package graph
+
+// Schema is the GraphQL schema served at `/graphql`. The `filter` and `sort`
+// arguments of the lists take the syntax of the list query parameters of the
+// REST API, and lists hold at most `first` entities.
+const Schema = `
+scalar Time
+
+schema {
+	query: Query
+}
+
+type Query {
+	clients(filter: String, sort: String, first: Int): [Client!]!
+	client(id: ID!): Client
+	commands(filter: String, sort: String, first: Int): [Command!]!
+	command(id: ID!): Command
+	groups(filter: String, sort: String, first: Int): [Group!]!
+	group(id: ID!): Group
+	check(id: ID!): Check
+}
+
+type Client {
+	id: ID!
+	name: String!
+	ip: String!
+	createdAt: Time!
+	updatedAt: Time!
+	groups: [Group!]!
+	# The latest check of each command of the groups of the client
+	latestChecks: [Check!]!
+	# The checks of the client, newest first
+	checks(commandId: ID, first: Int): [Check!]!
+}
+
+type Group {
+	id: ID!
+	name: String!
+	createdAt: Time!
+	updatedAt: Time!
+	commands: [GroupCommand!]!
+	clients: [Client!]!
+}
+
+type GroupCommand {
+	id: ID!
+	command: Command
+	nextCheck: Int!
+	stopError: Boolean!
+}
+
+type Command {
+	id: ID!
+	command: String!
+	name: String!
+	description: String!
+	format: String!
+	createdAt: Time!
+	updatedAt: Time!
+	groups: [Group!]!
+	# The latest check of the command on the client
+	latestCheck(clientId: ID!): Check
+}
+
+type Check {
+	id: ID!
+	client: Client
+	command: Command
+	response: String!
+	error: Boolean!
+	finished: Boolean!
+	checked: Boolean!
+	createdAt: Time!
+}
+`
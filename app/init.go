@@ -5,11 +5,21 @@ package main
 
 import (
 	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/controllers"
+	"github.com/keiwi/api/app/mailer"
 	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/rpc"
+	"github.com/keiwi/api/app/security"
 )
 
 func init() {
+	aah.OnInit(security.ConfigureClientCerts)
 	aah.OnStart(models.ConnectNats)
+	aah.OnStart(mailer.Load)
+	aah.OnStart(controllers.LoadOpenAPI)
+	aah.OnStart(controllers.LoadGraphQL)
+	aah.OnStart(rpc.Start)
+	aah.OnShutdown(rpc.Stop)
 	aah.OnShutdown(models.DisconnectNats)
 
 	//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
@@ -54,11 +64,14 @@ func init() {
 		aah.RouteMiddleware,
 		aah.CORSMiddleware,
 		aah.BindMiddleware,
+		security.ClientCertMiddleware,
+		security.ClientIPMiddleware,
 		aah.AuthcAuthzMiddleware,
 
 		//
 		// NOTE: Register your Custom middleware's right here
 		//
+		controllers.DeprecationMiddleware,
 
 		aah.ActionMiddleware,
 	)
@@ -67,7 +80,7 @@ func init() {
 	// Add Application Error Handler
 	// Doc: https://docs.aahframework.org/error-handling.html
 	//__________________________________________________________________________
-	// aah.SetErrorHandler(AppErrorHandler)
+	aah.SetErrorHandler(AppErrorHandler)
 
 	//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
 	// Add Custom value Parser
@@ -87,3 +100,10 @@ func init() {
 	// // Add your validation funcs
 
 }
+
+// AppErrorHandler replies to framework errors, such as a denied authorization,
+// with the same JSON response the controllers use.
+func AppErrorHandler(ctx *aah.Context, err *aah.Error) bool {
+	ctx.Reply().Status(err.Code).JSON(models.Response{Message: err.Message})
+	return true
+}
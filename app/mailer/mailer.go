@@ -0,0 +1,126 @@
+package mailer
+
+import (
+	"io"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+)
+
+// Default is the mailer configured in `mail`, it is set on server start up
+var Default Mailer = &LogMailer{}
+
+// Message is a plain text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer sends emails
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Load configures the Default mailer from `mail` in the application config
+func Load(_ *aah.Event) {
+	cfg := aah.AppConfig()
+	from := cfg.StringDefault("mail.from", "keiwi <noreply@localhost>")
+
+	switch driver := cfg.StringDefault("mail.driver", "log"); driver {
+	case "smtp":
+		Default = &SMTPMailer{
+			Addr:     net.JoinHostPort(cfg.StringDefault("mail.smtp.host", "localhost"), cfg.StringDefault("mail.smtp.port", "25")),
+			From:     from,
+			Username: cfg.StringDefault("mail.smtp.username", ""),
+			Password: cfg.StringDefault("mail.smtp.password", ""),
+		}
+	case "log":
+		Default = &LogMailer{From: from, File: cfg.StringDefault("mail.file", "")}
+	default:
+		log.Fatalf("unknown mail driver: %s", driver)
+	}
+}
+
+// Send sends the message with the Default mailer
+func Send(msg Message) error {
+	return Default.Send(msg)
+}
+
+// SMTPMailer sends emails through an SMTP server
+type SMTPMailer struct {
+	Addr     string
+	From     string
+	Username string
+	Password string
+}
+
+// Send is `Mailer` interface
+func (m *SMTPMailer) Send(msg Message) error {
+	var auth smtp.Auth
+	if m.Username != "" {
+		host, _, err := net.SplitHostPort(m.Addr)
+		if err != nil {
+			return err
+		}
+		auth = smtp.PlainAuth("", m.Username, m.Password, host)
+	}
+
+	return smtp.SendMail(m.Addr, auth, address(m.From), []string{msg.To}, format(m.From, msg))
+}
+
+// LogMailer writes emails to a file, or to the application log if no file is
+// set. It is meant for development, where no mail server is available.
+type LogMailer struct {
+	From string
+	File string
+
+	mu sync.Mutex
+}
+
+// Send is `Mailer` interface
+func (m *LogMailer) Send(msg Message) error {
+	if m.File == "" {
+		log.Infof("mail to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.File, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.WriteString(f, string(format(m.From, msg))+"\r\n")
+	return err
+}
+
+// format returns the message with its headers
+func format(from string, msg Message) []byte {
+	headers := []string{
+		"From: " + from,
+		"To: " + msg.To,
+		"Subject: " + msg.Subject,
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+	}
+	return []byte(strings.Join(headers, "\r\n") + "\r\n\r\n" + strings.Replace(msg.Body, "\n", "\r\n", -1))
+}
+
+// address returns the bare address of `name <address>`
+func address(from string) string {
+	if i := strings.LastIndex(from, "<"); i >= 0 {
+		return strings.TrimSuffix(from[i+1:], ">")
+	}
+	return from
+}
@@ -0,0 +1,147 @@
+package models
+
+import (
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ACLsCollection is the storage collection of the access lists of clients,
+// commands and groups
+const ACLsCollection = "acls"
+
+// Entities that have access lists
+const (
+	ACLClient  = "client"
+	ACLCommand = "command"
+	ACLGroup   = "group"
+)
+
+// Access levels, each level includes the ones before it. Admins can change
+// the access list itself.
+const (
+	ACLRead  = "read"
+	ACLWrite = "write"
+	ACLAdmin = "admin"
+)
+
+var aclLevels = map[string]int{ACLRead: 1, ACLWrite: 2, ACLAdmin: 3}
+
+// ACL is the owner and access list of a client, command or group
+type ACL struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	Entity    string        `json:"entity" bson:"entity"`
+	EntityID  bson.ObjectId `json:"entity_id" bson:"entity_id"`
+	OwnerID   bson.ObjectId `json:"owner_id,omitempty" bson:"owner_id,omitempty"`
+	Entries   []ACLEntry    `json:"entries" bson:"entries"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// ACLEntry grants a user, or every user holding a role, a level of access
+type ACLEntry struct {
+	UserID bson.ObjectId `json:"user_id,omitempty" bson:"user_id,omitempty"`
+	Role   string        `json:"role,omitempty" bson:"role,omitempty"`
+	Level  string        `json:"level" bson:"level"`
+}
+
+// ACLEntity - json data expected for looking up an access list
+type ACLEntity struct {
+	Entity string `json:"entity"`
+	ID     string `json:"id"`
+}
+
+// ACLSet - json data expected for replacing an access list, the owner is
+// kept when empty
+type ACLSet struct {
+	Entity  string     `json:"entity"`
+	ID      string     `json:"id"`
+	OwnerID string     `json:"owner_id"`
+	Entries []ACLEntry `json:"entries"`
+}
+
+// IsACLEntity checks if the entity has access lists
+func IsACLEntity(entity string) bool {
+	return entity == ACLClient || entity == ACLCommand || entity == ACLGroup
+}
+
+// ACLLevel returns the rank of the level, 0 for unknown levels
+func ACLLevel(level string) int {
+	return aclLevels[level]
+}
+
+// Level returns the highest level the access list grants the user holding
+// the roles, the owner is an admin
+func (acl *ACL) Level(user bson.ObjectId, hasRole func(string) bool) int {
+	if acl.OwnerID != "" && acl.OwnerID == user {
+		return ACLLevel(ACLAdmin)
+	}
+
+	level := 0
+	for _, e := range acl.Entries {
+		if e.UserID != "" && e.UserID != user || e.Role != "" && !hasRole(e.Role) || e.UserID == "" && e.Role == "" {
+			continue
+		}
+		if l := ACLLevel(e.Level); l > level {
+			level = l
+		}
+	}
+	return level
+}
+
+// FindACLs returns the access lists matching the filter
+func FindACLs(filter utils.Filter) ([]ACL, error) {
+	var acls []ACL
+	err := Find(ACLsCollection, utils.FindOptions{Filter: filter}, &acls)
+	return acls, err
+}
+
+// FindEntityACLs returns the access lists of the entities by entity ID
+func FindEntityACLs(entity string, ids []bson.ObjectId) (map[bson.ObjectId]*ACL, error) {
+	acls, err := FindACLs(utils.Filter{"entity": entity, "entity_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[bson.ObjectId]*ACL, len(acls))
+	for i := range acls {
+		byID[acls[i].EntityID] = &acls[i]
+	}
+	return byID, nil
+}
+
+// SaveACL creates the access list or replaces the owner and entries of the
+// existing access list of the entity
+func SaveACL(acl *ACL) error {
+	acl.UpdatedAt = time.Now()
+
+	filter := utils.Filter{"entity": acl.Entity, "entity_id": acl.EntityID}
+	exists, err := Has(ACLsCollection, utils.HasOptions{Filter: filter})
+	if err != nil {
+		return err
+	}
+	if exists {
+		return Update(ACLsCollection, utils.UpdateOptions{
+			Filter: filter,
+			Updates: utils.Updates{"$set": bson.M{
+				"owner_id":   acl.OwnerID,
+				"entries":    acl.Entries,
+				"updated_at": acl.UpdatedAt,
+			}},
+		})
+	}
+
+	if acl.ID == "" {
+		acl.ID = bson.NewObjectId()
+	}
+	acl.CreatedAt = acl.UpdatedAt
+	return Create(ACLsCollection, acl)
+}
+
+// DeleteACLs removes the access lists of the entities
+func DeleteACLs(entity string, ids ...bson.ObjectId) error {
+	return Delete(ACLsCollection, utils.DeleteOptions{
+		Filter: utils.Filter{"entity": entity, "entity_id": bson.M{"$in": ids}},
+	})
+}
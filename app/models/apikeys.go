@@ -0,0 +1,60 @@
+package models
+
+import (
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// APIKeysCollection is the storage collection of api keys
+const APIKeysCollection = "api_keys"
+
+// APIKey is a named key used by scripts to authenticate as a user, only the
+// hash of the key is stored
+type APIKey struct {
+	ID          bson.ObjectId `json:"id" bson:"_id"`
+	UserID      bson.ObjectId `json:"user_id" bson:"user_id"`
+	Name        string        `json:"name" bson:"name"`
+	Prefix      string        `json:"prefix" bson:"prefix"` // Shown to tell keys apart
+	Hash        string        `json:"hash,omitempty" bson:"hash"`
+	Permissions []string      `json:"permissions" bson:"permissions"`
+	ExpiresAt   *time.Time    `json:"expires_at,omitempty" bson:"expires_at,omitempty"`
+	LastUsedAt  *time.Time    `json:"last_used_at,omitempty" bson:"last_used_at,omitempty"`
+	CreatedAt   time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// APIKeyCreate - json data expected for creating a new api key
+type APIKeyCreate struct {
+	Name        string   `json:"name"`
+	Permissions []string `json:"permissions"`
+	ExpiresAt   string   `json:"expires_at"` // Optional, RFC 3339
+}
+
+// APIKeyID
+type APIKeyID struct {
+	ID string `json:"id"`
+}
+
+// IsExpired checks if the key has passed its expiry
+func (k *APIKey) IsExpired() bool {
+	return k.ExpiresAt != nil && time.Now().After(*k.ExpiresAt)
+}
+
+// FindAPIKeys returns the api keys matching the filter, newest first
+func FindAPIKeys(filter utils.Filter) ([]APIKey, error) {
+	var keys []APIKey
+	err := Find(APIKeysCollection, utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"-created_at"},
+	}, &keys)
+	return keys, err
+}
+
+// TouchAPIKey sets the last used timestamp of the key
+func TouchAPIKey(id bson.ObjectId) error {
+	return Update(APIKeysCollection, utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": id},
+		Updates: utils.Updates{"$set": bson.M{"last_used_at": time.Now()}},
+	})
+}
@@ -0,0 +1,139 @@
+package models
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// AuditCollection is the storage collection of the audit log
+const AuditCollection = "audit"
+
+// Audit actions
+const (
+	AuditCreate = "create"
+	AuditEdit   = "edit"
+	AuditDelete = "delete"
+)
+
+// auditRedacted are fields whose values are never stored in the audit log,
+// only that they changed
+var auditRedacted = map[string]bool{
+	"password":       true,
+	"totp_secret":    true,
+	"recovery_codes": true,
+	"token_hash":     true,
+	"hash":           true,
+}
+
+// auditRedactedValue replaces the values of redacted fields
+const auditRedactedValue = "[redacted]"
+
+// AuditRecord is a change made through the API
+type AuditRecord struct {
+	ID        bson.ObjectId          `json:"id" bson:"_id"`
+	Actor     string                 `json:"actor" bson:"actor"` // ID of the user
+	ActorName string                 `json:"actor_name" bson:"actor_name"`
+	Via       string                 `json:"via,omitempty" bson:"via,omitempty"` // e.g. `apikey` or `cert` when not a login session
+	OrgID     string                 `json:"org_id,omitempty" bson:"org_id,omitempty"`
+	IP        string                 `json:"ip" bson:"ip"`
+	RequestID string                 `json:"request_id" bson:"request_id"`
+	Action    string                 `json:"action" bson:"action"`
+	Entity    string                 `json:"entity" bson:"entity"`
+	EntityID  string                 `json:"entity_id" bson:"entity_id"`
+	Changes   map[string]AuditChange `json:"changes,omitempty" bson:"changes,omitempty"`
+	CreatedAt time.Time              `json:"created_at" bson:"created_at"`
+}
+
+// AuditChange is the value of a field before and after a change
+type AuditChange struct {
+	Before interface{} `json:"before" bson:"before"`
+	After  interface{} `json:"after" bson:"after"`
+}
+
+// AuditQuery - json data expected for searching the audit log, From and To
+// are RFC 3339 timestamps
+type AuditQuery struct {
+	Actor    string `json:"actor"`
+	OrgID    string `json:"org_id"`
+	Entity   string `json:"entity"`
+	EntityID string `json:"entity_id"`
+	Action   string `json:"action"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Limit    int    `json:"limit"`
+}
+
+// AuditDiff returns the fields that differ between before and after, either
+// may be nil for a created or deleted entity
+func AuditDiff(before, after interface{}) (map[string]AuditChange, error) {
+	b, err := auditFields(before)
+	if err != nil {
+		return nil, err
+	}
+	a, err := auditFields(after)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make(map[string]AuditChange)
+	for k, v := range b {
+		if !reflect.DeepEqual(v, a[k]) {
+			changes[k] = auditChange(k, v, a[k])
+		}
+	}
+	for k, v := range a {
+		if _, ok := b[k]; !ok {
+			changes[k] = auditChange(k, nil, v)
+		}
+	}
+	return changes, nil
+}
+
+// auditChange returns the change of the field, with the values of redacted
+// fields replaced
+func auditChange(field string, before, after interface{}) AuditChange {
+	if auditRedacted[field] {
+		if before != nil {
+			before = auditRedactedValue
+		}
+		if after != nil {
+			after = auditRedactedValue
+		}
+	}
+	return AuditChange{Before: before, After: after}
+}
+
+// auditFields returns the fields of v as they are stored
+func auditFields(v interface{}) (map[string]interface{}, error) {
+	fields := make(map[string]interface{})
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return fields, nil
+	}
+
+	data, err := bson.MarshalJSON(v)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	// The update time changes with every edit
+	delete(fields, "updated_at")
+	return fields, nil
+}
+
+// FindAuditRecords returns up to limit records matching the filter, newest first
+func FindAuditRecords(filter utils.Filter, limit int) ([]AuditRecord, error) {
+	var records []AuditRecord
+	err := Find(AuditCollection, utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"-created_at"},
+		Limit:  utils.Limit(limit),
+	}, &records)
+	return records, err
+}
@@ -0,0 +1,54 @@
+package models
+
+import "encoding/json"
+
+// BulkResult is the outcome of an item of a bulk request
+type BulkResult struct {
+	Index   int    `json:"index"`
+	ID      string `json:"id,omitempty"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// BulkClientCreate - json data expected for creating several clients, an
+// atomic request creates either every client or none
+type BulkClientCreate struct {
+	Atomic bool           `json:"atomic"`
+	Items  []ClientCreate `json:"items"`
+}
+
+// BulkCommandCreate - json data expected for creating several commands, an
+// atomic request creates either every command or none
+type BulkCommandCreate struct {
+	Atomic bool            `json:"atomic"`
+	Items  []CommandCreate `json:"items"`
+}
+
+// BulkUpdate - json data expected for updating several entities with a JSON
+// Merge Patch each, an atomic request updates either every entity or none
+type BulkUpdate struct {
+	Atomic bool             `json:"atomic"`
+	Items  []BulkUpdateItem `json:"items"`
+}
+
+// BulkUpdateItem is the merge patch of an entity
+type BulkUpdateItem struct {
+	ID    string          `json:"id"`
+	Patch json.RawMessage `json:"patch"`
+}
+
+// BulkDelete - json data expected for deleting several entities, an atomic
+// request deletes either every entity or none
+type BulkDelete struct {
+	Atomic bool     `json:"atomic"`
+	IDs    []string `json:"ids"`
+}
+
+// BulkGroupMembers - json data expected for adding clients to a group or
+// removing them from it, an atomic request changes either every client or
+// none
+type BulkGroupMembers struct {
+	Atomic    bool     `json:"atomic"`
+	GroupID   string   `json:"group_id"`
+	ClientIDs []string `json:"client_ids"`
+}
@@ -1,5 +1,17 @@
 package models
 
+import "github.com/keiwi/api/app/query"
+
+// CheckFields are the fields of checks that lists can be filtered, sorted
+// and selected on
+var CheckFields = query.Fields{
+	"id":         {Type: query.ObjectID, Name: "_id"},
+	"client_id":  {Type: query.ObjectID},
+	"command_id": {Type: query.ObjectID},
+	"error":      {Type: query.Bool},
+	"created_at": {Type: query.Time},
+}
+
 // ChecksID
 type ChecksID struct {
 	ID string `json:"id"`
@@ -1,5 +1,28 @@
 package models
 
+import (
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/query"
+)
+
+// ClientSchema describes the fields of a client that may be patched
+var ClientSchema = patch.Schema{
+	"name":      {Type: patch.String, Required: true},
+	"ip":        {Type: patch.String, Required: true},
+	"group_ids": {Type: patch.Array, Items: &patch.Field{Type: patch.ObjectID}},
+}
+
+// ClientFields are the fields of clients that lists can be filtered, sorted
+// and selected on
+var ClientFields = query.Fields{
+	"id":         {Type: query.ObjectID, Name: "_id"},
+	"name":       {Type: query.String},
+	"ip":         {Type: query.String},
+	"group_ids":  {Type: query.ObjectID, NoSort: true},
+	"created_at": {Type: query.Time},
+	"updated_at": {Type: query.Time},
+}
+
 // ClientCreate - json data expected for creating a new client
 type ClientCreate struct {
 	IP   string `json:"ip"`
@@ -1,5 +1,30 @@
 package models
 
+import (
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/query"
+)
+
+// CommandSchema describes the fields of a command that may be patched
+var CommandSchema = patch.Schema{
+	"command":     {Type: patch.String, Required: true},
+	"name":        {Type: patch.String, Required: true},
+	"description": {Type: patch.String, Required: true},
+	"format":      {Type: patch.String},
+}
+
+// CommandFields are the fields of commands that lists can be filtered,
+// sorted and selected on
+var CommandFields = query.Fields{
+	"id":          {Type: query.ObjectID, Name: "_id"},
+	"command":     {Type: query.String},
+	"name":        {Type: query.String},
+	"description": {Type: query.String},
+	"format":      {Type: query.String},
+	"created_at":  {Type: query.Time},
+	"updated_at":  {Type: query.Time},
+}
+
 type CommandCreate struct {
 	Command     string `json:"command"`
 	Name        string `json:"namn"`
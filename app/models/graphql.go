@@ -0,0 +1,8 @@
+package models
+
+// GraphQLRequest - json data expected for executing a GraphQL query
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
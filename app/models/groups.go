@@ -1,5 +1,34 @@
 package models
 
+import (
+	"math"
+
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/query"
+)
+
+// GroupSchema describes the fields of a group that may be patched, commands
+// added without an id get a new one
+var GroupSchema = patch.Schema{
+	"name": {Type: patch.String, Required: true},
+	"commands": {Type: patch.Array, Items: &patch.Field{Type: patch.Object, Fields: patch.Schema{
+		"id":         {Type: patch.ObjectID},
+		"command_id": {Type: patch.ObjectID, Required: true},
+		"next_check": {Type: patch.Integer, Max: math.MaxInt32},
+		"stop_error": {Type: patch.Boolean},
+	}}},
+}
+
+// GroupFields are the fields of groups that lists can be filtered, sorted
+// and selected on, `command_id` matches the groups running the command
+var GroupFields = query.Fields{
+	"id":         {Type: query.ObjectID, Name: "_id"},
+	"name":       {Type: query.String},
+	"command_id": {Type: query.ObjectID, Name: "commands.command_id", NoSort: true, NoSelect: true},
+	"created_at": {Type: query.Time},
+	"updated_at": {Type: query.Time},
+}
+
 type GroupRename struct {
 	NewName string `json:"new_name"`
 	OldName string `json:"old_name"`
@@ -0,0 +1,46 @@
+package models
+
+import (
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// IdentitiesCollection is the storage collection of external identities
+const IdentitiesCollection = "identities"
+
+// Identity links an account at an external identity provider to a user
+type Identity struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	Provider  string        `json:"provider" bson:"provider"` // e.g. the issuer of an OpenID Connect provider
+	Subject   string        `json:"subject" bson:"subject"`   // The id of the account at the provider
+	UserID    bson.ObjectId `json:"user_id" bson:"user_id"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// FindIdentity returns the identity of the subject at the provider
+func FindIdentity(provider, subject string) (*Identity, error) {
+	var identities []Identity
+	err := Find(IdentitiesCollection, utils.FindOptions{
+		Filter: utils.Filter{"provider": provider, "subject": subject},
+	}, &identities)
+	if err != nil {
+		return nil, err
+	}
+	if len(identities) <= 0 {
+		return nil, ErrUserNotFound
+	}
+	return &identities[0], nil
+}
+
+// CreateIdentity links the subject at the provider to the user
+func CreateIdentity(provider, subject string, user bson.ObjectId) error {
+	return Create(IdentitiesCollection, Identity{
+		ID:        bson.NewObjectId(),
+		Provider:  provider,
+		Subject:   subject,
+		UserID:    user,
+		CreatedAt: time.Now(),
+	})
+}
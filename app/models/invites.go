@@ -0,0 +1,83 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// InvitesCollection is the storage collection of invitations
+const InvitesCollection = "invites"
+
+// ErrInviteUnusable is returned when an invite has been used or has expired
+var ErrInviteUnusable = errors.New("invite has been used or has expired")
+
+// Invite is a single-use invitation to sign up with a role, only the hash of
+// the invitation token is stored
+type Invite struct {
+	ID        bson.ObjectId  `json:"id" bson:"_id"`
+	TokenHash string         `json:"token_hash,omitempty" bson:"token_hash"`
+	Email     string         `json:"email,omitempty" bson:"email,omitempty"` // Restricts the invite to this email
+	Role      string         `json:"role" bson:"role"`
+	ExpiresAt time.Time      `json:"expires_at" bson:"expires_at"`
+	UsedAt    *time.Time     `json:"used_at,omitempty" bson:"used_at,omitempty"`
+	UsedBy    *bson.ObjectId `json:"used_by,omitempty" bson:"used_by,omitempty"`
+	CreatedBy bson.ObjectId  `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time      `json:"created_at" bson:"created_at"`
+}
+
+// InviteCreate - json data expected for creating a new invite
+type InviteCreate struct {
+	Role      string `json:"role"`
+	Email     string `json:"email"`
+	ExpiresIn string `json:"expires_in"` // Duration, e.g. "72h"
+}
+
+// InviteID
+type InviteID struct {
+	ID string `json:"id"`
+}
+
+// IsUsable checks if the invite has neither been used nor expired
+func (i *Invite) IsUsable() bool {
+	return i.UsedAt == nil && time.Now().Before(i.ExpiresAt)
+}
+
+// FindInvites returns the invites matching the filter, newest first
+func FindInvites(filter utils.Filter) ([]Invite, error) {
+	var invites []Invite
+	err := Find(InvitesCollection, utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"-created_at"},
+	}, &invites)
+	return invites, err
+}
+
+// UseInvite marks the invite as used by the user, unless it has already been
+// used or has expired. Of concurrent signups with the invite only one uses it,
+// the others get ErrInviteUnusable.
+func UseInvite(id, user bson.ObjectId) error {
+	now := time.Now()
+	matched, err := UpdateCount(InvitesCollection, utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": id, "used_at": nil, "expires_at": bson.M{"$gt": now}},
+		Updates: utils.Updates{"$set": bson.M{"used_at": now, "used_by": user}},
+	})
+	if err != nil {
+		return err
+	}
+	if matched <= 0 {
+		return ErrInviteUnusable
+	}
+	return nil
+}
+
+// ReleaseInvite makes an invite used by the user usable again, for when the
+// signup using it failed
+func ReleaseInvite(id, user bson.ObjectId) error {
+	return Update(InvitesCollection, utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": id, "used_by": user},
+		Updates: utils.Updates{"$unset": bson.M{"used_at": "", "used_by": ""}},
+	})
+}
@@ -0,0 +1,38 @@
+package models
+
+// MFACode - json data expected for confirming a two-factor code
+type MFACode struct {
+	Code string `json:"code"`
+}
+
+// MFALogin - json data expected for completing a two-factor login, the code
+// may be a TOTP code or a recovery code
+type MFALogin struct {
+	MFAToken string `json:"mfa_token"`
+	Code     string `json:"code"`
+}
+
+// MFADisable - json data expected for disabling two-factor authentication
+type MFADisable struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// MFAPending is the reply to a login that has to complete two-factor authentication
+type MFAPending struct {
+	MFAToken string `json:"mfa_token"`
+	Enroll   bool   `json:"enroll"` // Two-factor authentication is required but not yet enabled
+}
+
+// MFAEnrollment is the reply to starting the two-factor enrollment
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+// MFAEnabled is the reply to enabling two-factor authentication, the token is
+// set when the enrollment completed a pending login
+type MFAEnabled struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+	Token         string   `json:"token,omitempty"`
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Storage collections of organizations and their members
+const (
+	OrgsCollection       = "orgs"
+	OrgMembersCollection = "org_members"
+)
+
+// Roles of the members of an organization, admins manage the members
+const (
+	OrgAdmin  = "admin"
+	OrgMember = "member"
+)
+
+var (
+	// ErrOrgNotFound is returned when no organization matches the lookup
+	ErrOrgNotFound = errors.New("organization not found")
+
+	// ErrOrgMemberNotFound is returned when a user isn't a member of the organization
+	ErrOrgMemberNotFound = errors.New("not a member of the organization")
+)
+
+// Org is an organization, a tenant owning clients, commands and groups
+type Org struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	Name      string        `json:"name" bson:"name"`
+	CreatedBy bson.ObjectId `json:"created_by" bson:"created_by"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at" bson:"updated_at"`
+}
+
+// OrgMembership is the membership of a user in an organization
+type OrgMembership struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	OrgID     bson.ObjectId `json:"org_id" bson:"org_id"`
+	UserID    bson.ObjectId `json:"user_id" bson:"user_id"`
+	Role      string        `json:"role" bson:"role"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// OrgMemberView is a member of an organization as shown to the API
+type OrgMemberView struct {
+	User      UserView  `json:"user"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// OrgCreate - json data expected for creating a new organization
+type OrgCreate struct {
+	Name string `json:"name"`
+}
+
+// OrgID
+type OrgID struct {
+	ID string `json:"id"`
+}
+
+// OrgMemberAdd - json data expected for adding a user, by username or
+// email, to an organization
+type OrgMemberAdd struct {
+	OrgID string `json:"org_id"`
+	Login string `json:"login"`
+	Role  string `json:"role"`
+}
+
+// OrgMemberRole - json data expected for changing the role of a member
+type OrgMemberRole struct {
+	OrgID  string `json:"org_id"`
+	UserID string `json:"user_id"`
+	Role   string `json:"role"`
+}
+
+// OrgMemberID
+type OrgMemberID struct {
+	OrgID  string `json:"org_id"`
+	UserID string `json:"user_id"`
+}
+
+// IsOrgRole checks if the role is a role of organization members
+func IsOrgRole(role string) bool {
+	return role == OrgAdmin || role == OrgMember
+}
+
+// FindOrgs returns the organizations matching the filter ordered by name
+func FindOrgs(filter utils.Filter) ([]Org, error) {
+	var orgs []Org
+	err := Find(OrgsCollection, utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"name"},
+	}, &orgs)
+	return orgs, err
+}
+
+// FindOrg returns the organization with the hex encoded ObjectId
+func FindOrg(id string) (*Org, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, ErrOrgNotFound
+	}
+
+	orgs, err := FindOrgs(utils.Filter{"_id": bson.ObjectIdHex(id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(orgs) <= 0 {
+		return nil, ErrOrgNotFound
+	}
+	return &orgs[0], nil
+}
+
+// FindOrgMembers returns the memberships matching the filter, oldest first
+func FindOrgMembers(filter utils.Filter) ([]OrgMembership, error) {
+	var members []OrgMembership
+	err := Find(OrgMembersCollection, utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"created_at"},
+	}, &members)
+	return members, err
+}
+
+// FindOrgMember returns the membership of the user in the organization
+func FindOrgMember(org, user bson.ObjectId) (*OrgMembership, error) {
+	members, err := FindOrgMembers(utils.Filter{"org_id": org, "user_id": user})
+	if err != nil {
+		return nil, err
+	}
+	if len(members) <= 0 {
+		return nil, ErrOrgMemberNotFound
+	}
+	return &members[0], nil
+}
+
+// MarshalWithOrg marshals the document like bson.MarshalJSON with the
+// `org_id` of the organization owning it added, for the storage models that
+// have no field for it. Without an organization it is marshaled as is.
+func MarshalWithOrg(v interface{}, org bson.ObjectId) ([]byte, error) {
+	data, err := bson.MarshalJSON(v)
+	if err != nil || org == "" {
+		return data, err
+	}
+
+	var doc map[string]json.RawMessage
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc["org_id"], err = bson.MarshalJSON(org); err != nil {
+		return nil, err
+	}
+	return json.Marshal(doc)
+}
@@ -0,0 +1,151 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+
+	"github.com/keiwi/api/app/query"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrInvalidCursor is returned for cursors that can't be decoded
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// DefaultSort is the sort of lists requested without one
+var DefaultSort = []string{"-created_at"}
+
+// Page describes a page of a list
+type Page struct {
+	Limit int    `json:"limit"`
+	Next  string `json:"next,omitempty"`
+	Prev  string `json:"prev,omitempty"`
+	Total *int   `json:"total,omitempty"` // Set when requested with `total=true`
+
+	// TotalCapped is set when more entities match than are counted, Total
+	// is then the number counted
+	TotalCapped bool `json:"total_capped,omitempty"`
+}
+
+// Cursor is a position in a sorted list, the values of the sort fields and
+// the id of the entity at the position. It is handed out encoded so clients
+// treat it as opaque.
+type Cursor struct {
+	Sort   []string      `json:"s"`
+	Values []interface{} `json:"v"`
+	ID     bson.ObjectId `json:"id"`
+	Before bool          `json:"b,omitempty"` // Page of the entities before the position
+}
+
+// Encode returns the opaque form of the cursor
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor decodes a cursor returned by Encode, converting the values
+// to the types of the fields
+func DecodeCursor(s string, fields query.Fields) (*Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil || !c.ID.Valid() || len(c.Values) != len(c.Sort) {
+		return nil, ErrInvalidCursor
+	}
+	for i, key := range c.Sort {
+		if c.Values[i], err = fields.Convert(sortName(key), c.Values[i]); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+	return &c, nil
+}
+
+// Filter matches the entities after the cursor in the order of its sort, or
+// the entities before it. Storage sorts null and missing values before any
+// other value, but doesn't compare them with $gt and $lt, so they are
+// matched explicitly.
+func (c Cursor) Filter(fields query.Fields) utils.Filter {
+	keys := c.keys()
+	values := append(append([]interface{}(nil), c.Values...), c.ID)
+
+	var or []utils.Filter
+	for i, key := range keys {
+		op := "$gt"
+		if sortDescending(key) != c.Before {
+			op = "$lt"
+		}
+
+		cond := utils.Filter{}
+		for j := 0; j < i; j++ {
+			cond[storageName(fields, keys[j])] = values[j]
+		}
+		name := storageName(fields, key)
+		switch {
+		case values[i] == nil && op == "$lt":
+			// nothing sorts before null
+			continue
+		case values[i] == nil:
+			cond[name] = bson.M{"$ne": nil}
+		case op == "$lt" && name != "_id":
+			cond["$or"] = []utils.Filter{{name: bson.M{"$lt": values[i]}}, {name: nil}}
+		default:
+			cond[name] = bson.M{op: values[i]}
+		}
+		or = append(or, cond)
+	}
+	return utils.Filter{"$or": or}
+}
+
+// StorageSort returns the storage sort of the cursor, pages before a
+// position are found in reverse
+func (c Cursor) StorageSort(fields query.Fields) utils.Sort {
+	var sort utils.Sort
+	for _, key := range c.keys() {
+		name := storageName(fields, key)
+		if sortDescending(key) != c.Before {
+			name = "-" + name
+		}
+		sort = append(sort, name)
+	}
+	return sort
+}
+
+// keys returns the sort keys of the cursor ending with the id, in the
+// direction of the last sort field, so the order is total
+func (c Cursor) keys() []string {
+	keys := append([]string(nil), c.Sort...)
+	for _, key := range keys {
+		if sortName(key) == "id" {
+			return keys
+		}
+	}
+
+	if len(keys) > 0 && sortDescending(keys[len(keys)-1]) {
+		return append(keys, "-id")
+	}
+	return append(keys, "id")
+}
+
+// storageName returns the storage name of the field of a sort key
+func storageName(fields query.Fields, key string) string {
+	if name := sortName(key); name != "id" {
+		return fields.StorageName(name)
+	}
+	return "_id"
+}
+
+// sortName returns the field name of a sort key
+func sortName(key string) string {
+	if sortDescending(key) {
+		return key[1:]
+	}
+	return key
+}
+
+func sortDescending(key string) bool {
+	return len(key) > 0 && key[0] == '-'
+}
@@ -0,0 +1,168 @@
+package models
+
+import (
+	"encoding/base64"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/keiwi/api/app/query"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var testFields = query.Fields{
+	"id":         {Type: query.ObjectID, Name: "_id"},
+	"name":       {Type: query.String},
+	"error":      {Type: query.Bool},
+	"checked":    {Type: query.Number},
+	"created_at": {Type: query.Time},
+	"group_name": {Type: query.String, Name: "group.name"},
+}
+
+var testID = bson.ObjectIdHex("5a1f0c6e9d1b2c3d4e5f6a7b")
+
+func TestCursorRoundTrip(t *testing.T) {
+	created := time.Date(2018, 1, 2, 3, 4, 5, 6, time.UTC)
+	tests := []Cursor{
+		{Sort: []string{}, Values: []interface{}{}, ID: testID},
+		{Sort: []string{"-created_at"}, Values: []interface{}{created}, ID: testID},
+		{Sort: []string{"name", "-checked"}, Values: []interface{}{"web-1", 2.5}, ID: testID, Before: true},
+		{Sort: []string{"error", "id"}, Values: []interface{}{false, testID}, ID: testID},
+		{Sort: []string{"name"}, Values: []interface{}{nil}, ID: testID},
+		{Sort: []string{"name"}, Values: []interface{}{`"quoted" ,/~`}, ID: testID},
+	}
+
+	for _, c := range tests {
+		decoded, err := DecodeCursor(c.Encode(), testFields)
+		if err != nil {
+			t.Errorf("DecodeCursor(%v) failed: %v", c, err)
+			continue
+		}
+		if decoded.ID != c.ID || decoded.Before != c.Before || !reflect.DeepEqual(decoded.Sort, c.Sort) {
+			t.Errorf("DecodeCursor(%v) = %v", c, decoded)
+			continue
+		}
+		for i, v := range c.Values {
+			if tv, ok := v.(time.Time); ok {
+				if dv, ok := decoded.Values[i].(time.Time); !ok || !dv.Equal(tv) {
+					t.Errorf("DecodeCursor(%v) value %d = %v, want %v", c, i, decoded.Values[i], v)
+				}
+			} else if !reflect.DeepEqual(decoded.Values[i], v) {
+				t.Errorf("DecodeCursor(%v) value %d = %#v, want %#v", c, i, decoded.Values[i], v)
+			}
+		}
+	}
+}
+
+func TestDecodeCursorRejects(t *testing.T) {
+	encode := func(s string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(s))
+	}
+	valid := Cursor{Sort: []string{"name"}, Values: []interface{}{"web-1"}, ID: testID}.Encode()
+
+	tests := []string{
+		"",
+		"not a cursor",
+		valid + "=",
+		valid[:len(valid)-2],
+		encode(`[]`),
+		encode(`{"s":["name"],"v":["web-1"]}`),
+		encode(`{"s":["name"],"v":["web-1"],"id":"web-1"}`),
+		encode(`{"s":["name"],"v":["web-1"],"id":"5a1f0c6e9d1b2c3d4e5f6a7"}`),
+		encode(`{"s":["name"],"v":[],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["name"],"v":["web-1","web-2"],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["name"],"v":[1],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["name"],"v":[{"$gt":""}],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["-created_at"],"v":["yesterday"],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["checked"],"v":["ten"],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["error"],"v":[1],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["id"],"v":["web-1"],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+		encode(`{"s":["password"],"v":["secret"],"id":"5a1f0c6e9d1b2c3d4e5f6a7b"}`),
+	}
+
+	for _, s := range tests {
+		if c, err := DecodeCursor(s, testFields); err != ErrInvalidCursor {
+			t.Errorf("DecodeCursor(%q) = %v, %v, want ErrInvalidCursor", s, c, err)
+		}
+	}
+}
+
+func TestCursorFilter(t *testing.T) {
+	tests := []struct {
+		cursor Cursor
+		filter utils.Filter
+		sort   utils.Sort
+	}{
+		{
+			Cursor{Sort: []string{"-created_at"}, Values: []interface{}{"t"}, ID: testID},
+			utils.Filter{"$or": []utils.Filter{
+				{"$or": []utils.Filter{{"created_at": bson.M{"$lt": "t"}}, {"created_at": nil}}},
+				{"created_at": "t", "_id": bson.M{"$lt": testID}},
+			}},
+			utils.Sort{"-created_at", "-_id"},
+		},
+		{
+			Cursor{Sort: []string{"-created_at"}, Values: []interface{}{"t"}, ID: testID, Before: true},
+			utils.Filter{"$or": []utils.Filter{
+				{"created_at": bson.M{"$gt": "t"}},
+				{"created_at": "t", "_id": bson.M{"$gt": testID}},
+			}},
+			utils.Sort{"created_at", "_id"},
+		},
+		{
+			Cursor{Sort: []string{"group_name", "-checked"}, Values: []interface{}{"a", 1.0}, ID: testID},
+			utils.Filter{"$or": []utils.Filter{
+				{"group.name": bson.M{"$gt": "a"}},
+				{"group.name": "a", "$or": []utils.Filter{{"checked": bson.M{"$lt": 1.0}}, {"checked": nil}}},
+				{"group.name": "a", "checked": 1.0, "_id": bson.M{"$lt": testID}},
+			}},
+			utils.Sort{"group.name", "-checked", "-_id"},
+		},
+		{
+			Cursor{Sort: []string{"name"}, Values: []interface{}{nil}, ID: testID},
+			utils.Filter{"$or": []utils.Filter{
+				{"name": bson.M{"$ne": nil}},
+				{"name": nil, "_id": bson.M{"$gt": testID}},
+			}},
+			utils.Sort{"name", "_id"},
+		},
+		{
+			Cursor{Sort: []string{"-name"}, Values: []interface{}{nil}, ID: testID},
+			utils.Filter{"$or": []utils.Filter{
+				{"name": nil, "_id": bson.M{"$lt": testID}},
+			}},
+			utils.Sort{"-name", "-_id"},
+		},
+		{
+			Cursor{Sort: []string{"name"}, Values: []interface{}{nil}, ID: testID, Before: true},
+			utils.Filter{"$or": []utils.Filter{
+				{"name": nil, "_id": bson.M{"$lt": testID}},
+			}},
+			utils.Sort{"-name", "-_id"},
+		},
+		{
+			Cursor{Sort: []string{"-id"}, Values: []interface{}{testID}, ID: testID},
+			utils.Filter{"$or": []utils.Filter{
+				{"_id": bson.M{"$lt": testID}},
+			}},
+			utils.Sort{"-_id"},
+		},
+		{
+			Cursor{ID: testID},
+			utils.Filter{"$or": []utils.Filter{
+				{"_id": bson.M{"$gt": testID}},
+			}},
+			utils.Sort{"_id"},
+		},
+	}
+
+	for _, test := range tests {
+		if filter := test.cursor.Filter(testFields); !reflect.DeepEqual(filter, test.filter) {
+			t.Errorf("%v.Filter() = %v, want %v", test.cursor, filter, test.filter)
+		}
+		if sort := test.cursor.StorageSort(testFields); !reflect.DeepEqual(sort, test.sort) {
+			t.Errorf("%v.StorageSort() = %v, want %v", test.cursor, sort, test.sort)
+		}
+	}
+}
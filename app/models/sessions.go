@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SessionsCollection is the storage collection of login sessions
+const SessionsCollection = "sessions"
+
+// ErrSessionNotFound is returned when no session matches the lookup
+var ErrSessionNotFound = errors.New("session not found")
+
+// How a session authenticated
+const (
+	SessionSignup   = "signup"
+	SessionPassword = "password"
+	SessionMFA      = "mfa"
+	SessionSSO      = "sso"
+)
+
+// Session is a login, the tokens issued for it carry its ID in the `sid`
+// claim and are rejected once it is revoked
+type Session struct {
+	ID         bson.ObjectId `json:"id" bson:"_id"`
+	UserID     bson.ObjectId `json:"user_id" bson:"user_id"`
+	Method     string        `json:"method" bson:"method"`
+	IP         string        `json:"ip" bson:"ip"`
+	UserAgent  string        `json:"user_agent" bson:"user_agent"`
+	Current    bool          `json:"current" bson:"-"` // Set when listing the sessions of the authenticated user
+	ExpiresAt  time.Time     `json:"expires_at" bson:"expires_at"`
+	LastUsedAt time.Time     `json:"last_used_at" bson:"last_used_at"`
+	RevokedAt  *time.Time    `json:"revoked_at,omitempty" bson:"revoked_at,omitempty"`
+	RefreshID  string        `json:"-" bson:"refresh_id,omitempty"` // The `jti` of the last refresh token issued
+	CreatedAt  time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// SessionID
+type SessionID struct {
+	ID string `json:"id"`
+}
+
+// IsActive checks if the session has neither been revoked nor expired
+func (s *Session) IsActive() bool {
+	return s.RevokedAt == nil && time.Now().Before(s.ExpiresAt)
+}
+
+// ActiveSessions returns a filter for the active sessions of the user
+func ActiveSessions(user bson.ObjectId) utils.Filter {
+	return utils.Filter{
+		"user_id":    user,
+		"revoked_at": nil,
+		"expires_at": bson.M{"$gt": time.Now()},
+	}
+}
+
+// FindSessions returns the sessions matching the filter, most recently used first
+func FindSessions(filter utils.Filter) ([]Session, error) {
+	var sessions []Session
+	err := Find(SessionsCollection, utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"-last_used_at"},
+	}, &sessions)
+	return sessions, err
+}
+
+// FindSession returns the session with the hex encoded ObjectId
+func FindSession(id string) (*Session, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, ErrSessionNotFound
+	}
+
+	sessions, err := FindSessions(utils.Filter{"_id": bson.ObjectIdHex(id)})
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) <= 0 {
+		return nil, ErrSessionNotFound
+	}
+	return &sessions[0], nil
+}
+
+// UpdateSession sets the fields of the session with the ID
+func UpdateSession(id bson.ObjectId, set bson.M) error {
+	return Update(SessionsCollection, utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": id},
+		Updates: utils.Updates{"$set": set},
+	})
+}
+
+// RotateSession sets the fields of the active session with the ID, if the
+// refresh token with the refresh ID is the last one issued for it. Of
+// concurrent requests with the refresh token only one rotates the session.
+func RotateSession(id bson.ObjectId, refreshID string, set bson.M) (bool, error) {
+	matched, err := UpdateCount(SessionsCollection, utils.UpdateOptions{
+		Filter: utils.Filter{
+			"_id":        id,
+			"revoked_at": nil,
+			"expires_at": bson.M{"$gt": time.Now()},
+			"refresh_id": refreshID,
+		},
+		Updates: utils.Updates{"$set": set},
+	})
+	return matched > 0, err
+}
+
+// RevokeSessions revokes the active sessions matching the filter
+func RevokeSessions(filter utils.Filter) error {
+	filter["revoked_at"] = nil
+	return Update(SessionsCollection, utils.UpdateOptions{
+		Filter:  filter,
+		Updates: utils.Updates{"$set": bson.M{"revoked_at": time.Now()}},
+	})
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SSOLoginsCollection is the storage collection of single sign-on logins
+// waiting for the identity provider to redirect back
+const SSOLoginsCollection = "sso_logins"
+
+// SSOLogin is a started single sign-on login, looked up by the hash of the
+// state parameter when the identity provider redirects back
+type SSOLogin struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	StateHash string        `json:"state_hash" bson:"state_hash"`
+	Nonce     string        `json:"nonce" bson:"nonce"`
+	Verifier  string        `json:"verifier" bson:"verifier"` // PKCE code verifier
+	ExpiresAt time.Time     `json:"expires_at" bson:"expires_at"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// SSOStart is the reply when starting a single sign-on login, the client
+// sends the user to URL
+type SSOStart struct {
+	URL string `json:"url"`
+}
+
+// SSOCallback - json data expected when the identity provider redirected back
+type SSOCallback struct {
+	Code  string `json:"code"`
+	State string `json:"state"`
+}
+
+// UseSSOLogin looks up the login with the state and deletes it, so it can
+// only be completed once
+func UseSSOLogin(stateHash string) (*SSOLogin, error) {
+	var logins []SSOLogin
+	err := Find(SSOLoginsCollection, utils.FindOptions{
+		Filter: utils.Filter{"state_hash": stateHash},
+	}, &logins)
+	if err != nil {
+		return nil, err
+	}
+	if len(logins) <= 0 {
+		return nil, ErrUserTokenInvalid
+	}
+	login := logins[0]
+
+	err = Delete(SSOLoginsCollection, utils.DeleteOptions{
+		Filter: utils.Filter{"_id": login.ID},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if time.Now().After(login.ExpiresAt) {
+		return nil, ErrUserTokenInvalid
+	}
+	return &login, nil
+}
@@ -0,0 +1,95 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// StorageTimeout is how long to wait for the storage service to reply
+var StorageTimeout = 5 * time.Second
+
+// storageReply is the reply of the storage service to a request
+type storageReply struct {
+	Error string          `json:"error"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// Request sends v to the storage service on the subject and decodes the
+// data of the reply into out, out may be nil if the data is not needed
+func Request(subject string, v interface{}, out interface{}) error {
+	if Conn == nil {
+		return errors.New("nats is not initialized")
+	}
+
+	data, err := bson.MarshalJSON(v)
+	if err != nil {
+		return err
+	}
+
+	msg, err := Conn.Request(subject, data, StorageTimeout)
+	if err != nil {
+		return err
+	}
+
+	var reply storageReply
+	if err := json.Unmarshal(msg.Data, &reply); err != nil {
+		return err
+	}
+	if reply.Error != "" {
+		return errors.New(reply.Error)
+	}
+	if out == nil || len(reply.Data) == 0 {
+		return nil
+	}
+	return bson.UnmarshalJSON(reply.Data, out)
+}
+
+// Create stores a new document in the collection
+func Create(collection string, doc interface{}) error {
+	return Request(collection+".create", doc, nil)
+}
+
+// Find decodes the documents in the collection matching the options into out
+func Find(collection string, find utils.FindOptions, out interface{}) error {
+	return Request(collection+".find", find, out)
+}
+
+// Has checks if any document in the collection matches the options
+func Has(collection string, has utils.HasOptions) (bool, error) {
+	var exists bool
+	err := Request(collection+".has", has, &exists)
+	return exists, err
+}
+
+// Update modifies the documents in the collection matching the options
+func Update(collection string, update utils.UpdateOptions) error {
+	return Request(collection+".update", update, nil)
+}
+
+// Delete removes the documents in the collection matching the options
+func Delete(collection string, del utils.DeleteOptions) error {
+	return Request(collection+".delete", del, nil)
+}
+
+// UpdateCount modifies the documents in the collection matching the options
+// and returns how many matched. The filter and update are applied at once by
+// the storage service, so it tells concurrent requests which one changed a
+// document.
+func UpdateCount(collection string, update utils.UpdateOptions) (int, error) {
+	var matched int
+	err := Request(collection+".update_count", update, &matched)
+	return matched, err
+}
+
+// DeleteCount removes the documents in the collection matching the options
+// and returns how many were removed, so that only one of concurrent requests
+// removes a document
+func DeleteCount(collection string, del utils.DeleteOptions) (int, error) {
+	var deleted int
+	err := Request(collection+".delete_count", del, &deleted)
+	return deleted, err
+}
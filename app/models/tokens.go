@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// RevokedTokensCollection is the storage collection of revoked token ids
+const RevokedTokensCollection = "revoked_tokens"
+
+// RevokedToken is a token id (jti) that is no longer accepted
+type RevokedToken struct {
+	ID        bson.ObjectId `json:"_id" bson:"_id"`
+	TokenID   string        `json:"jti" bson:"jti"`
+	ExpiresAt time.Time     `json:"expires_at" bson:"expires_at"` // The storage may drop the entry after this
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// RefreshRequest - json data expected for refreshing or revoking a token
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RevokeToken stores the token id as revoked until the token expires
+func RevokeToken(jti string, expiresAt time.Time) error {
+	return Create(RevokedTokensCollection, RevokedToken{
+		ID:        bson.NewObjectId(),
+		TokenID:   jti,
+		ExpiresAt: expiresAt,
+		CreatedAt: time.Now(),
+	})
+}
+
+// IsTokenRevoked checks if the token id has been revoked
+func IsTokenRevoked(jti string) (bool, error) {
+	return Has(RevokedTokensCollection, utils.HasOptions{
+		Filter: utils.Filter{"jti": jti},
+	})
+}
@@ -0,0 +1,100 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// UserTokensCollection is the storage collection of single-use user tokens
+const UserTokensCollection = "user_tokens"
+
+// Purposes of user tokens
+const (
+	PasswordResetToken = "password_reset"
+	VerifyEmailToken   = "verify_email"
+)
+
+// UserToken is a single-use, time-limited token sent to a user by email, only
+// the hash of the token is stored
+type UserToken struct {
+	ID        bson.ObjectId `json:"id" bson:"_id"`
+	UserID    bson.ObjectId `json:"user_id" bson:"user_id"`
+	Purpose   string        `json:"purpose" bson:"purpose"`
+	TokenHash string        `json:"token_hash" bson:"token_hash"`
+	Email     string        `json:"email,omitempty" bson:"email,omitempty"` // The email being verified
+	ExpiresAt time.Time     `json:"expires_at" bson:"expires_at"`
+	CreatedAt time.Time     `json:"created_at" bson:"created_at"`
+}
+
+// PasswordForgot - json data expected for requesting a password reset
+type PasswordForgot struct {
+	Email string `json:"email"`
+}
+
+// PasswordResetConfirm - json data expected for setting a new password with a reset token
+type PasswordResetConfirm struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// EmailVerify - json data expected for verifying an email
+type EmailVerify struct {
+	Token string `json:"token"`
+}
+
+// ErrUserTokenInvalid is returned when a user token is unknown, used or expired
+var ErrUserTokenInvalid = errors.New("invalid or expired token")
+
+// CreateUserToken stores a token for the user, replacing the unused tokens
+// of the user with the same purpose
+func CreateUserToken(token UserToken) error {
+	err := Delete(UserTokensCollection, utils.DeleteOptions{
+		Filter: utils.Filter{"user_id": token.UserID, "purpose": token.Purpose},
+	})
+	if err != nil {
+		return err
+	}
+	return Create(UserTokensCollection, token)
+}
+
+// DeleteUserTokens deletes the unused tokens of the user, e.g. once the
+// email they were sent to changed
+func DeleteUserTokens(user bson.ObjectId) error {
+	return Delete(UserTokensCollection, utils.DeleteOptions{
+		Filter: utils.Filter{"user_id": user},
+	})
+}
+
+// UseUserToken looks up the token with the purpose and deletes it, so it can
+// only be used once. Of concurrent requests with the token, only the one
+// deleting it gets it.
+func UseUserToken(purpose, hash string) (*UserToken, error) {
+	var tokens []UserToken
+	err := Find(UserTokensCollection, utils.FindOptions{
+		Filter: utils.Filter{"purpose": purpose, "token_hash": hash},
+	}, &tokens)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) <= 0 {
+		return nil, ErrUserTokenInvalid
+	}
+	token := tokens[0]
+
+	deleted, err := DeleteCount(UserTokensCollection, utils.DeleteOptions{
+		Filter: utils.Filter{"_id": token.ID, "token_hash": hash},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if deleted <= 0 {
+		return nil, ErrUserTokenInvalid
+	}
+	if time.Now().After(token.ExpiresAt) {
+		return nil, ErrUserTokenInvalid
+	}
+	return &token, nil
+}
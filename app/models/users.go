@@ -1,7 +1,243 @@
 package models
 
+import (
+	"errors"
+	"time"
+
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"golang.org/x/crypto/bcrypt"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// UsersCollection is the storage collection of users
+const UsersCollection = "users"
+
+// ErrUserNotFound is returned when no user matches the lookup
+var ErrUserNotFound = errors.New("user not found")
+
 type User struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Password string `json:"password"`
+	Invite   string `json:"invite"` // Invitation token, only used on signup
+}
+
+// UserView is a user as it is returned by the API, without any secrets
+type UserView struct {
+	ID            bson.ObjectId `json:"id"`
+	Username      string        `json:"username"`
+	Email         string        `json:"email"`
+	EmailVerified bool          `json:"email_verified"`
+	Roles         []string      `json:"roles"`
+	Permissions   []string      `json:"permissions"`
+	IsLocked      bool          `json:"is_locked"`
+	IsExpired     bool          `json:"is_expired"`
+	TOTPEnabled   bool          `json:"totp_enabled"`
+	CreatedAt     time.Time     `json:"created_at"`
+	UpdatedAt     time.Time     `json:"updated_at"`
+}
+
+// UserList - json data expected for listing users, a page starts after the
+// ID of the last user of the previous page
+type UserList struct {
+	After string `json:"after"`
+	Limit int    `json:"limit"`
+}
+
+// UserListPage is a page of users, Next is empty on the last page
+type UserListPage struct {
+	Users []UserView `json:"users"`
+	Next  string     `json:"next"`
+}
+
+// UserID
+type UserID struct {
+	ID string `json:"id"`
+}
+
+// UserDisable - json data expected for disabling or enabling a user
+type UserDisable struct {
+	ID       string `json:"id"`
+	Disabled bool   `json:"disabled"`
+}
+
+// UserPasswordReset - json data expected for an admin setting the password of a user
+type UserPasswordReset struct {
+	ID       string `json:"id"`
+	Password string `json:"password"`
+}
+
+// UserRoles - json data expected for changing the roles of a user
+type UserRoles struct {
+	ID    string   `json:"id"`
+	Roles []string `json:"roles"`
+}
+
+// PasswordChange - json data expected for users changing their own password
+type PasswordChange struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+// EmailChange - json data expected for users changing their own email
+type EmailChange struct {
+	Password string `json:"password"`
+	Email    string `json:"email"`
+}
+
+// NewUserView returns the user without any secrets
+func NewUserView(user *storageModel.User) UserView {
+	return UserView{
+		ID:            user.ID,
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Roles:         user.Roles,
+		Permissions:   user.Permissions,
+		IsLocked:      user.IsLocked,
+		IsExpired:     user.IsExpired,
+		TOTPEnabled:   user.TOTPEnabled,
+		CreatedAt:     user.CreatedAt,
+		UpdatedAt:     user.UpdatedAt,
+	}
+}
+
+// FindUser returns the newest user matching the filter
+func FindUser(filter utils.Filter) (*storageModel.User, error) {
+	if Conn == nil {
+		return nil, errors.New("nats is not initialized")
+	}
+
+	find := utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"-created_at"},
+		Limit:  1,
+	}
+
+	data, err := bson.MarshalJSON(find)
+	if err != nil {
+		return nil, err
+	}
+
+	users, err := utilNats.FindUser(Conn, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(users) <= 0 {
+		return nil, ErrUserNotFound
+	}
+
+	return &users[0], nil
+}
+
+// FindUserWithID returns the user with the hex encoded ObjectId
+func FindUserWithID(id string) (*storageModel.User, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, ErrUserNotFound
+	}
+	return FindUser(utils.Filter{"_id": bson.ObjectIdHex(id)})
+}
+
+// FindUserWithLogin returns the user with a matching username, falling back to the email
+func FindUserWithLogin(login string) (*storageModel.User, error) {
+	user, err := FindUser(utils.Filter{"username": login})
+	if err != ErrUserNotFound {
+		return user, err
+	}
+	return FindUser(utils.Filter{"email": login})
+}
+
+// HasUser checks if any user matches the filter
+func HasUser(filter utils.Filter) (bool, error) {
+	if Conn == nil {
+		return false, errors.New("nats is not initialized")
+	}
+
+	data, err := bson.MarshalJSON(utils.HasOptions{Filter: filter})
+	if err != nil {
+		return false, err
+	}
+
+	return utilNats.HasUser(Conn, data)
+}
+
+// CreateUser stores a new user, the timestamps are set to now
+func CreateUser(user *storageModel.User) error {
+	if Conn == nil {
+		return errors.New("nats is not initialized")
+	}
+
+	user.CreatedAt = time.Now()
+	user.UpdatedAt = user.CreatedAt
+
+	data, err := bson.MarshalJSON(user)
+	if err != nil {
+		return err
+	}
+
+	return utilNats.CreateUser(Conn, data)
+}
+
+// UpdateUser sets the fields of the user with the ID
+func UpdateUser(id bson.ObjectId, set bson.M) error {
+	if Conn == nil {
+		return errors.New("nats is not initialized")
+	}
+
+	set["updated_at"] = time.Now()
+	update := utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": id},
+		Updates: utils.Updates{"$set": set},
+	}
+
+	data, err := bson.MarshalJSON(update)
+	if err != nil {
+		return err
+	}
+
+	return utilNats.UpdateUser(Conn, data)
+}
+
+// UseTOTPStep records the TOTP time step as the last one used by the user and
+// reports if it is later than the previous one, so every code is only
+// accepted once even by concurrent requests
+func UseTOTPStep(id bson.ObjectId, step int64) (bool, error) {
+	matched, err := UpdateCount(UsersCollection, utils.UpdateOptions{
+		Filter: utils.Filter{"_id": id, "$or": []bson.M{
+			{"totp_step": bson.M{"$lt": step}},
+			{"totp_step": bson.M{"$exists": false}},
+		}},
+		Updates: utils.Updates{"$set": bson.M{"totp_step": step}},
+	})
+	return matched > 0, err
+}
+
+// UseRecoveryCode removes the recovery code hash from the user and reports if
+// it was still there, so every code is only accepted once even by concurrent
+// requests
+func UseRecoveryCode(id bson.ObjectId, hash string) (bool, error) {
+	matched, err := UpdateCount(UsersCollection, utils.UpdateOptions{
+		Filter: utils.Filter{"_id": id, "recovery_codes": hash},
+		Updates: utils.Updates{
+			"$pull": bson.M{"recovery_codes": hash},
+			"$set":  bson.M{"updated_at": time.Now()},
+		},
+	})
+	return matched > 0, err
+}
+
+// HashPassword - Hash the password (takes a username as well, it can be used for salting).
+func HashPassword(username, password string) string {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		panic("Permissions: bcrypt password hashing unsuccessful")
+	}
+	return string(hash)
+}
+
+// CheckPassword - compare a hashed password with a possible plaintext equivalent
+func CheckPassword(hashedPassword, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(password)) == nil
 }
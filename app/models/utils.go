@@ -12,9 +12,10 @@ var (
 
 type Response struct {
 	// MessageJSON - json data for outputting
-	Success bool        `json:"success"` // Wether an error occured or not
-	Message string      `json:"message"` // The message
-	Data    interface{} `json:"data"`    // Extra data, generally it will contain a struct
+	Success bool        `json:"success"`        // Wether an error occured or not
+	Message string      `json:"message"`        // The message
+	Data    interface{} `json:"data"`           // Extra data, generally it will contain a struct
+	Page    *Page       `json:"page,omitempty"` // Pagination of lists
 }
 
 // TODO: Consider about being more specific about editing requests rather then going with this option.
@@ -0,0 +1,406 @@
+// Package openapi generates the OpenAPI 3 document of the API from the
+// routes of `routes.conf` and the actions they call. Parameters of the
+// actions that are structs are read from the request body as JSON, the
+// others from the path, and their schemas follow the JSON tags of the types.
+// What the routes can't tell, e.g. which actions reply with a page of a list,
+// is described with Action.
+package openapi
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/query"
+)
+
+// Version is the version of the OpenAPI specification of the documents
+const Version = "3.0.3"
+
+// Document is an OpenAPI document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Tags       []Tag               `json:"tags,omitempty"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+// Info describes the API
+type Info struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+// Tag groups the operations of a controller
+type Tag struct {
+	Name string `json:"name"`
+}
+
+// PathItem holds the operations of a path by lower case HTTP method
+type PathItem map[string]*Operation
+
+// Operation describes a route
+type Operation struct {
+	OperationID string                `json:"operationId"`
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Permissions []string              `json:"x-permissions,omitempty"`
+}
+
+// Parameter describes a path or query parameter
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+// RequestBody describes the body of a request by content type
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+// MediaType holds the schema of a content type
+type MediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// Response describes a reply
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Components holds the schemas of the named types and the security schemes
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+// SecurityScheme describes a way of authenticating requests
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Description  string `json:"description,omitempty"`
+	Scheme       string `json:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+	Name         string `json:"name,omitempty"`
+	In           string `json:"in,omitempty"`
+}
+
+// Action describes what the routes and parameters can't tell about an action
+type Action struct {
+	Summary string       // Derived from the action name when empty
+	Data    interface{}  // Value of the type of the `data` of successful replies
+	List    query.Fields // Fields of the lists replied a page at a time
+	Patch   patch.Schema // Schema of the patch read from the body
+	Query   []*Parameter // Query parameters besides those of lists
+}
+
+// Generator generates the document of the routes
+type Generator struct {
+	Info Info
+
+	// Controllers are nil pointers to the controllers of the routes, e.g.
+	// `(*controllers.ClientsController)(nil)`
+	Controllers []interface{}
+
+	// Actions are keyed by controller and action, e.g.
+	// `ClientsController.GetClients`
+	Actions map[string]Action
+
+	// Envelope is a value of the type every reply is wrapped in, its `data`
+	// member is replaced by the data of the action
+	Envelope interface{}
+
+	// Deprecated maps the paths of deprecated routes to their successors
+	Deprecated map[string]string
+
+	// SecuritySchemes are required by every route that isn't anonymous
+	SecuritySchemes map[string]*SecurityScheme
+}
+
+// Generate returns the document of the routes, the actions of the routes of
+// the controllers have to exist
+func (g *Generator) Generate(routes []Route) (*Document, error) {
+	controllers := make(map[string]reflect.Type)
+	for _, c := range g.Controllers {
+		t := reflect.TypeOf(c)
+		controllers[t.Elem().Name()] = t
+	}
+
+	s := newSchemas()
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    g.Info,
+		Paths:   make(map[string]PathItem),
+		Components: Components{
+			Schemas:         s.components,
+			SecuritySchemes: g.SecuritySchemes,
+		},
+	}
+
+	var security []map[string][]string
+	for name := range g.SecuritySchemes {
+		security = append(security, map[string][]string{name: {}})
+	}
+	sort.Slice(security, func(i, j int) bool { return firstKey(security[i]) < firstKey(security[j]) })
+
+	envelope := s.of(reflect.TypeOf(g.Envelope))
+	tags := make(map[string]bool)
+	for _, r := range routes {
+		// routes of controllers that don't exist can't be served
+		t, found := controllers[r.Controller]
+		if !found {
+			continue
+		}
+		m, found := t.MethodByName(r.Action)
+		if !found {
+			return nil, fmt.Errorf("route %s: unknown action %s.%s", r.Name, r.Controller, r.Action)
+		}
+
+		action := g.Actions[r.Controller+"."+r.Action]
+		tag := tagName(r.Controller)
+		tags[tag] = true
+
+		op := &Operation{
+			OperationID: operationID(r.Name),
+			Summary:     action.Summary,
+			Tags:        []string{tag},
+			Responses:   make(map[string]*Response),
+		}
+		if op.Summary == "" {
+			op.Summary = summary(r.Action, tag)
+		}
+
+		path, params := openAPIPath(r.Path)
+		if err := op.addParameters(s, m.Type, params); err != nil {
+			return nil, fmt.Errorf("route %s: %v", r.Name, err)
+		}
+		if action.List != nil {
+			op.Parameters = append(op.Parameters, listParameters(action.List)...)
+		}
+		op.Parameters = append(op.Parameters, action.Query...)
+		if action.Patch != nil {
+			op.RequestBody = patchBody(s, action.Patch)
+		}
+
+		if successor, found := g.Deprecated[r.Path]; found {
+			op.Deprecated = true
+			op.Description = "Deprecated, use `" + successor + "` instead."
+		}
+		if r.Auth != "anonymous" {
+			op.Security = security
+			op.Permissions = r.Permissions
+			if len(r.Permissions) > 0 {
+				op.Description = strings.TrimSpace(op.Description + " Requires the permissions `" + strings.Join(r.Permissions, "`, `") + "`.")
+			}
+		}
+
+		op.addResponses(s, envelope, action, r.Auth != "anonymous", len(params) > 0)
+
+		item, found := doc.Paths[path]
+		if !found {
+			item = make(PathItem)
+			doc.Paths[path] = item
+		}
+		for _, method := range strings.Split(r.Method, ",") {
+			item[strings.ToLower(strings.TrimSpace(method))] = op
+		}
+	}
+
+	for tag := range tags {
+		doc.Tags = append(doc.Tags, Tag{Name: tag})
+	}
+	sort.Slice(doc.Tags, func(i, j int) bool { return doc.Tags[i].Name < doc.Tags[j].Name })
+	return doc, nil
+}
+
+// addParameters adds the parameters of the action, structs are read from
+// the body and the others from the path parameters in order
+func (op *Operation) addParameters(s *schemas, method reflect.Type, params []string) error {
+	// the first parameter is the controller
+	for i := 1; i < method.NumIn(); i++ {
+		t := method.In(i)
+		if t.Kind() == reflect.Struct {
+			op.RequestBody = &RequestBody{
+				Required: true,
+				Content:  map[string]MediaType{"application/json": {Schema: s.of(t)}},
+			}
+			continue
+		}
+
+		if len(params) <= 0 {
+			return fmt.Errorf("parameter %d of the action isn't a path parameter", i)
+		}
+		op.Parameters = append(op.Parameters, &Parameter{Name: params[0], In: "path", Required: true, Schema: s.of(t)})
+		params = params[1:]
+	}
+	return nil
+}
+
+// addResponses adds the replies of the action, every reply is wrapped in the
+// envelope
+func (op *Operation) addResponses(s *schemas, envelope *Schema, action Action, auth, path bool) {
+	reply := envelope
+	if action.Data != nil {
+		data := s.of(reflect.TypeOf(action.Data))
+		reply = &Schema{AllOf: []*Schema{envelope, {Type: "object", Properties: map[string]*Schema{"data": data}}}}
+	}
+
+	content := func(schema *Schema) map[string]MediaType {
+		return map[string]MediaType{"application/json": {Schema: schema}}
+	}
+	op.Responses["200"] = &Response{Description: "Success", Content: content(reply)}
+	op.Responses["400"] = &Response{Description: "Invalid request", Content: content(envelope)}
+	if auth {
+		op.Responses["401"] = &Response{Description: "Not authenticated", Content: content(envelope)}
+		op.Responses["403"] = &Response{Description: "Not permitted", Content: content(envelope)}
+	}
+	if path {
+		op.Responses["404"] = &Response{Description: "Not found", Content: content(envelope)}
+	}
+	if action.Patch != nil {
+		op.Responses["413"] = &Response{Description: "The patch is too large", Content: content(envelope)}
+		op.Responses["415"] = &Response{Description: "Unsupported patch type", Content: content(envelope)}
+	}
+	op.Responses["500"] = &Response{Description: "Internal error", Content: content(envelope)}
+}
+
+// listParameters returns the query parameters of lists of the fields
+func listParameters(fields query.Fields) []*Parameter {
+	var all, sortable, selectable []string
+	for name, f := range fields {
+		all = append(all, name)
+		if !f.NoSort {
+			sortable = append(sortable, name)
+		}
+		if !f.NoSelect {
+			selectable = append(selectable, name)
+		}
+	}
+	sort.Strings(all)
+	sort.Strings(sortable)
+	sort.Strings(selectable)
+
+	str := &Schema{Type: "string"}
+	return []*Parameter{
+		{Name: "filter", In: "query", Schema: str, Description: "Comma separated conditions, e.g. `name~\"^web-\",created_at>=2018-01-01T00:00:00Z`, on the fields " + quoteList(all) + "."},
+		{Name: "sort", In: "query", Schema: str, Description: "Comma separated fields, descending when prefixed with `-`, of " + quoteList(sortable) + "."},
+		{Name: "fields", In: "query", Schema: str, Description: "Comma separated fields to reply with, of " + quoteList(selectable) + "."},
+		{Name: "limit", In: "query", Schema: &Schema{Type: "integer", Minimum: floatPtr(1)}, Description: "Size of the page."},
+		{Name: "cursor", In: "query", Schema: str, Description: "The `next` or `prev` cursor of the page of a previous reply."},
+		{Name: "total", In: "query", Schema: &Schema{Type: "boolean"}, Description: "Count the entities matching the filter, up to a limit after which `total_capped` is set."},
+	}
+}
+
+// patchBody returns the body of actions reading a JSON Merge Patch or JSON
+// Patch of the schema
+func patchBody(s *schemas, schema patch.Schema) *RequestBody {
+	merge := patchSchema(&patch.Field{Type: patch.Object, Fields: schema})
+	// a merge patch only holds the changed members
+	merge.Required = nil
+
+	return &RequestBody{
+		Required: true,
+		Content: map[string]MediaType{
+			patch.MergePatchType: {Schema: merge},
+			patch.JSONPatchType:  {Schema: &Schema{Type: "array", Items: s.of(reflect.TypeOf(patch.Operation{}))}},
+		},
+	}
+}
+
+// openAPIPath converts the parameters of a route path, e.g. `/clients/:id`,
+// to those of OpenAPI, e.g. `/clients/{id}`, and returns their names
+func openAPIPath(path string) (string, []string) {
+	var params []string
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			params = append(params, segment[1:])
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
+
+// operationID converts a route name, e.g. `v2_list_clients`, to camel case
+func operationID(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// tagName returns the tag of the operations of a controller, the
+// controllers of every API version share theirs
+func tagName(controller string) string {
+	name := strings.TrimSuffix(controller, "Controller")
+	if i := strings.LastIndex(name, "V"); i > 0 && isDigits(name[i+1:]) {
+		name = name[:i]
+	}
+	return name
+}
+
+// summary derives the summary of an action from its name, e.g.
+// `GetClientWithID` becomes "Get client with ID", single words are
+// followed by the tag
+func summary(action, tag string) string {
+	var words []string
+	start := 0
+	runes := []rune(action)
+	for i := 1; i <= len(runes); i++ {
+		if i == len(runes) || (unicode.IsUpper(runes[i]) && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])))) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	for i := 1; i < len(words); i++ {
+		if strings.ToUpper(words[i]) != words[i] {
+			words[i] = strings.ToLower(words[i])
+		}
+	}
+	if len(words) == 1 {
+		words = append(words, strings.ToLower(tag))
+	}
+	return strings.Join(words, " ")
+}
+
+func quoteList(names []string) string {
+	return "`" + strings.Join(names, "`, `") + "`"
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func firstKey(m map[string][]string) string {
+	for k := range m {
+		return k
+	}
+	return ""
+}
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
@@ -0,0 +1,97 @@
+package openapi
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"aahframework.org/config.v0"
+)
+
+// Route is a route of `routes.conf`
+type Route struct {
+	Name        string
+	Path        string
+	Method      string
+	Controller  string
+	Action      string
+	Auth        string
+	Permissions []string // Permissions checked with `ispermitted`, e.g. `clients:write`
+}
+
+var permissionPattern = regexp.MustCompile(`^ispermitted\((.+)\)$`)
+
+// LoadRoutes reads the routes of every domain of the routes configuration
+// file, ordered by path and method
+func LoadRoutes(file string) ([]Route, error) {
+	cfg, err := config.LoadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the routes: %v", err)
+	}
+
+	var routes []Route
+	for _, domain := range cfg.KeysByPath("domains") {
+		prefix := "domains." + domain
+		auth := cfg.StringDefault(prefix+".default_auth", "anonymous")
+		routes = append(routes, loadRoutes(cfg, prefix+".routes", Route{Auth: auth})...)
+	}
+
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes, nil
+}
+
+// loadRoutes reads the routes of the section, nested routes inherit the
+// path, controller and auth of their parent
+func loadRoutes(cfg *config.Config, section string, parent Route) []Route {
+	var routes []Route
+	for _, name := range cfg.KeysByPath(section) {
+		key := section + "." + name
+		r := Route{
+			Name:       name,
+			Path:       parent.Path + cfg.StringDefault(key+".path", ""),
+			Method:     cfg.StringDefault(key+".method", "GET"),
+			Controller: controllerName(cfg.StringDefault(key+".controller", parent.Controller)),
+			Action:     cfg.StringDefault(key+".action", ""),
+			Auth:       cfg.StringDefault(key+".auth", parent.Auth),
+		}
+
+		permissions, _ := cfg.StringList(key + ".authorization.permissions")
+		for _, p := range permissions {
+			if m := permissionPattern.FindStringSubmatch(p); m != nil {
+				r.Permissions = append(r.Permissions, m[1])
+			}
+		}
+
+		if r.Action == "" {
+			// aah's defaults of the action by method
+			r.Action = map[string]string{
+				"GET": "Index", "POST": "Create", "PUT": "Update",
+				"PATCH": "Update", "DELETE": "Delete", "OPTIONS": "Options", "HEAD": "Head",
+			}[r.Method]
+		}
+
+		// a namespace only groups its routes
+		if cfg.IsExists(key + ".routes") {
+			routes = append(routes, loadRoutes(cfg, key+".routes", r)...)
+			continue
+		}
+		routes = append(routes, r)
+	}
+	return routes
+}
+
+// controllerName returns the type name of a route controller, which may
+// have a package prefix and leave out the `Controller` suffix
+func controllerName(name string) string {
+	name = name[strings.LastIndex(name, "/")+1:]
+	if name != "" && !strings.HasSuffix(name, "Controller") {
+		name += "Controller"
+	}
+	return name
+}
@@ -0,0 +1,188 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/keiwi/api/app/patch"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Schema is an OpenAPI schema
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            int                `json:"minLength,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+}
+
+// objectIDPattern matches the hex form of ObjectIds
+const objectIDPattern = "^[0-9a-f]{24}$"
+
+var (
+	objectIDType   = reflect.TypeOf(bson.ObjectId(""))
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas holds the schemas of the named struct types, which are referenced
+// from the other schemas
+type schemas struct {
+	components map[string]*Schema
+	types      map[string]reflect.Type
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: make(map[string]*Schema), types: make(map[string]reflect.Type)}
+}
+
+// of returns the schema of the type as it is marshaled to JSON
+func (s *schemas) of(t reflect.Type) *Schema {
+	switch t {
+	case objectIDType:
+		return &Schema{Type: "string", Pattern: objectIDPattern}
+	case timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		schema := s.of(t.Elem())
+		if schema.Ref != "" {
+			return schema
+		}
+		schema.Nullable = true
+		return schema
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint8, reflect.Uint16:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint32, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: s.of(t.Elem()), Nullable: t.Kind() == reflect.Slice}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: s.of(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.object(t)
+		}
+		return s.ref(t)
+	}
+	// interfaces may hold any value
+	return &Schema{}
+}
+
+// ref returns a reference to the component of the named struct type,
+// adding it first if needed
+func (s *schemas) ref(t reflect.Type) *Schema {
+	name := t.Name()
+	if other, found := s.types[name]; found && other != t {
+		// types of different packages sharing a name
+		name = strings.Title(t.PkgPath()[strings.LastIndex(t.PkgPath(), "/")+1:]) + name
+	}
+
+	if _, found := s.types[name]; !found {
+		s.types[name] = t
+		// the placeholder ends recursion of self-referencing types
+		s.components[name] = &Schema{}
+		*s.components[name] = *s.object(t)
+	}
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+// object returns the schema of the struct type with the members of its JSON
+// form, embedded structs are flattened
+func (s *schemas) object(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" || (f.PkgPath != "" && !f.Anonymous) {
+			continue
+		}
+
+		name := strings.Split(tag, ",")[0]
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				for n, p := range s.object(ft).Properties {
+					schema.Properties[n] = p
+				}
+				continue
+			}
+		}
+		if name == "" {
+			name = f.Name
+		}
+		schema.Properties[name] = s.of(f.Type)
+	}
+	return schema
+}
+
+// patchSchema returns the schema of a patch schema field
+func patchSchema(f *patch.Field) *Schema {
+	switch f.Type {
+	case patch.String:
+		schema := &Schema{Type: "string"}
+		if f.Required {
+			schema.MinLength = 1
+		}
+		return schema
+	case patch.Integer:
+		schema := &Schema{Type: "integer"}
+		if f.Max > f.Min {
+			schema.Minimum, schema.Maximum = floatPtr(float64(f.Min)), floatPtr(float64(f.Max))
+		}
+		return schema
+	case patch.Boolean:
+		return &Schema{Type: "boolean"}
+	case patch.ObjectID:
+		return &Schema{Type: "string", Pattern: objectIDPattern}
+	case patch.Array:
+		schema := &Schema{Type: "array"}
+		if f.Items != nil {
+			schema.Items = patchSchema(f.Items)
+		}
+		return schema
+	case patch.Object:
+		schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+		for name, field := range f.Fields {
+			prop := patchSchema(field)
+			if field.Required {
+				schema.Required = append(schema.Required, name)
+			} else {
+				// a merge patch removes members set to null
+				prop.Nullable = true
+			}
+			schema.Properties[name] = prop
+		}
+		sort.Strings(schema.Required)
+		return schema
+	}
+	return &Schema{}
+}
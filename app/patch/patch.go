@@ -0,0 +1,333 @@
+// Package patch applies JSON Merge Patch (RFC 7396) and JSON Patch
+// (RFC 6902) documents to JSON objects, and validates the patched objects
+// against a schema.
+package patch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Content types of the patch formats
+const (
+	MergePatchType = "application/merge-patch+json"
+	JSONPatchType  = "application/json-patch+json"
+)
+
+// ErrUnsupportedType is returned for patches of any other content type
+var ErrUnsupportedType = errors.New("unsupported patch content type")
+
+// Operation is an operation of a JSON Patch document
+type Operation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// Apply applies the patch of the content type to a copy of the document,
+// `application/json` is treated as a merge patch
+func Apply(contentType string, doc map[string]interface{}, data []byte) (map[string]interface{}, error) {
+	var target interface{}
+	if err := clone(doc, &target); err != nil {
+		return nil, err
+	}
+
+	var result interface{}
+	switch contentType {
+	case MergePatchType, "application/json":
+		var p interface{}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, err
+		}
+		result = MergePatch(target, p)
+	case JSONPatchType:
+		var ops []Operation
+		if err := json.Unmarshal(data, &ops); err != nil {
+			return nil, err
+		}
+		var err error
+		if result, err = JSONPatch(target, ops); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, ErrUnsupportedType
+	}
+
+	patched, ok := result.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the patched document is not an object")
+	}
+	return patched, nil
+}
+
+// MergePatch applies a merge patch to the target as described in RFC 7396,
+// objects of the target are modified in place
+func MergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{})
+	}
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+		} else {
+			t[k] = MergePatch(t[k], v)
+		}
+	}
+	return t
+}
+
+// JSONPatch applies the operations to the document in order as described in
+// RFC 6902, the document is modified in place. Either every operation is
+// applied or an error is returned.
+func JSONPatch(doc interface{}, ops []Operation) (interface{}, error) {
+	for i, op := range ops {
+		var err error
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %v", i, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc interface{}, op Operation) (interface{}, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op.Op {
+	case "add", "replace", "test":
+		if len(op.Value) <= 0 {
+			return nil, errors.New("value is missing")
+		}
+		var value interface{}
+		if err := json.Unmarshal(op.Value, &value); err != nil {
+			return nil, err
+		}
+
+		switch op.Op {
+		case "add":
+			return add(doc, path, value)
+		case "replace":
+			return replace(doc, path, value)
+		}
+		current, err := get(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !reflect.DeepEqual(current, value) {
+			return nil, errors.New("test failed")
+		}
+		return doc, nil
+	case "remove":
+		doc, _, err = remove(doc, path)
+		return doc, err
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+
+		var value interface{}
+		if op.Op == "move" {
+			if isPrefix(from, path) && len(from) < len(path) {
+				return nil, errors.New("can't move a value into itself")
+			}
+			if doc, value, err = remove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			v, err := get(doc, from)
+			if err != nil {
+				return nil, err
+			}
+			if err := clone(v, &value); err != nil {
+				return nil, err
+			}
+		}
+		return add(doc, path, value)
+	}
+	return nil, fmt.Errorf("unknown operation '%s'", op.Op)
+}
+
+// parsePointer splits a JSON pointer (RFC 6901) into its unescaped tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer '%s'", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.Replace(strings.Replace(t, "~1", "/", -1), "~0", "~", -1)
+	}
+	return tokens, nil
+}
+
+func isPrefix(prefix, tokens []string) bool {
+	if len(prefix) > len(tokens) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != tokens[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// index parses an array index, which has to be less than n
+func index(token string, n int) (int, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i >= n || token != strconv.Itoa(i) {
+		return 0, fmt.Errorf("invalid array index '%s'", token)
+	}
+	return i, nil
+}
+
+// child returns the member or element of the container
+func child(doc interface{}, token string) (interface{}, error) {
+	switch c := doc.(type) {
+	case map[string]interface{}:
+		v, found := c[token]
+		if !found {
+			return nil, fmt.Errorf("member '%s' doesn't exist", token)
+		}
+		return v, nil
+	case []interface{}:
+		i, err := index(token, len(c))
+		if err != nil {
+			return nil, err
+		}
+		return c[i], nil
+	}
+	return nil, fmt.Errorf("can't look up '%s' in a value", token)
+}
+
+func get(doc interface{}, path []string) (interface{}, error) {
+	for _, token := range path {
+		var err error
+		if doc, err = child(doc, token); err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// modify calls fn with the container of the last token of the path and
+// stores the container it returns in place of the old one
+func modify(doc interface{}, path []string, fn func(container interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+
+	c, err := child(doc, path[0])
+	if err != nil {
+		return nil, err
+	}
+	if c, err = modify(c, path[1:], fn); err != nil {
+		return nil, err
+	}
+
+	switch p := doc.(type) {
+	case map[string]interface{}:
+		p[path[0]] = c
+	case []interface{}:
+		i, _ := index(path[0], len(p))
+		p[i] = c
+	}
+	return doc, nil
+}
+
+func add(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return modify(doc, path, func(container interface{}, token string) (interface{}, error) {
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+			return c, nil
+		case []interface{}:
+			i := len(c)
+			if token != "-" {
+				var err error
+				if i, err = index(token, len(c)+1); err != nil {
+					return nil, err
+				}
+			}
+			c = append(c, nil)
+			copy(c[i+1:], c[i:])
+			c[i] = value
+			return c, nil
+		}
+		return nil, fmt.Errorf("can't add '%s' to a value", token)
+	})
+}
+
+func replace(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return modify(doc, path, func(container interface{}, token string) (interface{}, error) {
+		if _, err := child(container, token); err != nil {
+			return nil, err
+		}
+		switch c := container.(type) {
+		case map[string]interface{}:
+			c[token] = value
+		case []interface{}:
+			i, _ := index(token, len(c))
+			c[i] = value
+		}
+		return container, nil
+	})
+}
+
+// remove removes the value at the path and returns it
+func remove(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, errors.New("can't remove the document")
+	}
+
+	var removed interface{}
+	doc, err := modify(doc, path, func(container interface{}, token string) (interface{}, error) {
+		var err error
+		if removed, err = child(container, token); err != nil {
+			return nil, err
+		}
+		switch c := container.(type) {
+		case map[string]interface{}:
+			delete(c, token)
+		case []interface{}:
+			i, _ := index(token, len(c))
+			return append(c[:i], c[i+1:]...), nil
+		}
+		return container, nil
+	})
+	return doc, removed, err
+}
+
+// clone deep copies v into out through JSON
+func clone(v interface{}, out interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,135 @@
+package patch
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func decode(t *testing.T, s string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(s), &v); err != nil {
+		t.Fatalf("invalid JSON %s: %v", s, err)
+	}
+	return v
+}
+
+// The examples of RFC 7396 appendix A
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		target, patch, result string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+
+	for _, test := range tests {
+		result := MergePatch(decode(t, test.target), decode(t, test.patch))
+		if !reflect.DeepEqual(result, decode(t, test.result)) {
+			t.Errorf("MergePatch(%s, %s) = %v, want %s", test.target, test.patch, result, test.result)
+		}
+	}
+}
+
+// The examples of RFC 6902 appendix A, result is empty when the patch fails
+func TestJSONPatch(t *testing.T) {
+	tests := []struct {
+		name, doc, patch, result string
+	}{
+		{"A.1", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux"}]`, `{"baz":"qux","foo":"bar"}`},
+		{"A.2", `{"foo":["bar","baz"]}`, `[{"op":"add","path":"/foo/1","value":"qux"}]`, `{"foo":["bar","qux","baz"]}`},
+		{"A.3", `{"baz":"qux","foo":"bar"}`, `[{"op":"remove","path":"/baz"}]`, `{"foo":"bar"}`},
+		{"A.4", `{"foo":["bar","qux","baz"]}`, `[{"op":"remove","path":"/foo/1"}]`, `{"foo":["bar","baz"]}`},
+		{"A.5", `{"baz":"qux","foo":"bar"}`, `[{"op":"replace","path":"/baz","value":"boo"}]`, `{"baz":"boo","foo":"bar"}`},
+		{"A.6", `{"foo":{"bar":"baz","waldo":"fred"},"qux":{"corge":"grault"}}`, `[{"op":"move","from":"/foo/waldo","path":"/qux/thud"}]`, `{"foo":{"bar":"baz"},"qux":{"corge":"grault","thud":"fred"}}`},
+		{"A.7", `{"foo":["all","grass","cows","eat"]}`, `[{"op":"move","from":"/foo/1","path":"/foo/3"}]`, `{"foo":["all","cows","eat","grass"]}`},
+		{"A.8", `{"baz":"qux","foo":["a",2,"c"]}`, `[{"op":"test","path":"/baz","value":"qux"},{"op":"test","path":"/foo/1","value":2}]`, `{"baz":"qux","foo":["a",2,"c"]}`},
+		{"A.9", `{"baz":"qux"}`, `[{"op":"test","path":"/baz","value":"bar"}]`, ``},
+		{"A.10", `{"foo":"bar"}`, `[{"op":"add","path":"/child","value":{"grandchild":{}}}]`, `{"foo":"bar","child":{"grandchild":{}}}`},
+		{"A.11", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","xyz":123}]`, `{"foo":"bar","baz":"qux"}`},
+		{"A.12", `{"foo":"bar"}`, `[{"op":"add","path":"/baz/bat","value":"qux"}]`, ``},
+		{"A.13", `{"foo":"bar"}`, `[{"op":"add","path":"/baz","value":"qux","op":"remove"}]`, ``},
+		{"A.14", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":10}]`, `{"/":9,"~1":10}`},
+		{"A.15", `{"/":9,"~1":10}`, `[{"op":"test","path":"/~01","value":"10"}]`, ``},
+		{"A.16", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/-","value":["abc","def"]}]`, `{"foo":["bar",["abc","def"]]}`},
+
+		{"missing value", `{"foo":"bar"}`, `[{"op":"replace","path":"/foo"}]`, ``},
+		{"unknown op", `{"foo":"bar"}`, `[{"op":"merge","path":"/foo","value":1}]`, ``},
+		{"invalid pointer", `{"foo":"bar"}`, `[{"op":"remove","path":"foo"}]`, ``},
+		{"index out of range", `{"foo":["bar"]}`, `[{"op":"add","path":"/foo/2","value":1}]`, ``},
+		{"leading zero index", `{"foo":["bar","baz"]}`, `[{"op":"remove","path":"/foo/01"}]`, ``},
+		{"move into itself", `{"foo":{"bar":1}}`, `[{"op":"move","from":"/foo","path":"/foo/bar"}]`, ``},
+		{"copy", `{"foo":{"bar":1}}`, `[{"op":"copy","from":"/foo","path":"/baz"}]`, `{"foo":{"bar":1},"baz":{"bar":1}}`},
+	}
+
+	for _, test := range tests {
+		var ops []Operation
+		if err := json.Unmarshal([]byte(test.patch), &ops); err != nil {
+			t.Fatalf("%s: invalid patch: %v", test.name, err)
+		}
+
+		result, err := JSONPatch(decode(t, test.doc), ops)
+		if test.result == "" {
+			if err == nil {
+				t.Errorf("%s: JSONPatch = %v, want an error", test.name, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: JSONPatch failed: %v", test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, decode(t, test.result)) {
+			t.Errorf("%s: JSONPatch = %v, want %s", test.name, result, test.result)
+		}
+	}
+}
+
+func TestApply(t *testing.T) {
+	doc := map[string]interface{}{"name": "web-1", "ip": "10.0.0.1"}
+	tests := []struct {
+		contentType, patch, result string
+	}{
+		{MergePatchType, `{"name":"web-2","ip":null}`, `{"name":"web-2"}`},
+		{"application/json", `{"name":"web-2"}`, `{"name":"web-2","ip":"10.0.0.1"}`},
+		{JSONPatchType, `[{"op":"replace","path":"/name","value":"web-2"}]`, `{"name":"web-2","ip":"10.0.0.1"}`},
+		{MergePatchType, `"web-2"`, ``},
+		{JSONPatchType, `[{"op":"replace","path":"","value":[]}]`, ``},
+		{"text/plain", `{}`, ``},
+	}
+
+	for _, test := range tests {
+		result, err := Apply(test.contentType, doc, []byte(test.patch))
+		if test.result == "" {
+			if err == nil {
+				t.Errorf("Apply(%s, %s) = %v, want an error", test.contentType, test.patch, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Apply(%s, %s) failed: %v", test.contentType, test.patch, err)
+			continue
+		}
+		if !reflect.DeepEqual(interface{}(result), decode(t, test.result)) {
+			t.Errorf("Apply(%s, %s) = %v, want %s", test.contentType, test.patch, result, test.result)
+		}
+	}
+
+	if doc["name"] != "web-1" || doc["ip"] != "10.0.0.1" {
+		t.Errorf("Apply modified the document: %v", doc)
+	}
+}
@@ -0,0 +1,171 @@
+package patch
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Types of schema fields
+const (
+	String   = "string"
+	Integer  = "integer"
+	Boolean  = "boolean"
+	ObjectID = "objectid"
+	Array    = "array"
+	Object   = "object"
+)
+
+// Schema describes the fields of a document that may be patched
+type Schema map[string]*Field
+
+// Field describes the value of a document field
+type Field struct {
+	Type     string
+	Required bool   // Missing, null and empty strings are rejected
+	Min, Max int64  // Bounds of integers, checked when Max is greater than Min
+	Items    *Field // Elements of arrays, any non-null value when nil
+	Fields   Schema // Members of objects
+}
+
+// ValidationError is returned for a document not matching its schema
+type ValidationError struct {
+	Path    string
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s %s", e.Path, e.Message)
+}
+
+// Document returns the fields of the schema of v, as v is marshaled to JSON
+func (s Schema) Document(v interface{}) (map[string]interface{}, error) {
+	var doc map[string]interface{}
+	if err := clone(v, &doc); err != nil {
+		return nil, err
+	}
+	return s.filter(doc), nil
+}
+
+// filter removes the members of the object, and its nested objects, that
+// aren't part of the schema
+func (s Schema) filter(doc map[string]interface{}) map[string]interface{} {
+	filtered := make(map[string]interface{})
+	for name, f := range s {
+		if v, found := doc[name]; found {
+			filtered[name] = f.filter(v)
+		}
+	}
+	return filtered
+}
+
+func (f *Field) filter(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		if f.Type == Object {
+			return f.Fields.filter(value)
+		}
+	case []interface{}:
+		if f.Type == Array && f.Items != nil {
+			for i := range value {
+				value[i] = f.Items.filter(value[i])
+			}
+		}
+	}
+	return v
+}
+
+// Validate checks that the document only has fields of the schema and that
+// every field matches its description
+func (s Schema) Validate(doc map[string]interface{}) error {
+	return s.validate("", doc)
+}
+
+func (s Schema) validate(path string, doc map[string]interface{}) error {
+	names := make([]string, 0, len(doc))
+	for name := range doc {
+		if s[name] == nil {
+			return &ValidationError{Path: path + "/" + name, Message: "is not a known field"}
+		}
+		names = append(names, name)
+	}
+	for name, f := range s {
+		if v, found := doc[name]; !found || v == nil {
+			if f.Required {
+				return &ValidationError{Path: path + "/" + name, Message: "is required"}
+			}
+		}
+	}
+
+	sort.Strings(names)
+	for _, name := range names {
+		if doc[name] == nil {
+			continue
+		}
+		if err := s[name].validate(path+"/"+name, doc[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *Field) validate(path string, v interface{}) error {
+	invalid := func(message string) error {
+		return &ValidationError{Path: path, Message: message}
+	}
+
+	switch f.Type {
+	case String:
+		s, ok := v.(string)
+		if !ok {
+			return invalid("must be a string")
+		}
+		if f.Required && strings.TrimSpace(s) == "" {
+			return invalid("must not be empty")
+		}
+	case Integer:
+		n, ok := v.(float64)
+		if !ok || n != math.Trunc(n) {
+			return invalid("must be an integer")
+		}
+		if f.Max > f.Min && (n < float64(f.Min) || n > float64(f.Max)) {
+			return invalid(fmt.Sprintf("must be between %d and %d", f.Min, f.Max))
+		}
+	case Boolean:
+		if _, ok := v.(bool); !ok {
+			return invalid("must be a boolean")
+		}
+	case ObjectID:
+		s, ok := v.(string)
+		if !ok || !bson.IsObjectIdHex(s) {
+			return invalid("must be an id")
+		}
+	case Array:
+		items, ok := v.([]interface{})
+		if !ok {
+			return invalid("must be an array")
+		}
+		for i, item := range items {
+			if item == nil {
+				return &ValidationError{Path: path + "/" + strconv.Itoa(i), Message: "must not be null"}
+			}
+			if f.Items == nil {
+				continue
+			}
+			if err := f.Items.validate(path+"/"+strconv.Itoa(i), item); err != nil {
+				return err
+			}
+		}
+	case Object:
+		m, ok := v.(map[string]interface{})
+		if !ok {
+			return invalid("must be an object")
+		}
+		return f.Fields.validate(path, m)
+	}
+	return nil
+}
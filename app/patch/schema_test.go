@@ -0,0 +1,90 @@
+package patch
+
+import (
+	"testing"
+)
+
+var testSchema = Schema{
+	"name":  {Type: String, Required: true},
+	"port":  {Type: Integer, Min: 1, Max: 65535},
+	"tls":   {Type: Boolean},
+	"group": {Type: ObjectID},
+	"ids":   {Type: Array, Items: &Field{Type: ObjectID}},
+	"tags":  {Type: Array},
+	"owner": {Type: Object, Fields: Schema{
+		"email": {Type: String, Required: true},
+	}},
+}
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		doc  string
+		path string // Path of the error, empty when the document is valid
+	}{
+		{`{"name":"web-1"}`, ""},
+		{`{"name":"web-1","port":443,"tls":true,"group":"5a1f0c6e9d1b2c3d4e5f6a7b"}`, ""},
+		{`{"name":"web-1","ids":["5a1f0c6e9d1b2c3d4e5f6a7b"],"owner":{"email":"a@b.c"}}`, ""},
+		{`{"name":"web-1","tags":["a",1,{"b":true}]}`, ""},
+		{`{"name":"web-1","port":null}`, ""},
+
+		{`{}`, "/name"},
+		{`{"name":null}`, "/name"},
+		{`{"name":" "}`, "/name"},
+		{`{"name":1}`, "/name"},
+		{`{"name":"web-1","ip":"10.0.0.1"}`, "/ip"},
+		{`{"name":"web-1","port":0}`, "/port"},
+		{`{"name":"web-1","port":1.5}`, "/port"},
+		{`{"name":"web-1","tls":"yes"}`, "/tls"},
+		{`{"name":"web-1","group":"web"}`, "/group"},
+		{`{"name":"web-1","ids":"5a1f0c6e9d1b2c3d4e5f6a7b"}`, "/ids"},
+		{`{"name":"web-1","ids":["5a1f0c6e9d1b2c3d4e5f6a7b","web"]}`, "/ids/1"},
+		{`{"name":"web-1","tags":["a",null]}`, "/tags/1"},
+		{`{"name":"web-1","owner":{}}`, "/owner/email"},
+		{`{"name":"web-1","owner":{"email":"a@b.c","name":"a"}}`, "/owner/name"},
+	}
+
+	for _, test := range tests {
+		doc := decode(t, test.doc).(map[string]interface{})
+		err := testSchema.Validate(doc)
+		if test.path == "" {
+			if err != nil {
+				t.Errorf("Validate(%s) failed: %v", test.doc, err)
+			}
+			continue
+		}
+
+		verr, ok := err.(*ValidationError)
+		if !ok {
+			t.Errorf("Validate(%s) = %v, want an error at %s", test.doc, err, test.path)
+			continue
+		}
+		if verr.Path != test.path {
+			t.Errorf("Validate(%s) failed at %s, want %s", test.doc, verr.Path, test.path)
+		}
+	}
+}
+
+func TestSchemaDocument(t *testing.T) {
+	v := struct {
+		Name   string `json:"name"`
+		Secret string `json:"secret"`
+		Owner  struct {
+			Email    string `json:"email"`
+			Password string `json:"password"`
+		} `json:"owner"`
+	}{Name: "web-1", Secret: "s"}
+	v.Owner.Email = "a@b.c"
+	v.Owner.Password = "p"
+
+	doc, err := testSchema.Document(v)
+	if err != nil {
+		t.Fatalf("Document failed: %v", err)
+	}
+	if _, found := doc["secret"]; found {
+		t.Errorf("Document kept a field outside the schema: %v", doc)
+	}
+	owner, _ := doc["owner"].(map[string]interface{})
+	if _, found := owner["password"]; found || owner["email"] != "a@b.c" {
+		t.Errorf("Document didn't filter the nested object: %v", doc)
+	}
+}
@@ -0,0 +1,398 @@
+// Package query parses the filter, sort and field selection of list
+// requests, e.g. `filter=name~"^web-",error=true&sort=-created_at&fields=name,ip`,
+// into storage filters. Only the fields of an allowlist can be used, and
+// operators are limited to the ones of the syntax.
+//
+// A filter is a comma separated list of conditions that all have to match.
+// A condition is a field, an operator and a value:
+//
+//	name="web-1"        equal, or a member of a list: command_id=[id1,id2]
+//	name!="web-1"       not equal, or not a member of a list
+//	name~"web-"         contains the text, or starts with it when it is
+//	                    prefixed with `^`: name~"^web-", strings only
+//	created_at>=2018-01-01T00:00:00Z   also >, < and <=
+//
+// Values may be quoted, with `\"` and `\\` escapes, and `null` matches
+// missing fields.
+//
+// The text of `~` is matched literally, it isn't a regular expression. The
+// storage runs patterns with a backtracking engine, so a pattern from a
+// request could take it down.
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Types of fields
+const (
+	String   = "string"
+	ObjectID = "objectid"
+	Time     = "time"
+	Number   = "number"
+	Bool     = "bool"
+)
+
+// Limits of queries
+const (
+	MaxConditions = 20
+	MaxListValues = 100
+	MaxSortKeys   = 3
+	MaxPattern    = 256
+)
+
+// Field describes a field that may be queried
+type Field struct {
+	Type     string
+	Name     string // Name in storage, when it differs from the query name
+	NoSort   bool   // Arrays and nested fields can't be sorted on
+	NoSelect bool   // Nested fields of arrays can only be filtered on
+}
+
+// Fields is the allowlist of the fields of an entity by their query names,
+// which are the JSON names of the entity
+type Fields map[string]Field
+
+// Error is returned for invalid queries
+type Error struct {
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func errorf(format string, args ...interface{}) error {
+	return &Error{Message: fmt.Sprintf(format, args...)}
+}
+
+// StorageName returns the name of the field in storage
+func (f Fields) StorageName(name string) string {
+	if field, found := f[name]; found && field.Name != "" {
+		return field.Name
+	}
+	return name
+}
+
+// Filter parses a filter into a storage filter, an empty filter matches
+// everything
+func (f Fields) Filter(s string) (utils.Filter, error) {
+	p := &parser{input: s}
+	var conditions []utils.Filter
+	for p.skipSpaces(); !p.done(); p.skipSpaces() {
+		if len(conditions) > 0 && !p.consume(",") {
+			return nil, errorf("expected ',' at %d", p.pos)
+		}
+		if len(conditions) >= MaxConditions {
+			return nil, errorf("a filter may have at most %d conditions", MaxConditions)
+		}
+
+		cond, err := f.condition(p)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, cond)
+	}
+
+	switch len(conditions) {
+	case 0:
+		return utils.Filter{}, nil
+	case 1:
+		return conditions[0], nil
+	}
+	return utils.Filter{"$and": conditions}, nil
+}
+
+func (f Fields) condition(p *parser) (utils.Filter, error) {
+	p.skipSpaces()
+	name := p.name()
+	field, found := f[name]
+	if !found {
+		return nil, errorf("unknown field '%s'", name)
+	}
+
+	p.skipSpaces()
+	op := p.operator()
+	if op == "" {
+		return nil, errorf("expected an operator after '%s'", name)
+	}
+
+	p.skipSpaces()
+	if p.consume("[") {
+		if op != "=" && op != "!=" {
+			return nil, errorf("lists can only be used with = and !=")
+		}
+
+		// an empty list matches nothing with $in, storage rejects a null one
+		values := []interface{}{}
+		for p.skipSpaces(); !p.consume("]"); p.skipSpaces() {
+			if len(values) > 0 && !p.consume(",") {
+				return nil, errorf("expected ',' or ']' at %d", p.pos)
+			}
+			if len(values) >= MaxListValues {
+				return nil, errorf("a list may have at most %d values", MaxListValues)
+			}
+
+			p.skipSpaces()
+			text, quoted, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			v, err := convert(name, field, text, quoted)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, v)
+		}
+
+		mongoOp := "$in"
+		if op == "!=" {
+			mongoOp = "$nin"
+		}
+		return utils.Filter{f.StorageName(name): bson.M{mongoOp: values}}, nil
+	}
+
+	text, quoted, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	if op == "~" {
+		if field.Type != String {
+			return nil, errorf("'%s' can't be matched with ~", name)
+		}
+		if len(text) > MaxPattern {
+			return nil, errorf("patterns may be at most %d characters", MaxPattern)
+		}
+		return utils.Filter{f.StorageName(name): bson.RegEx{Pattern: pattern(text)}}, nil
+	}
+
+	v, err := convert(name, field, text, quoted)
+	if err != nil {
+		return nil, err
+	}
+
+	switch op {
+	case "=":
+		return utils.Filter{f.StorageName(name): v}, nil
+	case "!=":
+		return utils.Filter{f.StorageName(name): bson.M{"$ne": v}}, nil
+	}
+	if field.Type == Bool || v == nil {
+		return nil, errorf("'%s' can't be compared with %s", name, op)
+	}
+	ops := map[string]string{">": "$gt", ">=": "$gte", "<": "$lt", "<=": "$lte"}
+	return utils.Filter{f.StorageName(name): bson.M{ops[op]: v}}, nil
+}
+
+// pattern returns the regular expression matching the text of `~` literally,
+// anchored to the start when the text starts with `^`
+func pattern(text string) string {
+	if strings.HasPrefix(text, "^") {
+		return "^" + regexp.QuoteMeta(text[1:])
+	}
+	return regexp.QuoteMeta(text)
+}
+
+// Sort parses a comma separated list of field names, prefixed with `-` for
+// descending order
+func (f Fields) Sort(s string) ([]string, error) {
+	var keys []string
+	seen := make(map[string]bool)
+	for _, key := range strings.Split(s, ",") {
+		key = strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+
+		name := strings.TrimPrefix(key, "-")
+		field, found := f[name]
+		if !found {
+			return nil, errorf("unknown field '%s'", name)
+		}
+		if field.NoSort {
+			return nil, errorf("'%s' can't be sorted on", name)
+		}
+		if seen[name] {
+			return nil, errorf("'%s' is sorted on more than once", name)
+		}
+		seen[name] = true
+		keys = append(keys, key)
+	}
+
+	if len(keys) > MaxSortKeys {
+		return nil, errorf("at most %d fields can be sorted on", MaxSortKeys)
+	}
+	return keys, nil
+}
+
+// Select parses a comma separated list of the field names to return
+func (f Fields) Select(s string) ([]string, error) {
+	var names []string
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		field, found := f[name]
+		if !found {
+			return nil, errorf("unknown field '%s'", name)
+		}
+		if field.NoSelect {
+			return nil, errorf("'%s' can't be selected", name)
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// Convert converts a value decoded from JSON to the type of the field
+func (f Fields) Convert(name string, v interface{}) (interface{}, error) {
+	field, found := f[name]
+	if !found {
+		return nil, errorf("unknown field '%s'", name)
+	}
+
+	switch value := v.(type) {
+	case nil:
+		return nil, nil
+	case string:
+		return convert(name, field, value, true)
+	case float64:
+		if field.Type == Number {
+			return value, nil
+		}
+	case bool:
+		if field.Type == Bool {
+			return value, nil
+		}
+	}
+	return nil, errorf("invalid value for '%s'", name)
+}
+
+// convert converts the text of a value to the type of the field, unquoted
+// `null` is nil for every type
+func convert(name string, field Field, text string, quoted bool) (interface{}, error) {
+	if !quoted && text == "null" {
+		return nil, nil
+	}
+
+	switch field.Type {
+	case String:
+		return text, nil
+	case ObjectID:
+		if !bson.IsObjectIdHex(text) {
+			return nil, errorf("'%s' has to be an ObjectId", name)
+		}
+		return bson.ObjectIdHex(text), nil
+	case Time:
+		t, err := time.Parse(time.RFC3339Nano, text)
+		if err != nil {
+			return nil, errorf("'%s' has to be an RFC 3339 time", name)
+		}
+		return t, nil
+	case Number:
+		n, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, errorf("'%s' has to be a number", name)
+		}
+		return n, nil
+	case Bool:
+		b, err := strconv.ParseBool(text)
+		if err != nil {
+			return nil, errorf("'%s' has to be true or false", name)
+		}
+		return b, nil
+	}
+	return nil, errorf("'%s' has an unknown type", name)
+}
+
+// parser reads the tokens of a filter
+type parser struct {
+	input string
+	pos   int
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+func (p *parser) skipSpaces() {
+	for !p.done() && p.input[p.pos] == ' ' {
+		p.pos++
+	}
+}
+
+func (p *parser) consume(token string) bool {
+	if strings.HasPrefix(p.input[p.pos:], token) {
+		p.pos += len(token)
+		return true
+	}
+	return false
+}
+
+// name reads a field name of letters, digits, `_` and `.`
+func (p *parser) name() string {
+	start := p.pos
+	for !p.done() {
+		c := p.input[p.pos]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '.') {
+			break
+		}
+		p.pos++
+	}
+	return p.input[start:p.pos]
+}
+
+func (p *parser) operator() string {
+	for _, op := range []string{"!=", ">=", "<=", "=", "~", ">", "<"} {
+		if p.consume(op) {
+			return op
+		}
+	}
+	return ""
+}
+
+// value reads a quoted value, or an unquoted one up to the next `,` or `]`
+func (p *parser) value() (string, bool, error) {
+	if !p.consume(`"`) {
+		start := p.pos
+		for !p.done() && p.input[p.pos] != ',' && p.input[p.pos] != ']' {
+			p.pos++
+		}
+		text := strings.TrimSpace(p.input[start:p.pos])
+		if text == "" {
+			return "", false, errorf("expected a value at %d", start)
+		}
+		return text, false, nil
+	}
+
+	var b bytes.Buffer
+	for !p.done() {
+		c := p.input[p.pos]
+		p.pos++
+		switch c {
+		case '"':
+			return b.String(), true, nil
+		case '\\':
+			// only quotes and backslashes are escaped, any other
+			// backslash is kept
+			if !p.done() && (p.input[p.pos] == '"' || p.input[p.pos] == '\\') {
+				c = p.input[p.pos]
+				p.pos++
+			}
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", false, errorf("unterminated string")
+}
@@ -0,0 +1,206 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var testFields = Fields{
+	"id":         {Type: ObjectID, Name: "_id"},
+	"name":       {Type: String},
+	"error":      {Type: Bool},
+	"checked":    {Type: Number},
+	"created_at": {Type: Time},
+	"commands":   {Type: ObjectID, Name: "commands.id", NoSort: true, NoSelect: true},
+}
+
+func TestFilter(t *testing.T) {
+	id := bson.ObjectIdHex("5a1f0c6e9d1b2c3d4e5f6a7b")
+	created := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		filter string
+		result utils.Filter
+	}{
+		{``, utils.Filter{}},
+		{`  `, utils.Filter{}},
+		{`name="web-1"`, utils.Filter{"name": "web-1"}},
+		{`name=web-1`, utils.Filter{"name": "web-1"}},
+		{` name = "web-1" `, utils.Filter{"name": "web-1"}},
+		{`name="a,b"`, utils.Filter{"name": "a,b"}},
+		{`name="say \"hi\" \\ \d"`, utils.Filter{"name": `say "hi" \ \d`}},
+		{`name!="web-1"`, utils.Filter{"name": bson.M{"$ne": "web-1"}}},
+		{`name=null`, utils.Filter{"name": nil}},
+		{`name="null"`, utils.Filter{"name": "null"}},
+		{`name=["a","b"]`, utils.Filter{"name": bson.M{"$in": []interface{}{"a", "b"}}}},
+		{`name!=[a, b]`, utils.Filter{"name": bson.M{"$nin": []interface{}{"a", "b"}}}},
+		{`name=[]`, utils.Filter{"name": bson.M{"$in": []interface{}{}}}},
+		{`name!=[ ]`, utils.Filter{"name": bson.M{"$nin": []interface{}{}}}},
+		{`name~"web-"`, utils.Filter{"name": bson.RegEx{Pattern: `web-`}}},
+		{`name~"^web-"`, utils.Filter{"name": bson.RegEx{Pattern: `^web-`}}},
+		{`name~"(a+)+$"`, utils.Filter{"name": bson.RegEx{Pattern: `\(a\+\)\+\$`}}},
+		{`name~"^.*"`, utils.Filter{"name": bson.RegEx{Pattern: `^\.\*`}}},
+		{`name~"a^b"`, utils.Filter{"name": bson.RegEx{Pattern: `a\^b`}}},
+		{`id=5a1f0c6e9d1b2c3d4e5f6a7b`, utils.Filter{"_id": id}},
+		{`commands=[5a1f0c6e9d1b2c3d4e5f6a7b]`, utils.Filter{"commands.id": bson.M{"$in": []interface{}{id}}}},
+		{`error=true`, utils.Filter{"error": true}},
+		{`checked>=2.5`, utils.Filter{"checked": bson.M{"$gte": 2.5}}},
+		{`checked<10`, utils.Filter{"checked": bson.M{"$lt": float64(10)}}},
+		{`created_at>2018-01-01T00:00:00Z`, utils.Filter{"created_at": bson.M{"$gt": created}}},
+		{`created_at<=2018-01-01T00:00:00Z`, utils.Filter{"created_at": bson.M{"$lte": created}}},
+		{`name="web-1",error=false`, utils.Filter{"$and": []utils.Filter{{"name": "web-1"}, {"error": false}}}},
+	}
+
+	for _, test := range tests {
+		result, err := testFields.Filter(test.filter)
+		if err != nil {
+			t.Errorf("Filter(%s) failed: %v", test.filter, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("Filter(%s) = %v, want %v", test.filter, result, test.result)
+		}
+	}
+}
+
+func TestFilterRejects(t *testing.T) {
+	tests := []string{
+		`password="secret"`,
+		`name`,
+		`name=`,
+		`checked==1`,
+		`name="web-1"error=true`,
+		`name="web-1`,
+		`name=[a,b`,
+		`name~[a]`,
+		`name>[a]`,
+		`error~"true"`,
+		`id~"5a1f"`,
+		`name~"` + strings.Repeat("a", MaxPattern+1) + `"`,
+		`id=web-1`,
+		`error=yes`,
+		`error>true`,
+		`checked>null`,
+		`checked=ten`,
+		`created_at>2018-01-01`,
+		`name.$where="1"`,
+		`$where="1"`,
+		strings.Repeat(`name="a",`, MaxConditions) + `name="a"`,
+		`name=[` + strings.Repeat(`a,`, MaxListValues) + `a]`,
+	}
+
+	for _, filter := range tests {
+		result, err := testFields.Filter(filter)
+		if err == nil {
+			t.Errorf("Filter(%s) = %v, want an error", filter, result)
+			continue
+		}
+		if _, ok := err.(*Error); !ok {
+			t.Errorf("Filter(%s) returned %T, want *Error", filter, err)
+		}
+	}
+}
+
+func TestSort(t *testing.T) {
+	tests := []struct {
+		sort   string
+		result []string // nil when the sort is rejected
+	}{
+		{``, []string{}},
+		{`name`, []string{"name"}},
+		{`-created_at, name`, []string{"-created_at", "name"}},
+		{`name,,id`, []string{"name", "id"}},
+		{`password`, nil},
+		{`commands`, nil},
+		{`name,-name`, nil},
+		{`name,id,checked,created_at`, nil},
+	}
+
+	for _, test := range tests {
+		result, err := testFields.Sort(test.sort)
+		if test.result == nil {
+			if err == nil {
+				t.Errorf("Sort(%s) = %v, want an error", test.sort, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Sort(%s) failed: %v", test.sort, err)
+			continue
+		}
+		if len(result) != len(test.result) || len(result) > 0 && !reflect.DeepEqual(result, test.result) {
+			t.Errorf("Sort(%s) = %v, want %v", test.sort, result, test.result)
+		}
+	}
+}
+
+func TestSelect(t *testing.T) {
+	tests := []struct {
+		fields string
+		result []string // nil when the selection is rejected
+	}{
+		{``, []string{}},
+		{`name, id`, []string{"name", "id"}},
+		{`password`, nil},
+		{`commands`, nil},
+	}
+
+	for _, test := range tests {
+		result, err := testFields.Select(test.fields)
+		if test.result == nil {
+			if err == nil {
+				t.Errorf("Select(%s) = %v, want an error", test.fields, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Select(%s) failed: %v", test.fields, err)
+			continue
+		}
+		if len(result) != len(test.result) || len(result) > 0 && !reflect.DeepEqual(result, test.result) {
+			t.Errorf("Select(%s) = %v, want %v", test.fields, result, test.result)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  interface{}
+		result interface{}
+		ok     bool
+	}{
+		{"name", "web-1", "web-1", true},
+		{"name", nil, nil, true},
+		{"id", "5a1f0c6e9d1b2c3d4e5f6a7b", bson.ObjectIdHex("5a1f0c6e9d1b2c3d4e5f6a7b"), true},
+		{"created_at", "2018-01-01T00:00:00Z", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), true},
+		{"checked", 3.0, 3.0, true},
+		{"error", true, true, true},
+		{"name", 1.0, nil, false},
+		{"checked", true, nil, false},
+		{"error", "true", true, true},
+		{"id", "web-1", nil, false},
+		{"password", "secret", nil, false},
+	}
+
+	for _, test := range tests {
+		result, err := testFields.Convert(test.name, test.value)
+		if !test.ok {
+			if err == nil {
+				t.Errorf("Convert(%s, %v) = %v, want an error", test.name, test.value, result)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Convert(%s, %v) failed: %v", test.name, test.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(result, test.result) {
+			t.Errorf("Convert(%s, %v) = %v, want %v", test.name, test.value, result, test.result)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package rpc
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/security"
+	"github.com/keiwi/api/app/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// permissions are the permissions of the methods, the same as those of the
+// routes of the HTTP API
+var permissions = map[string]string{
+	keiwiv1.ClientService_ListClients_FullMethodName:  "clients:read",
+	keiwiv1.ClientService_GetClient_FullMethodName:    "clients:read",
+	keiwiv1.ClientService_CreateClient_FullMethodName: "clients:write",
+	keiwiv1.ClientService_UpdateClient_FullMethodName: "clients:write",
+	keiwiv1.ClientService_DeleteClient_FullMethodName: "clients:write",
+
+	keiwiv1.CommandService_ListCommands_FullMethodName:  "commands:read",
+	keiwiv1.CommandService_GetCommand_FullMethodName:    "commands:read",
+	keiwiv1.CommandService_CreateCommand_FullMethodName: "commands:write",
+	keiwiv1.CommandService_UpdateCommand_FullMethodName: "commands:write",
+	keiwiv1.CommandService_DeleteCommand_FullMethodName: "commands:write",
+
+	keiwiv1.GroupService_ListGroups_FullMethodName:  "groups:read",
+	keiwiv1.GroupService_GetGroup_FullMethodName:    "groups:read",
+	keiwiv1.GroupService_CreateGroup_FullMethodName: "groups:write",
+	keiwiv1.GroupService_UpdateGroup_FullMethodName: "groups:write",
+	keiwiv1.GroupService_DeleteGroup_FullMethodName: "groups:write",
+
+	keiwiv1.CheckService_ListChecks_FullMethodName:       "checks:read",
+	keiwiv1.CheckService_GetCheck_FullMethodName:         "checks:read",
+	keiwiv1.CheckService_DeleteCheck_FullMethodName:      "checks:write",
+	keiwiv1.CheckService_ListClientChecks_FullMethodName: "checks:read",
+	keiwiv1.CheckService_ListLatestChecks_FullMethodName: "checks:read",
+
+	keiwiv1.UserService_GetCurrentUser_FullMethodName:    security.SelfPermission,
+	keiwiv1.UserService_ListUsers_FullMethodName:         "users:read",
+	keiwiv1.UserService_SetUserDisabled_FullMethodName:   "users:write",
+	keiwiv1.UserService_SetUserRoles_FullMethodName:      "users:write",
+	keiwiv1.UserService_ResetUserPassword_FullMethodName: "users:write",
+	keiwiv1.UserService_DeleteUser_FullMethodName:        "users:write",
+}
+
+// callerKey is the context key of the caller of a call
+type callerKey struct{}
+
+// authenticate authenticates every call with the `authorization` metadata
+// and checks the permission of the method, the caller of the call is added
+// to its context
+func authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	permission, found := permissions[info.FullMethod]
+	if !found {
+		return nil, status.Error(codes.Unimplemented, "unknown method")
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	ip := clientIP(ctx, md)
+	authcInfo, authzInfo, err := security.Authenticate(first(md, "authorization"), ip)
+	if err != nil {
+		return nil, status.Error(codes.Unauthenticated, "authentication failed")
+	}
+	if authcInfo.IsLocked || authcInfo.IsExpired {
+		return nil, status.Error(codes.PermissionDenied, "the account is locked or expired")
+	}
+	if !authzInfo.IsPermitted(permission) {
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+
+	c := &service.Caller{
+		UserID:    bson.ObjectIdHex(authcInfo.PrimaryPrincipal().Value),
+		Username:  security.PrincipalClaim(authcInfo, "username"),
+		Subject:   authzInfo,
+		IP:        ip,
+		RequestID: first(md, aah.AppConfig().StringDefault("request.id.header", "X-Request-Id")),
+	}
+	if security.PrincipalClaim(authcInfo, security.APIKeyClaim) != "" {
+		c.Via = security.APIKeyClaim
+	}
+	if err := service.ResolveOrg(c, first(md, service.OrgHeader)); err != nil {
+		return nil, toStatus(err)
+	}
+
+	return handler(context.WithValue(ctx, callerKey{}, c), req)
+}
+
+// callerOf returns the caller added to the context of the call
+func callerOf(ctx context.Context) *service.Caller {
+	return ctx.Value(callerKey{}).(*service.Caller)
+}
+
+// toStatus maps an error of the service layer to a status
+func toStatus(err error) error {
+	e, ok := err.(*service.Error)
+	if !ok {
+		log.Errorf("unexpected error: %v", err)
+		return status.Error(codes.Internal, service.ErrInternal.Message)
+	}
+
+	code := codes.Internal
+	switch e.Kind {
+	case service.Invalid, service.Unsupported:
+		code = codes.InvalidArgument
+	case service.NotFound:
+		code = codes.NotFound
+	case service.Forbidden:
+		code = codes.PermissionDenied
+	}
+	return status.Error(code, e.Message)
+}
+
+// clientIP returns the IP of the peer of the call, or the client of the
+// gateway for calls forwarded by it
+func clientIP(ctx context.Context, md metadata.MD) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return ""
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+
+	// the gateway runs on loopback and appends the address of its client to
+	// x-forwarded-for, which is then the address of the connection
+	fwd := md.Get("x-forwarded-for")
+	if ip := net.ParseIP(host); ip != nil && ip.IsLoopback() && len(fwd) > 0 {
+		hops := strings.Split(strings.Join(fwd, ","), ",")
+		return security.ClientIP(strings.TrimSpace(hops[len(hops)-1]), hops[:len(hops)-1]...)
+	}
+	return security.ClientIP(p.Addr.String(), fwd...)
+}
+
+// first returns the first value of the metadata key
+func first(md metadata.MD, key string) string {
+	if v := md.Get(key); len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	storageModel "github.com/keiwi/utils/models"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// checkServer implements CheckService with the service layer
+type checkServer struct {
+	keiwiv1.UnimplementedCheckServiceServer
+}
+
+func (checkServer) ListChecks(ctx context.Context, req *keiwiv1.ListRequest) (*keiwiv1.ListChecksResponse, error) {
+	items, page, err := service.ListChecks(callerOf(ctx), listQuery(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &keiwiv1.ListChecksResponse{Checks: toChecks(items.([]storageModel.Check)), Page: toPage(page)}, nil
+}
+
+func (checkServer) GetCheck(ctx context.Context, req *keiwiv1.GetRequest) (*keiwiv1.Check, error) {
+	check, err := service.GetCheck(callerOf(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCheck(check), nil
+}
+
+func (checkServer) DeleteCheck(ctx context.Context, req *keiwiv1.DeleteRequest) (*emptypb.Empty, error) {
+	if err := service.DeleteCheck(callerOf(ctx), req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (checkServer) ListClientChecks(ctx context.Context, req *keiwiv1.ListClientChecksRequest) (*keiwiv1.ListChecksResponse, error) {
+	items, page, err := service.ClientChecks(callerOf(ctx), req.GetClientId(), service.ListQuery{
+		Filter: req.GetFilter(),
+		Sort:   req.GetSort(),
+		Cursor: req.GetPageToken(),
+		Limit:  int(req.GetPageSize()),
+		Total:  req.GetTotalSize(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &keiwiv1.ListChecksResponse{Checks: toChecks(items.([]storageModel.Check)), Page: toPage(page)}, nil
+}
+
+func (checkServer) ListLatestChecks(ctx context.Context, req *keiwiv1.ListLatestChecksRequest) (*keiwiv1.ChecksResponse, error) {
+	checks, err := service.LatestChecks(callerOf(ctx), req.GetClientId(), req.GetCommandIds())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &keiwiv1.ChecksResponse{Checks: toChecks(checks)}, nil
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	storageModel "github.com/keiwi/utils/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// clientServer implements ClientService with the service layer
+type clientServer struct {
+	keiwiv1.UnimplementedClientServiceServer
+}
+
+func (clientServer) ListClients(ctx context.Context, req *keiwiv1.ListRequest) (*keiwiv1.ListClientsResponse, error) {
+	items, page, err := service.ListClients(callerOf(ctx), listQuery(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	clients := items.([]storageModel.Client)
+	res := &keiwiv1.ListClientsResponse{Clients: make([]*keiwiv1.Client, 0, len(clients)), Page: toPage(page)}
+	for _, c := range clients {
+		res.Clients = append(res.Clients, toClient(c))
+	}
+	return res, nil
+}
+
+func (clientServer) GetClient(ctx context.Context, req *keiwiv1.GetRequest) (*keiwiv1.Client, error) {
+	client, err := service.GetClient(callerOf(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toClient(client), nil
+}
+
+func (clientServer) CreateClient(ctx context.Context, req *keiwiv1.CreateClientRequest) (*keiwiv1.Client, error) {
+	client, err := service.CreateClient(callerOf(ctx), models.ClientCreate{Name: req.GetName(), IP: req.GetIp()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toClient(client), nil
+}
+
+func (clientServer) UpdateClient(ctx context.Context, req *keiwiv1.UpdateClientRequest) (*keiwiv1.Client, error) {
+	if req.GetClient() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Client is missing")
+	}
+
+	data, err := mergePatch(req.GetClient(), req.GetUpdateMask(), models.ClientSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	client, _, err := service.PatchClient(callerOf(ctx), req.GetClient().GetId(), patch.MergePatchType, data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toClient(client), nil
+}
+
+func (clientServer) DeleteClient(ctx context.Context, req *keiwiv1.DeleteRequest) (*emptypb.Empty, error) {
+	if err := service.DeleteClient(callerOf(ctx), req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,78 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	storageModel "github.com/keiwi/utils/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// commandServer implements CommandService with the service layer
+type commandServer struct {
+	keiwiv1.UnimplementedCommandServiceServer
+}
+
+func (commandServer) ListCommands(ctx context.Context, req *keiwiv1.ListRequest) (*keiwiv1.ListCommandsResponse, error) {
+	items, page, err := service.ListCommands(callerOf(ctx), listQuery(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	commands := items.([]storageModel.Command)
+	res := &keiwiv1.ListCommandsResponse{Commands: make([]*keiwiv1.Command, 0, len(commands)), Page: toPage(page)}
+	for _, c := range commands {
+		res.Commands = append(res.Commands, toCommand(c))
+	}
+	return res, nil
+}
+
+func (commandServer) GetCommand(ctx context.Context, req *keiwiv1.GetRequest) (*keiwiv1.Command, error) {
+	cmd, err := service.GetCommand(callerOf(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCommand(cmd), nil
+}
+
+func (commandServer) CreateCommand(ctx context.Context, req *keiwiv1.CreateCommandRequest) (*keiwiv1.Command, error) {
+	cmd, err := service.CreateCommand(callerOf(ctx), models.CommandCreate{
+		Command:     req.GetCommand(),
+		Name:        req.GetName(),
+		Description: req.GetDescription(),
+		Format:      req.GetFormat(),
+	})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCommand(cmd), nil
+}
+
+func (commandServer) UpdateCommand(ctx context.Context, req *keiwiv1.UpdateCommandRequest) (*keiwiv1.Command, error) {
+	if req.GetCommand() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Command is missing")
+	}
+
+	data, err := mergePatch(req.GetCommand(), req.GetUpdateMask(), models.CommandSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd, _, err := service.PatchCommand(callerOf(ctx), req.GetCommand().GetId(), patch.MergePatchType, data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toCommand(cmd), nil
+}
+
+func (commandServer) DeleteCommand(ctx context.Context, req *keiwiv1.DeleteRequest) (*emptypb.Empty, error) {
+	if err := service.DeleteCommand(callerOf(ctx), req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
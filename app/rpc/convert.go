@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"time"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	storageModel "github.com/keiwi/utils/models"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// listQuery returns the list query of a list request
+func listQuery(req *keiwiv1.ListRequest) service.ListQuery {
+	return service.ListQuery{
+		Filter: req.GetFilter(),
+		Sort:   req.GetSort(),
+		Cursor: req.GetPageToken(),
+		Limit:  int(req.GetPageSize()),
+		Total:  req.GetTotalSize(),
+	}
+}
+
+func toPage(p *models.Page) *keiwiv1.Page {
+	page := &keiwiv1.Page{
+		PageSize:        int32(p.Limit),
+		NextPageToken:   p.Next,
+		PrevPageToken:   p.Prev,
+		TotalSizeCapped: p.TotalCapped,
+	}
+	if p.Total != nil {
+		total := int32(*p.Total)
+		page.TotalSize = &total
+	}
+	return page
+}
+
+func toTimestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func hexIDs(ids []bson.ObjectId) []string {
+	hex := make([]string, 0, len(ids))
+	for _, id := range ids {
+		hex = append(hex, id.Hex())
+	}
+	return hex
+}
+
+func toClient(c storageModel.Client) *keiwiv1.Client {
+	return &keiwiv1.Client{
+		Id:        c.ID.Hex(),
+		Name:      c.Name,
+		Ip:        c.IP,
+		GroupIds:  hexIDs(c.GroupIDs),
+		CreatedAt: toTimestamp(c.CreatedAt),
+		UpdatedAt: toTimestamp(c.UpdatedAt),
+	}
+}
+
+func toCommand(c storageModel.Command) *keiwiv1.Command {
+	return &keiwiv1.Command{
+		Id:          c.ID.Hex(),
+		Command:     c.Command,
+		Name:        c.Name,
+		Description: c.Description,
+		Format:      c.Format,
+		CreatedAt:   toTimestamp(c.CreatedAt),
+		UpdatedAt:   toTimestamp(c.UpdatedAt),
+	}
+}
+
+func toGroup(g storageModel.Group) *keiwiv1.Group {
+	group := &keiwiv1.Group{
+		Id:        g.ID.Hex(),
+		Name:      g.Name,
+		Commands:  make([]*keiwiv1.GroupCommand, 0, len(g.Commands)),
+		CreatedAt: toTimestamp(g.CreatedAt),
+		UpdatedAt: toTimestamp(g.UpdatedAt),
+	}
+	for _, c := range g.Commands {
+		group.Commands = append(group.Commands, &keiwiv1.GroupCommand{
+			Id:        c.ID.Hex(),
+			CommandId: c.CommandID.Hex(),
+			NextCheck: int32(c.NextCheck),
+			StopError: c.StopError,
+		})
+	}
+	return group
+}
+
+func toCheck(c storageModel.Check) *keiwiv1.Check {
+	return &keiwiv1.Check{
+		Id:        c.ID.Hex(),
+		ClientId:  c.ClientID.Hex(),
+		CommandId: c.CommandID.Hex(),
+		Response:  c.Response,
+		Checked:   c.Checked,
+		Error:     c.Error,
+		Finished:  c.Finished,
+		CreatedAt: toTimestamp(c.CreatedAt),
+		UpdatedAt: toTimestamp(c.UpdatedAt),
+	}
+}
+
+func toChecks(checks []storageModel.Check) []*keiwiv1.Check {
+	list := make([]*keiwiv1.Check, 0, len(checks))
+	for _, c := range checks {
+		list = append(list, toCheck(c))
+	}
+	return list
+}
+
+func toUser(u models.UserView) *keiwiv1.User {
+	return &keiwiv1.User{
+		Id:            u.ID.Hex(),
+		Username:      u.Username,
+		Email:         u.Email,
+		EmailVerified: u.EmailVerified,
+		Roles:         u.Roles,
+		Permissions:   u.Permissions,
+		IsLocked:      u.IsLocked,
+		IsExpired:     u.IsExpired,
+		TotpEnabled:   u.TOTPEnabled,
+		CreatedAt:     toTimestamp(u.CreatedAt),
+		UpdatedAt:     toTimestamp(u.UpdatedAt),
+	}
+}
@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	storageModel "github.com/keiwi/utils/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// groupServer implements GroupService with the service layer
+type groupServer struct {
+	keiwiv1.UnimplementedGroupServiceServer
+}
+
+func (groupServer) ListGroups(ctx context.Context, req *keiwiv1.ListRequest) (*keiwiv1.ListGroupsResponse, error) {
+	items, page, err := service.ListGroups(callerOf(ctx), listQuery(req))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	groups := items.([]storageModel.Group)
+	res := &keiwiv1.ListGroupsResponse{Groups: make([]*keiwiv1.Group, 0, len(groups)), Page: toPage(page)}
+	for _, g := range groups {
+		res.Groups = append(res.Groups, toGroup(g))
+	}
+	return res, nil
+}
+
+func (groupServer) GetGroup(ctx context.Context, req *keiwiv1.GetRequest) (*keiwiv1.Group, error) {
+	group, err := service.GetGroup(callerOf(ctx), req.GetId())
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toGroup(group), nil
+}
+
+func (groupServer) CreateGroup(ctx context.Context, req *keiwiv1.CreateGroupRequest) (*keiwiv1.Group, error) {
+	group, err := service.CreateGroup(callerOf(ctx), models.GroupCreate{GroupName: req.GetName(), CommandID: req.GetCommandId()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toGroup(group), nil
+}
+
+func (groupServer) UpdateGroup(ctx context.Context, req *keiwiv1.UpdateGroupRequest) (*keiwiv1.Group, error) {
+	if req.GetGroup() == nil {
+		return nil, status.Error(codes.InvalidArgument, "Group is missing")
+	}
+
+	data, err := mergePatch(req.GetGroup(), req.GetUpdateMask(), models.GroupSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	group, _, err := service.PatchGroup(callerOf(ctx), req.GetGroup().GetId(), patch.MergePatchType, data)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toGroup(group), nil
+}
+
+func (groupServer) DeleteGroup(ctx context.Context, req *keiwiv1.DeleteRequest) (*emptypb.Empty, error) {
+	if err := service.DeleteGroup(callerOf(ctx), req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
@@ -0,0 +1,2182 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.10
+// 	protoc        (unknown)
+// source: keiwi/v1/keiwi.proto
+
+// The gRPC API of keiwi. The services call the same service layer as the
+// HTTP controllers, so entities are validated, scoped to the organization of
+// the call and checked against the access lists the same way.
+//
+// Calls are authenticated with the `authorization` metadata, holding a
+// `Bearer` token, an `ApiKey` or `Basic` credentials like the `Authorization`
+// header of the HTTP API. The `x-keiwi-org` metadata selects the active
+// organization.
+
+package keiwiv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	fieldmaskpb "google.golang.org/protobuf/types/known/fieldmaskpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// ListRequest selects a page of a list. The filter and sort take the syntax
+// of the `filter` and `sort` query parameters of the HTTP API, and pages are
+// walked with the next or previous page token of a reply.
+type ListRequest struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Filter    string                 `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort      string                 `protobuf:"bytes,2,opt,name=sort,proto3" json:"sort,omitempty"`
+	PageSize  int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken string                 `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Count the entities matching the filter, up to the `max_total` of the
+	// pagination configuration
+	TotalSize     bool `protobuf:"varint,5,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRequest) Reset() {
+	*x = ListRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRequest) ProtoMessage() {}
+
+func (x *ListRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRequest.ProtoReflect.Descriptor instead.
+func (*ListRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *ListRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *ListRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListRequest) GetTotalSize() bool {
+	if x != nil {
+		return x.TotalSize
+	}
+	return false
+}
+
+// Page describes the page of a list reply
+type Page struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	PrevPageToken string                 `protobuf:"bytes,3,opt,name=prev_page_token,json=prevPageToken,proto3" json:"prev_page_token,omitempty"`
+	TotalSize     *int32                 `protobuf:"varint,4,opt,name=total_size,json=totalSize,proto3,oneof" json:"total_size,omitempty"`
+	// Set when more entities match than are counted, total_size is then the
+	// number counted
+	TotalSizeCapped bool `protobuf:"varint,5,opt,name=total_size_capped,json=totalSizeCapped,proto3" json:"total_size_capped,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *Page) Reset() {
+	*x = Page{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Page) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Page) ProtoMessage() {}
+
+func (x *Page) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Page.ProtoReflect.Descriptor instead.
+func (*Page) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Page) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *Page) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+func (x *Page) GetPrevPageToken() string {
+	if x != nil {
+		return x.PrevPageToken
+	}
+	return ""
+}
+
+func (x *Page) GetTotalSize() int32 {
+	if x != nil && x.TotalSize != nil {
+		return *x.TotalSize
+	}
+	return 0
+}
+
+func (x *Page) GetTotalSizeCapped() bool {
+	if x != nil {
+		return x.TotalSizeCapped
+	}
+	return false
+}
+
+type GetRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetRequest) Reset() {
+	*x = GetRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetRequest) ProtoMessage() {}
+
+func (x *GetRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetRequest.ProtoReflect.Descriptor instead.
+func (*GetRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type DeleteRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteRequest) Reset() {
+	*x = DeleteRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteRequest) ProtoMessage() {}
+
+func (x *DeleteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteRequest.ProtoReflect.Descriptor instead.
+func (*DeleteRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *DeleteRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+type Client struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Ip            string                 `protobuf:"bytes,3,opt,name=ip,proto3" json:"ip,omitempty"`
+	GroupIds      []string               `protobuf:"bytes,4,rep,name=group_ids,json=groupIds,proto3" json:"group_ids,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Client) Reset() {
+	*x = Client{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Client) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Client) ProtoMessage() {}
+
+func (x *Client) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Client.ProtoReflect.Descriptor instead.
+func (*Client) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Client) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Client) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Client) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+func (x *Client) GetGroupIds() []string {
+	if x != nil {
+		return x.GroupIds
+	}
+	return nil
+}
+
+func (x *Client) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Client) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListClientsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Clients       []*Client              `protobuf:"bytes,1,rep,name=clients,proto3" json:"clients,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListClientsResponse) Reset() {
+	*x = ListClientsResponse{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListClientsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListClientsResponse) ProtoMessage() {}
+
+func (x *ListClientsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListClientsResponse.ProtoReflect.Descriptor instead.
+func (*ListClientsResponse) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ListClientsResponse) GetClients() []*Client {
+	if x != nil {
+		return x.Clients
+	}
+	return nil
+}
+
+func (x *ListClientsResponse) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type CreateClientRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Ip            string                 `protobuf:"bytes,2,opt,name=ip,proto3" json:"ip,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateClientRequest) Reset() {
+	*x = CreateClientRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateClientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateClientRequest) ProtoMessage() {}
+
+func (x *CreateClientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateClientRequest.ProtoReflect.Descriptor instead.
+func (*CreateClientRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateClientRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateClientRequest) GetIp() string {
+	if x != nil {
+		return x.Ip
+	}
+	return ""
+}
+
+// UpdateClientRequest changes the fields of the mask, every field is changed
+// without a mask
+type UpdateClientRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Client        *Client                `protobuf:"bytes,1,opt,name=client,proto3" json:"client,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateClientRequest) Reset() {
+	*x = UpdateClientRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateClientRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateClientRequest) ProtoMessage() {}
+
+func (x *UpdateClientRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateClientRequest.ProtoReflect.Descriptor instead.
+func (*UpdateClientRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *UpdateClientRequest) GetClient() *Client {
+	if x != nil {
+		return x.Client
+	}
+	return nil
+}
+
+func (x *UpdateClientRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type Command struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Command       string                 `protobuf:"bytes,2,opt,name=command,proto3" json:"command,omitempty"`
+	Name          string                 `protobuf:"bytes,3,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,4,opt,name=description,proto3" json:"description,omitempty"`
+	Format        string                 `protobuf:"bytes,5,opt,name=format,proto3" json:"format,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Command) Reset() {
+	*x = Command{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Command) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Command) ProtoMessage() {}
+
+func (x *Command) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Command.ProtoReflect.Descriptor instead.
+func (*Command) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *Command) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Command) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *Command) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Command) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Command) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *Command) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Command) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListCommandsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Commands      []*Command             `protobuf:"bytes,1,rep,name=commands,proto3" json:"commands,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCommandsResponse) Reset() {
+	*x = ListCommandsResponse{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCommandsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCommandsResponse) ProtoMessage() {}
+
+func (x *ListCommandsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCommandsResponse.ProtoReflect.Descriptor instead.
+func (*ListCommandsResponse) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ListCommandsResponse) GetCommands() []*Command {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+func (x *ListCommandsResponse) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+type CreateCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       string                 `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Format        string                 `protobuf:"bytes,4,opt,name=format,proto3" json:"format,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCommandRequest) Reset() {
+	*x = CreateCommandRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCommandRequest) ProtoMessage() {}
+
+func (x *CreateCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCommandRequest.ProtoReflect.Descriptor instead.
+func (*CreateCommandRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *CreateCommandRequest) GetCommand() string {
+	if x != nil {
+		return x.Command
+	}
+	return ""
+}
+
+func (x *CreateCommandRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCommandRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *CreateCommandRequest) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+// UpdateCommandRequest changes the fields of the mask, every field is
+// changed without a mask
+type UpdateCommandRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Command       *Command               `protobuf:"bytes,1,opt,name=command,proto3" json:"command,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCommandRequest) Reset() {
+	*x = UpdateCommandRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCommandRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCommandRequest) ProtoMessage() {}
+
+func (x *UpdateCommandRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCommandRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCommandRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *UpdateCommandRequest) GetCommand() *Command {
+	if x != nil {
+		return x.Command
+	}
+	return nil
+}
+
+func (x *UpdateCommandRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+// GroupCommand is a command run on the clients of a group every next_check
+// seconds
+type GroupCommand struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	CommandId     string                 `protobuf:"bytes,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	NextCheck     int32                  `protobuf:"varint,3,opt,name=next_check,json=nextCheck,proto3" json:"next_check,omitempty"`
+	StopError     bool                   `protobuf:"varint,4,opt,name=stop_error,json=stopError,proto3" json:"stop_error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GroupCommand) Reset() {
+	*x = GroupCommand{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GroupCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GroupCommand) ProtoMessage() {}
+
+func (x *GroupCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GroupCommand.ProtoReflect.Descriptor instead.
+func (*GroupCommand) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GroupCommand) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GroupCommand) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *GroupCommand) GetNextCheck() int32 {
+	if x != nil {
+		return x.NextCheck
+	}
+	return 0
+}
+
+func (x *GroupCommand) GetStopError() bool {
+	if x != nil {
+		return x.StopError
+	}
+	return false
+}
+
+type Group struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Commands      []*GroupCommand        `protobuf:"bytes,3,rep,name=commands,proto3" json:"commands,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Group) Reset() {
+	*x = Group{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Group) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Group) ProtoMessage() {}
+
+func (x *Group) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Group.ProtoReflect.Descriptor instead.
+func (*Group) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *Group) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Group) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Group) GetCommands() []*GroupCommand {
+	if x != nil {
+		return x.Commands
+	}
+	return nil
+}
+
+func (x *Group) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Group) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListGroupsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Groups        []*Group               `protobuf:"bytes,1,rep,name=groups,proto3" json:"groups,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListGroupsResponse) Reset() {
+	*x = ListGroupsResponse{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListGroupsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListGroupsResponse) ProtoMessage() {}
+
+func (x *ListGroupsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListGroupsResponse.ProtoReflect.Descriptor instead.
+func (*ListGroupsResponse) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListGroupsResponse) GetGroups() []*Group {
+	if x != nil {
+		return x.Groups
+	}
+	return nil
+}
+
+func (x *ListGroupsResponse) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// CreateGroupRequest adds a command to the group with the name, the group is
+// created if it doesn't exist
+type CreateGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	CommandId     string                 `protobuf:"bytes,2,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateGroupRequest) Reset() {
+	*x = CreateGroupRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateGroupRequest) ProtoMessage() {}
+
+func (x *CreateGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateGroupRequest.ProtoReflect.Descriptor instead.
+func (*CreateGroupRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *CreateGroupRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateGroupRequest) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+// UpdateGroupRequest changes the fields of the mask, every field is changed
+// without a mask. Commands without an id are added to the group.
+type UpdateGroupRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         *Group                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	UpdateMask    *fieldmaskpb.FieldMask `protobuf:"bytes,2,opt,name=update_mask,json=updateMask,proto3" json:"update_mask,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateGroupRequest) Reset() {
+	*x = UpdateGroupRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateGroupRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateGroupRequest) ProtoMessage() {}
+
+func (x *UpdateGroupRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateGroupRequest.ProtoReflect.Descriptor instead.
+func (*UpdateGroupRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *UpdateGroupRequest) GetGroup() *Group {
+	if x != nil {
+		return x.Group
+	}
+	return nil
+}
+
+func (x *UpdateGroupRequest) GetUpdateMask() *fieldmaskpb.FieldMask {
+	if x != nil {
+		return x.UpdateMask
+	}
+	return nil
+}
+
+type Check struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	ClientId      string                 `protobuf:"bytes,2,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	CommandId     string                 `protobuf:"bytes,3,opt,name=command_id,json=commandId,proto3" json:"command_id,omitempty"`
+	Response      string                 `protobuf:"bytes,4,opt,name=response,proto3" json:"response,omitempty"`
+	Checked       bool                   `protobuf:"varint,5,opt,name=checked,proto3" json:"checked,omitempty"`
+	Error         bool                   `protobuf:"varint,6,opt,name=error,proto3" json:"error,omitempty"`
+	Finished      bool                   `protobuf:"varint,7,opt,name=finished,proto3" json:"finished,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Check) Reset() {
+	*x = Check{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Check) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Check) ProtoMessage() {}
+
+func (x *Check) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Check.ProtoReflect.Descriptor instead.
+func (*Check) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *Check) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *Check) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *Check) GetCommandId() string {
+	if x != nil {
+		return x.CommandId
+	}
+	return ""
+}
+
+func (x *Check) GetResponse() string {
+	if x != nil {
+		return x.Response
+	}
+	return ""
+}
+
+func (x *Check) GetChecked() bool {
+	if x != nil {
+		return x.Checked
+	}
+	return false
+}
+
+func (x *Check) GetError() bool {
+	if x != nil {
+		return x.Error
+	}
+	return false
+}
+
+func (x *Check) GetFinished() bool {
+	if x != nil {
+		return x.Finished
+	}
+	return false
+}
+
+func (x *Check) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Check) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type ListChecksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Checks        []*Check               `protobuf:"bytes,1,rep,name=checks,proto3" json:"checks,omitempty"`
+	Page          *Page                  `protobuf:"bytes,2,opt,name=page,proto3" json:"page,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListChecksResponse) Reset() {
+	*x = ListChecksResponse{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListChecksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListChecksResponse) ProtoMessage() {}
+
+func (x *ListChecksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListChecksResponse.ProtoReflect.Descriptor instead.
+func (*ListChecksResponse) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListChecksResponse) GetChecks() []*Check {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+func (x *ListChecksResponse) GetPage() *Page {
+	if x != nil {
+		return x.Page
+	}
+	return nil
+}
+
+// ListClientChecksRequest lists a page of the checks of a client, newest
+// first unless sorted otherwise. The list fields are those of ListRequest.
+type ListClientChecksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	Filter        string                 `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	Sort          string                 `protobuf:"bytes,3,opt,name=sort,proto3" json:"sort,omitempty"`
+	PageSize      int32                  `protobuf:"varint,4,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,5,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	TotalSize     bool                   `protobuf:"varint,6,opt,name=total_size,json=totalSize,proto3" json:"total_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListClientChecksRequest) Reset() {
+	*x = ListClientChecksRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListClientChecksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListClientChecksRequest) ProtoMessage() {}
+
+func (x *ListClientChecksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListClientChecksRequest.ProtoReflect.Descriptor instead.
+func (*ListClientChecksRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *ListClientChecksRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ListClientChecksRequest) GetFilter() string {
+	if x != nil {
+		return x.Filter
+	}
+	return ""
+}
+
+func (x *ListClientChecksRequest) GetSort() string {
+	if x != nil {
+		return x.Sort
+	}
+	return ""
+}
+
+func (x *ListClientChecksRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListClientChecksRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListClientChecksRequest) GetTotalSize() bool {
+	if x != nil {
+		return x.TotalSize
+	}
+	return false
+}
+
+// ListLatestChecksRequest looks up the latest check of each of the commands
+// on a client
+type ListLatestChecksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	ClientId      string                 `protobuf:"bytes,1,opt,name=client_id,json=clientId,proto3" json:"client_id,omitempty"`
+	CommandIds    []string               `protobuf:"bytes,2,rep,name=command_ids,json=commandIds,proto3" json:"command_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListLatestChecksRequest) Reset() {
+	*x = ListLatestChecksRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListLatestChecksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListLatestChecksRequest) ProtoMessage() {}
+
+func (x *ListLatestChecksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListLatestChecksRequest.ProtoReflect.Descriptor instead.
+func (*ListLatestChecksRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListLatestChecksRequest) GetClientId() string {
+	if x != nil {
+		return x.ClientId
+	}
+	return ""
+}
+
+func (x *ListLatestChecksRequest) GetCommandIds() []string {
+	if x != nil {
+		return x.CommandIds
+	}
+	return nil
+}
+
+type ChecksResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Checks        []*Check               `protobuf:"bytes,1,rep,name=checks,proto3" json:"checks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ChecksResponse) Reset() {
+	*x = ChecksResponse{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ChecksResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ChecksResponse) ProtoMessage() {}
+
+func (x *ChecksResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ChecksResponse.ProtoReflect.Descriptor instead.
+func (*ChecksResponse) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ChecksResponse) GetChecks() []*Check {
+	if x != nil {
+		return x.Checks
+	}
+	return nil
+}
+
+// User is a user without any secrets
+type User struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username      string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	Email         string                 `protobuf:"bytes,3,opt,name=email,proto3" json:"email,omitempty"`
+	EmailVerified bool                   `protobuf:"varint,4,opt,name=email_verified,json=emailVerified,proto3" json:"email_verified,omitempty"`
+	Roles         []string               `protobuf:"bytes,5,rep,name=roles,proto3" json:"roles,omitempty"`
+	Permissions   []string               `protobuf:"bytes,6,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	IsLocked      bool                   `protobuf:"varint,7,opt,name=is_locked,json=isLocked,proto3" json:"is_locked,omitempty"`
+	IsExpired     bool                   `protobuf:"varint,8,opt,name=is_expired,json=isExpired,proto3" json:"is_expired,omitempty"`
+	TotpEnabled   bool                   `protobuf:"varint,9,opt,name=totp_enabled,json=totpEnabled,proto3" json:"totp_enabled,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *User) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetEmail() string {
+	if x != nil {
+		return x.Email
+	}
+	return ""
+}
+
+func (x *User) GetEmailVerified() bool {
+	if x != nil {
+		return x.EmailVerified
+	}
+	return false
+}
+
+func (x *User) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+func (x *User) GetPermissions() []string {
+	if x != nil {
+		return x.Permissions
+	}
+	return nil
+}
+
+func (x *User) GetIsLocked() bool {
+	if x != nil {
+		return x.IsLocked
+	}
+	return false
+}
+
+func (x *User) GetIsExpired() bool {
+	if x != nil {
+		return x.IsExpired
+	}
+	return false
+}
+
+func (x *User) GetTotpEnabled() bool {
+	if x != nil {
+		return x.TotpEnabled
+	}
+	return false
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *User) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+// ListUsersRequest selects a page of users ordered by id, a page starts after
+// the id of the last user of the previous page
+type ListUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PageSize      int32                  `protobuf:"varint,1,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersRequest) Reset() {
+	*x = ListUsersRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersRequest) ProtoMessage() {}
+
+func (x *ListUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersRequest.ProtoReflect.Descriptor instead.
+func (*ListUsersRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *ListUsersRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListUsersRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUsersResponse) Reset() {
+	*x = ListUsersResponse{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUsersResponse) ProtoMessage() {}
+
+func (x *ListUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUsersResponse.ProtoReflect.Descriptor instead.
+func (*ListUsersResponse) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *ListUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+func (x *ListUsersResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type SetUserDisabledRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Disabled      bool                   `protobuf:"varint,2,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetUserDisabledRequest) Reset() {
+	*x = SetUserDisabledRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserDisabledRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserDisabledRequest) ProtoMessage() {}
+
+func (x *SetUserDisabledRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserDisabledRequest.ProtoReflect.Descriptor instead.
+func (*SetUserDisabledRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *SetUserDisabledRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetUserDisabledRequest) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type SetUserRolesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Roles         []string               `protobuf:"bytes,2,rep,name=roles,proto3" json:"roles,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetUserRolesRequest) Reset() {
+	*x = SetUserRolesRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetUserRolesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetUserRolesRequest) ProtoMessage() {}
+
+func (x *SetUserRolesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetUserRolesRequest.ProtoReflect.Descriptor instead.
+func (*SetUserRolesRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{26}
+}
+
+func (x *SetUserRolesRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *SetUserRolesRequest) GetRoles() []string {
+	if x != nil {
+		return x.Roles
+	}
+	return nil
+}
+
+type ResetUserPasswordRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	Password      string                 `protobuf:"bytes,2,opt,name=password,proto3" json:"password,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetUserPasswordRequest) Reset() {
+	*x = ResetUserPasswordRequest{}
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetUserPasswordRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetUserPasswordRequest) ProtoMessage() {}
+
+func (x *ResetUserPasswordRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_keiwi_v1_keiwi_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetUserPasswordRequest.ProtoReflect.Descriptor instead.
+func (*ResetUserPasswordRequest) Descriptor() ([]byte, []int) {
+	return file_keiwi_v1_keiwi_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *ResetUserPasswordRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *ResetUserPasswordRequest) GetPassword() string {
+	if x != nil {
+		return x.Password
+	}
+	return ""
+}
+
+var File_keiwi_v1_keiwi_proto protoreflect.FileDescriptor
+
+const file_keiwi_v1_keiwi_proto_rawDesc = "" +
+	"\n" +
+	"\x14keiwi/v1/keiwi.proto\x12\bkeiwi.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1bgoogle/protobuf/empty.proto\x1a google/protobuf/field_mask.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x94\x01\n" +
+	"\vListRequest\x12\x16\n" +
+	"\x06filter\x18\x01 \x01(\tR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x02 \x01(\tR\x04sort\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x05 \x01(\bR\ttotalSize\"\xd2\x01\n" +
+	"\x04Page\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12&\n" +
+	"\x0fprev_page_token\x18\x03 \x01(\tR\rprevPageToken\x12\"\n" +
+	"\n" +
+	"total_size\x18\x04 \x01(\x05H\x00R\ttotalSize\x88\x01\x01\x12*\n" +
+	"\x11total_size_capped\x18\x05 \x01(\bR\x0ftotalSizeCappedB\r\n" +
+	"\v_total_size\"\x1c\n" +
+	"\n" +
+	"GetRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\x1f\n" +
+	"\rDeleteRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\"\xcf\x01\n" +
+	"\x06Client\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ip\x18\x03 \x01(\tR\x02ip\x12\x1b\n" +
+	"\tgroup_ids\x18\x04 \x03(\tR\bgroupIds\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"e\n" +
+	"\x13ListClientsResponse\x12*\n" +
+	"\aclients\x18\x01 \x03(\v2\x10.keiwi.v1.ClientR\aclients\x12\"\n" +
+	"\x04page\x18\x02 \x01(\v2\x0e.keiwi.v1.PageR\x04page\"9\n" +
+	"\x13CreateClientRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x0e\n" +
+	"\x02ip\x18\x02 \x01(\tR\x02ip\"|\n" +
+	"\x13UpdateClientRequest\x12(\n" +
+	"\x06client\x18\x01 \x01(\v2\x10.keiwi.v1.ClientR\x06client\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"\xf7\x01\n" +
+	"\aCommand\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x18\n" +
+	"\acommand\x18\x02 \x01(\tR\acommand\x12\x12\n" +
+	"\x04name\x18\x03 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x04 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06format\x18\x05 \x01(\tR\x06format\x129\n" +
+	"\n" +
+	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"i\n" +
+	"\x14ListCommandsResponse\x12-\n" +
+	"\bcommands\x18\x01 \x03(\v2\x11.keiwi.v1.CommandR\bcommands\x12\"\n" +
+	"\x04page\x18\x02 \x01(\v2\x0e.keiwi.v1.PageR\x04page\"~\n" +
+	"\x14CreateCommandRequest\x12\x18\n" +
+	"\acommand\x18\x01 \x01(\tR\acommand\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06format\x18\x04 \x01(\tR\x06format\"\x80\x01\n" +
+	"\x14UpdateCommandRequest\x12+\n" +
+	"\acommand\x18\x01 \x01(\v2\x11.keiwi.v1.CommandR\acommand\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"{\n" +
+	"\fGroupCommand\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1d\n" +
+	"\n" +
+	"command_id\x18\x02 \x01(\tR\tcommandId\x12\x1d\n" +
+	"\n" +
+	"next_check\x18\x03 \x01(\x05R\tnextCheck\x12\x1d\n" +
+	"\n" +
+	"stop_error\x18\x04 \x01(\bR\tstopError\"\xd5\x01\n" +
+	"\x05Group\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x122\n" +
+	"\bcommands\x18\x03 \x03(\v2\x16.keiwi.v1.GroupCommandR\bcommands\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"a\n" +
+	"\x12ListGroupsResponse\x12'\n" +
+	"\x06groups\x18\x01 \x03(\v2\x0f.keiwi.v1.GroupR\x06groups\x12\"\n" +
+	"\x04page\x18\x02 \x01(\v2\x0e.keiwi.v1.PageR\x04page\"G\n" +
+	"\x12CreateGroupRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1d\n" +
+	"\n" +
+	"command_id\x18\x02 \x01(\tR\tcommandId\"x\n" +
+	"\x12UpdateGroupRequest\x12%\n" +
+	"\x05group\x18\x01 \x01(\v2\x0f.keiwi.v1.GroupR\x05group\x12;\n" +
+	"\vupdate_mask\x18\x02 \x01(\v2\x1a.google.protobuf.FieldMaskR\n" +
+	"updateMask\"\xb1\x02\n" +
+	"\x05Check\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1b\n" +
+	"\tclient_id\x18\x02 \x01(\tR\bclientId\x12\x1d\n" +
+	"\n" +
+	"command_id\x18\x03 \x01(\tR\tcommandId\x12\x1a\n" +
+	"\bresponse\x18\x04 \x01(\tR\bresponse\x12\x18\n" +
+	"\achecked\x18\x05 \x01(\bR\achecked\x12\x14\n" +
+	"\x05error\x18\x06 \x01(\bR\x05error\x12\x1a\n" +
+	"\bfinished\x18\a \x01(\bR\bfinished\x129\n" +
+	"\n" +
+	"created_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"a\n" +
+	"\x12ListChecksResponse\x12'\n" +
+	"\x06checks\x18\x01 \x03(\v2\x0f.keiwi.v1.CheckR\x06checks\x12\"\n" +
+	"\x04page\x18\x02 \x01(\v2\x0e.keiwi.v1.PageR\x04page\"\xbd\x01\n" +
+	"\x17ListClientChecksRequest\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x16\n" +
+	"\x06filter\x18\x02 \x01(\tR\x06filter\x12\x12\n" +
+	"\x04sort\x18\x03 \x01(\tR\x04sort\x12\x1b\n" +
+	"\tpage_size\x18\x04 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x05 \x01(\tR\tpageToken\x12\x1d\n" +
+	"\n" +
+	"total_size\x18\x06 \x01(\bR\ttotalSize\"W\n" +
+	"\x17ListLatestChecksRequest\x12\x1b\n" +
+	"\tclient_id\x18\x01 \x01(\tR\bclientId\x12\x1f\n" +
+	"\vcommand_ids\x18\x02 \x03(\tR\n" +
+	"commandIds\"9\n" +
+	"\x0eChecksResponse\x12'\n" +
+	"\x06checks\x18\x01 \x03(\v2\x0f.keiwi.v1.CheckR\x06checks\"\xfc\x02\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12\x14\n" +
+	"\x05email\x18\x03 \x01(\tR\x05email\x12%\n" +
+	"\x0eemail_verified\x18\x04 \x01(\bR\remailVerified\x12\x14\n" +
+	"\x05roles\x18\x05 \x03(\tR\x05roles\x12 \n" +
+	"\vpermissions\x18\x06 \x03(\tR\vpermissions\x12\x1b\n" +
+	"\tis_locked\x18\a \x01(\bR\bisLocked\x12\x1d\n" +
+	"\n" +
+	"is_expired\x18\b \x01(\bR\tisExpired\x12!\n" +
+	"\ftotp_enabled\x18\t \x01(\bR\vtotpEnabled\x129\n" +
+	"\n" +
+	"created_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"N\n" +
+	"\x10ListUsersRequest\x12\x1b\n" +
+	"\tpage_size\x18\x01 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\"a\n" +
+	"\x11ListUsersResponse\x12$\n" +
+	"\x05users\x18\x01 \x03(\v2\x0e.keiwi.v1.UserR\x05users\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"D\n" +
+	"\x16SetUserDisabledRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bdisabled\x18\x02 \x01(\bR\bdisabled\";\n" +
+	"\x13SetUserRolesRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x14\n" +
+	"\x05roles\x18\x02 \x03(\tR\x05roles\"F\n" +
+	"\x18ResetUserPasswordRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12\x1a\n" +
+	"\bpassword\x18\x02 \x01(\tR\bpassword2\xd6\x03\n" +
+	"\rClientService\x12X\n" +
+	"\vListClients\x12\x15.keiwi.v1.ListRequest\x1a\x1d.keiwi.v1.ListClientsResponse\"\x13\x82\xd3\xe4\x93\x02\r\x12\v/v1/clients\x12M\n" +
+	"\tGetClient\x12\x14.keiwi.v1.GetRequest\x1a\x10.keiwi.v1.Client\"\x18\x82\xd3\xe4\x93\x02\x12\x12\x10/v1/clients/{id}\x12W\n" +
+	"\fCreateClient\x12\x1d.keiwi.v1.CreateClientRequest\x1a\x10.keiwi.v1.Client\"\x16\x82\xd3\xe4\x93\x02\x10:\x01*\"\v/v1/clients\x12h\n" +
+	"\fUpdateClient\x12\x1d.keiwi.v1.UpdateClientRequest\x1a\x10.keiwi.v1.Client\"'\x82\xd3\xe4\x93\x02!:\x06client2\x17/v1/clients/{client.id}\x12Y\n" +
+	"\fDeleteClient\x12\x17.keiwi.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\"\x18\x82\xd3\xe4\x93\x02\x12*\x10/v1/clients/{id}2\xe9\x03\n" +
+	"\x0eCommandService\x12[\n" +
+	"\fListCommands\x12\x15.keiwi.v1.ListRequest\x1a\x1e.keiwi.v1.ListCommandsResponse\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/commands\x12P\n" +
+	"\n" +
+	"GetCommand\x12\x14.keiwi.v1.GetRequest\x1a\x11.keiwi.v1.Command\"\x19\x82\xd3\xe4\x93\x02\x13\x12\x11/v1/commands/{id}\x12[\n" +
+	"\rCreateCommand\x12\x1e.keiwi.v1.CreateCommandRequest\x1a\x11.keiwi.v1.Command\"\x17\x82\xd3\xe4\x93\x02\x11:\x01*\"\f/v1/commands\x12n\n" +
+	"\rUpdateCommand\x12\x1e.keiwi.v1.UpdateCommandRequest\x1a\x11.keiwi.v1.Command\"*\x82\xd3\xe4\x93\x02$:\acommand2\x19/v1/commands/{command.id}\x12[\n" +
+	"\rDeleteCommand\x12\x17.keiwi.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\"\x19\x82\xd3\xe4\x93\x02\x13*\x11/v1/commands/{id}2\xc3\x03\n" +
+	"\fGroupService\x12U\n" +
+	"\n" +
+	"ListGroups\x12\x15.keiwi.v1.ListRequest\x1a\x1c.keiwi.v1.ListGroupsResponse\"\x12\x82\xd3\xe4\x93\x02\f\x12\n" +
+	"/v1/groups\x12J\n" +
+	"\bGetGroup\x12\x14.keiwi.v1.GetRequest\x1a\x0f.keiwi.v1.Group\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/groups/{id}\x12S\n" +
+	"\vCreateGroup\x12\x1c.keiwi.v1.CreateGroupRequest\x1a\x0f.keiwi.v1.Group\"\x15\x82\xd3\xe4\x93\x02\x0f:\x01*\"\n" +
+	"/v1/groups\x12b\n" +
+	"\vUpdateGroup\x12\x1c.keiwi.v1.UpdateGroupRequest\x1a\x0f.keiwi.v1.Group\"$\x82\xd3\xe4\x93\x02\x1e:\x05group2\x15/v1/groups/{group.id}\x12W\n" +
+	"\vDeleteGroup\x12\x17.keiwi.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\"\x17\x82\xd3\xe4\x93\x02\x11*\x0f/v1/groups/{id}2\x87\x04\n" +
+	"\fCheckService\x12U\n" +
+	"\n" +
+	"ListChecks\x12\x15.keiwi.v1.ListRequest\x1a\x1c.keiwi.v1.ListChecksResponse\"\x12\x82\xd3\xe4\x93\x02\f\x12\n" +
+	"/v1/checks\x12J\n" +
+	"\bGetCheck\x12\x14.keiwi.v1.GetRequest\x1a\x0f.keiwi.v1.Check\"\x17\x82\xd3\xe4\x93\x02\x11\x12\x0f/v1/checks/{id}\x12W\n" +
+	"\vDeleteCheck\x12\x17.keiwi.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\"\x17\x82\xd3\xe4\x93\x02\x11*\x0f/v1/checks/{id}\x12{\n" +
+	"\x10ListClientChecks\x12!.keiwi.v1.ListClientChecksRequest\x1a\x1c.keiwi.v1.ListChecksResponse\"&\x82\xd3\xe4\x93\x02 \x12\x1e/v1/clients/{client_id}/checks\x12~\n" +
+	"\x10ListLatestChecks\x12!.keiwi.v1.ListLatestChecksRequest\x1a\x18.keiwi.v1.ChecksResponse\"-\x82\xd3\xe4\x93\x02'\x12%/v1/clients/{client_id}/checks:latest2\xd6\x04\n" +
+	"\vUserService\x12N\n" +
+	"\x0eGetCurrentUser\x12\x16.google.protobuf.Empty\x1a\x0e.keiwi.v1.User\"\x14\x82\xd3\xe4\x93\x02\x0e\x12\f/v1/users/me\x12W\n" +
+	"\tListUsers\x12\x1a.keiwi.v1.ListUsersRequest\x1a\x1b.keiwi.v1.ListUsersResponse\"\x11\x82\xd3\xe4\x93\x02\v\x12\t/v1/users\x12j\n" +
+	"\x0fSetUserDisabled\x12 .keiwi.v1.SetUserDisabledRequest\x1a\x0e.keiwi.v1.User\"%\x82\xd3\xe4\x93\x02\x1f:\x01*\"\x1a/v1/users/{id}:setDisabled\x12a\n" +
+	"\fSetUserRoles\x12\x1d.keiwi.v1.SetUserRolesRequest\x1a\x0e.keiwi.v1.User\"\"\x82\xd3\xe4\x93\x02\x1c:\x01*\"\x17/v1/users/{id}:setRoles\x12x\n" +
+	"\x11ResetUserPassword\x12\".keiwi.v1.ResetUserPasswordRequest\x1a\x16.google.protobuf.Empty\"'\x82\xd3\xe4\x93\x02!:\x01*\"\x1c/v1/users/{id}:resetPassword\x12U\n" +
+	"\n" +
+	"DeleteUser\x12\x17.keiwi.v1.DeleteRequest\x1a\x16.google.protobuf.Empty\"\x16\x82\xd3\xe4\x93\x02\x10*\x0e/v1/users/{id}B.Z,github.com/keiwi/api/app/rpc/keiwiv1;keiwiv1b\x06proto3"
+
+var (
+	file_keiwi_v1_keiwi_proto_rawDescOnce sync.Once
+	file_keiwi_v1_keiwi_proto_rawDescData []byte
+)
+
+func file_keiwi_v1_keiwi_proto_rawDescGZIP() []byte {
+	file_keiwi_v1_keiwi_proto_rawDescOnce.Do(func() {
+		file_keiwi_v1_keiwi_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_keiwi_v1_keiwi_proto_rawDesc), len(file_keiwi_v1_keiwi_proto_rawDesc)))
+	})
+	return file_keiwi_v1_keiwi_proto_rawDescData
+}
+
+var file_keiwi_v1_keiwi_proto_msgTypes = make([]protoimpl.MessageInfo, 28)
+var file_keiwi_v1_keiwi_proto_goTypes = []any{
+	(*ListRequest)(nil),              // 0: keiwi.v1.ListRequest
+	(*Page)(nil),                     // 1: keiwi.v1.Page
+	(*GetRequest)(nil),               // 2: keiwi.v1.GetRequest
+	(*DeleteRequest)(nil),            // 3: keiwi.v1.DeleteRequest
+	(*Client)(nil),                   // 4: keiwi.v1.Client
+	(*ListClientsResponse)(nil),      // 5: keiwi.v1.ListClientsResponse
+	(*CreateClientRequest)(nil),      // 6: keiwi.v1.CreateClientRequest
+	(*UpdateClientRequest)(nil),      // 7: keiwi.v1.UpdateClientRequest
+	(*Command)(nil),                  // 8: keiwi.v1.Command
+	(*ListCommandsResponse)(nil),     // 9: keiwi.v1.ListCommandsResponse
+	(*CreateCommandRequest)(nil),     // 10: keiwi.v1.CreateCommandRequest
+	(*UpdateCommandRequest)(nil),     // 11: keiwi.v1.UpdateCommandRequest
+	(*GroupCommand)(nil),             // 12: keiwi.v1.GroupCommand
+	(*Group)(nil),                    // 13: keiwi.v1.Group
+	(*ListGroupsResponse)(nil),       // 14: keiwi.v1.ListGroupsResponse
+	(*CreateGroupRequest)(nil),       // 15: keiwi.v1.CreateGroupRequest
+	(*UpdateGroupRequest)(nil),       // 16: keiwi.v1.UpdateGroupRequest
+	(*Check)(nil),                    // 17: keiwi.v1.Check
+	(*ListChecksResponse)(nil),       // 18: keiwi.v1.ListChecksResponse
+	(*ListClientChecksRequest)(nil),  // 19: keiwi.v1.ListClientChecksRequest
+	(*ListLatestChecksRequest)(nil),  // 20: keiwi.v1.ListLatestChecksRequest
+	(*ChecksResponse)(nil),           // 21: keiwi.v1.ChecksResponse
+	(*User)(nil),                     // 22: keiwi.v1.User
+	(*ListUsersRequest)(nil),         // 23: keiwi.v1.ListUsersRequest
+	(*ListUsersResponse)(nil),        // 24: keiwi.v1.ListUsersResponse
+	(*SetUserDisabledRequest)(nil),   // 25: keiwi.v1.SetUserDisabledRequest
+	(*SetUserRolesRequest)(nil),      // 26: keiwi.v1.SetUserRolesRequest
+	(*ResetUserPasswordRequest)(nil), // 27: keiwi.v1.ResetUserPasswordRequest
+	(*timestamppb.Timestamp)(nil),    // 28: google.protobuf.Timestamp
+	(*fieldmaskpb.FieldMask)(nil),    // 29: google.protobuf.FieldMask
+	(*emptypb.Empty)(nil),            // 30: google.protobuf.Empty
+}
+var file_keiwi_v1_keiwi_proto_depIdxs = []int32{
+	28, // 0: keiwi.v1.Client.created_at:type_name -> google.protobuf.Timestamp
+	28, // 1: keiwi.v1.Client.updated_at:type_name -> google.protobuf.Timestamp
+	4,  // 2: keiwi.v1.ListClientsResponse.clients:type_name -> keiwi.v1.Client
+	1,  // 3: keiwi.v1.ListClientsResponse.page:type_name -> keiwi.v1.Page
+	4,  // 4: keiwi.v1.UpdateClientRequest.client:type_name -> keiwi.v1.Client
+	29, // 5: keiwi.v1.UpdateClientRequest.update_mask:type_name -> google.protobuf.FieldMask
+	28, // 6: keiwi.v1.Command.created_at:type_name -> google.protobuf.Timestamp
+	28, // 7: keiwi.v1.Command.updated_at:type_name -> google.protobuf.Timestamp
+	8,  // 8: keiwi.v1.ListCommandsResponse.commands:type_name -> keiwi.v1.Command
+	1,  // 9: keiwi.v1.ListCommandsResponse.page:type_name -> keiwi.v1.Page
+	8,  // 10: keiwi.v1.UpdateCommandRequest.command:type_name -> keiwi.v1.Command
+	29, // 11: keiwi.v1.UpdateCommandRequest.update_mask:type_name -> google.protobuf.FieldMask
+	12, // 12: keiwi.v1.Group.commands:type_name -> keiwi.v1.GroupCommand
+	28, // 13: keiwi.v1.Group.created_at:type_name -> google.protobuf.Timestamp
+	28, // 14: keiwi.v1.Group.updated_at:type_name -> google.protobuf.Timestamp
+	13, // 15: keiwi.v1.ListGroupsResponse.groups:type_name -> keiwi.v1.Group
+	1,  // 16: keiwi.v1.ListGroupsResponse.page:type_name -> keiwi.v1.Page
+	13, // 17: keiwi.v1.UpdateGroupRequest.group:type_name -> keiwi.v1.Group
+	29, // 18: keiwi.v1.UpdateGroupRequest.update_mask:type_name -> google.protobuf.FieldMask
+	28, // 19: keiwi.v1.Check.created_at:type_name -> google.protobuf.Timestamp
+	28, // 20: keiwi.v1.Check.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 21: keiwi.v1.ListChecksResponse.checks:type_name -> keiwi.v1.Check
+	1,  // 22: keiwi.v1.ListChecksResponse.page:type_name -> keiwi.v1.Page
+	17, // 23: keiwi.v1.ChecksResponse.checks:type_name -> keiwi.v1.Check
+	28, // 24: keiwi.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	28, // 25: keiwi.v1.User.updated_at:type_name -> google.protobuf.Timestamp
+	22, // 26: keiwi.v1.ListUsersResponse.users:type_name -> keiwi.v1.User
+	0,  // 27: keiwi.v1.ClientService.ListClients:input_type -> keiwi.v1.ListRequest
+	2,  // 28: keiwi.v1.ClientService.GetClient:input_type -> keiwi.v1.GetRequest
+	6,  // 29: keiwi.v1.ClientService.CreateClient:input_type -> keiwi.v1.CreateClientRequest
+	7,  // 30: keiwi.v1.ClientService.UpdateClient:input_type -> keiwi.v1.UpdateClientRequest
+	3,  // 31: keiwi.v1.ClientService.DeleteClient:input_type -> keiwi.v1.DeleteRequest
+	0,  // 32: keiwi.v1.CommandService.ListCommands:input_type -> keiwi.v1.ListRequest
+	2,  // 33: keiwi.v1.CommandService.GetCommand:input_type -> keiwi.v1.GetRequest
+	10, // 34: keiwi.v1.CommandService.CreateCommand:input_type -> keiwi.v1.CreateCommandRequest
+	11, // 35: keiwi.v1.CommandService.UpdateCommand:input_type -> keiwi.v1.UpdateCommandRequest
+	3,  // 36: keiwi.v1.CommandService.DeleteCommand:input_type -> keiwi.v1.DeleteRequest
+	0,  // 37: keiwi.v1.GroupService.ListGroups:input_type -> keiwi.v1.ListRequest
+	2,  // 38: keiwi.v1.GroupService.GetGroup:input_type -> keiwi.v1.GetRequest
+	15, // 39: keiwi.v1.GroupService.CreateGroup:input_type -> keiwi.v1.CreateGroupRequest
+	16, // 40: keiwi.v1.GroupService.UpdateGroup:input_type -> keiwi.v1.UpdateGroupRequest
+	3,  // 41: keiwi.v1.GroupService.DeleteGroup:input_type -> keiwi.v1.DeleteRequest
+	0,  // 42: keiwi.v1.CheckService.ListChecks:input_type -> keiwi.v1.ListRequest
+	2,  // 43: keiwi.v1.CheckService.GetCheck:input_type -> keiwi.v1.GetRequest
+	3,  // 44: keiwi.v1.CheckService.DeleteCheck:input_type -> keiwi.v1.DeleteRequest
+	19, // 45: keiwi.v1.CheckService.ListClientChecks:input_type -> keiwi.v1.ListClientChecksRequest
+	20, // 46: keiwi.v1.CheckService.ListLatestChecks:input_type -> keiwi.v1.ListLatestChecksRequest
+	30, // 47: keiwi.v1.UserService.GetCurrentUser:input_type -> google.protobuf.Empty
+	23, // 48: keiwi.v1.UserService.ListUsers:input_type -> keiwi.v1.ListUsersRequest
+	25, // 49: keiwi.v1.UserService.SetUserDisabled:input_type -> keiwi.v1.SetUserDisabledRequest
+	26, // 50: keiwi.v1.UserService.SetUserRoles:input_type -> keiwi.v1.SetUserRolesRequest
+	27, // 51: keiwi.v1.UserService.ResetUserPassword:input_type -> keiwi.v1.ResetUserPasswordRequest
+	3,  // 52: keiwi.v1.UserService.DeleteUser:input_type -> keiwi.v1.DeleteRequest
+	5,  // 53: keiwi.v1.ClientService.ListClients:output_type -> keiwi.v1.ListClientsResponse
+	4,  // 54: keiwi.v1.ClientService.GetClient:output_type -> keiwi.v1.Client
+	4,  // 55: keiwi.v1.ClientService.CreateClient:output_type -> keiwi.v1.Client
+	4,  // 56: keiwi.v1.ClientService.UpdateClient:output_type -> keiwi.v1.Client
+	30, // 57: keiwi.v1.ClientService.DeleteClient:output_type -> google.protobuf.Empty
+	9,  // 58: keiwi.v1.CommandService.ListCommands:output_type -> keiwi.v1.ListCommandsResponse
+	8,  // 59: keiwi.v1.CommandService.GetCommand:output_type -> keiwi.v1.Command
+	8,  // 60: keiwi.v1.CommandService.CreateCommand:output_type -> keiwi.v1.Command
+	8,  // 61: keiwi.v1.CommandService.UpdateCommand:output_type -> keiwi.v1.Command
+	30, // 62: keiwi.v1.CommandService.DeleteCommand:output_type -> google.protobuf.Empty
+	14, // 63: keiwi.v1.GroupService.ListGroups:output_type -> keiwi.v1.ListGroupsResponse
+	13, // 64: keiwi.v1.GroupService.GetGroup:output_type -> keiwi.v1.Group
+	13, // 65: keiwi.v1.GroupService.CreateGroup:output_type -> keiwi.v1.Group
+	13, // 66: keiwi.v1.GroupService.UpdateGroup:output_type -> keiwi.v1.Group
+	30, // 67: keiwi.v1.GroupService.DeleteGroup:output_type -> google.protobuf.Empty
+	18, // 68: keiwi.v1.CheckService.ListChecks:output_type -> keiwi.v1.ListChecksResponse
+	17, // 69: keiwi.v1.CheckService.GetCheck:output_type -> keiwi.v1.Check
+	30, // 70: keiwi.v1.CheckService.DeleteCheck:output_type -> google.protobuf.Empty
+	18, // 71: keiwi.v1.CheckService.ListClientChecks:output_type -> keiwi.v1.ListChecksResponse
+	21, // 72: keiwi.v1.CheckService.ListLatestChecks:output_type -> keiwi.v1.ChecksResponse
+	22, // 73: keiwi.v1.UserService.GetCurrentUser:output_type -> keiwi.v1.User
+	24, // 74: keiwi.v1.UserService.ListUsers:output_type -> keiwi.v1.ListUsersResponse
+	22, // 75: keiwi.v1.UserService.SetUserDisabled:output_type -> keiwi.v1.User
+	22, // 76: keiwi.v1.UserService.SetUserRoles:output_type -> keiwi.v1.User
+	30, // 77: keiwi.v1.UserService.ResetUserPassword:output_type -> google.protobuf.Empty
+	30, // 78: keiwi.v1.UserService.DeleteUser:output_type -> google.protobuf.Empty
+	53, // [53:79] is the sub-list for method output_type
+	27, // [27:53] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
+}
+
+func init() { file_keiwi_v1_keiwi_proto_init() }
+func file_keiwi_v1_keiwi_proto_init() {
+	if File_keiwi_v1_keiwi_proto != nil {
+		return
+	}
+	file_keiwi_v1_keiwi_proto_msgTypes[1].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_keiwi_v1_keiwi_proto_rawDesc), len(file_keiwi_v1_keiwi_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   28,
+			NumExtensions: 0,
+			NumServices:   5,
+		},
+		GoTypes:           file_keiwi_v1_keiwi_proto_goTypes,
+		DependencyIndexes: file_keiwi_v1_keiwi_proto_depIdxs,
+		MessageInfos:      file_keiwi_v1_keiwi_proto_msgTypes,
+	}.Build()
+	File_keiwi_v1_keiwi_proto = out.File
+	file_keiwi_v1_keiwi_proto_goTypes = nil
+	file_keiwi_v1_keiwi_proto_depIdxs = nil
+}
@@ -0,0 +1,142 @@
+// Package rpc serves the gRPC API of proto/keiwi/v1/keiwi.proto, and
+// optionally the same API as JSON through grpc-gateway. The services call the
+// service layer shared with the HTTP controllers.
+package rpc
+
+//go:generate sh -c "cd ../../proto && buf generate"
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+var (
+	server  *grpc.Server
+	gateway *http.Server
+)
+
+// Start starts the gRPC server, and the gateway if enabled, once aah has
+// started
+func Start(_ *aah.Event) {
+	cfg := aah.AppConfig()
+	if !cfg.BoolDefault("api.grpc.enable", false) {
+		return
+	}
+
+	address := cfg.StringDefault("api.grpc.address", ":9090")
+	lis, err := net.Listen("tcp", address)
+	if err != nil {
+		log.Fatalf("unable to listen for gRPC on %s: %v", address, err)
+	}
+
+	opts := []grpc.ServerOption{grpc.UnaryInterceptor(authenticate)}
+	certFile := cfg.StringDefault("api.grpc.tls.cert_file", "")
+	keyFile := cfg.StringDefault("api.grpc.tls.key_file", "")
+	if certFile != "" {
+		creds, err := credentials.NewServerTLSFromFile(certFile, keyFile)
+		if err != nil {
+			log.Fatalf("unable to load the gRPC certificate: %v", err)
+		}
+		opts = append(opts, grpc.Creds(creds))
+	}
+
+	server = grpc.NewServer(opts...)
+	keiwiv1.RegisterClientServiceServer(server, clientServer{})
+	keiwiv1.RegisterCommandServiceServer(server, commandServer{})
+	keiwiv1.RegisterGroupServiceServer(server, groupServer{})
+	keiwiv1.RegisterCheckServiceServer(server, checkServer{})
+	keiwiv1.RegisterUserServiceServer(server, userServer{})
+
+	go func() {
+		if err := server.Serve(lis); err != nil {
+			log.Errorf("the gRPC server stopped: %v", err)
+		}
+	}()
+	log.Infof("gRPC server listening on %s", address)
+
+	if cfg.BoolDefault("api.grpc.gateway.enable", false) {
+		startGateway(lis.Addr().String(), certFile)
+	}
+}
+
+// startGateway serves the API as JSON, forwarding the calls to the gRPC
+// server at endpoint
+func startGateway(endpoint, certFile string) {
+	cfg := aah.AppConfig()
+
+	creds := insecure.NewCredentials()
+	if certFile != "" {
+		var err error
+		creds, err = credentials.NewClientTLSFromFile(certFile, cfg.StringDefault("api.grpc.gateway.server_name", "localhost"))
+		if err != nil {
+			log.Fatalf("unable to load the gRPC certificate for the gateway: %v", err)
+		}
+	}
+
+	// the gateway dials its own address, the port of a wildcard address
+	// is reachable on localhost
+	if strings.HasPrefix(endpoint, "[::]:") || strings.HasPrefix(endpoint, "0.0.0.0:") {
+		endpoint = "localhost" + endpoint[strings.LastIndex(endpoint, ":"):]
+	}
+
+	mux := runtime.NewServeMux(runtime.WithIncomingHeaderMatcher(forwardHeader))
+	opts := []grpc.DialOption{grpc.WithTransportCredentials(creds)}
+	ctx := context.Background()
+	for _, register := range []func(context.Context, *runtime.ServeMux, string, []grpc.DialOption) error{
+		keiwiv1.RegisterClientServiceHandlerFromEndpoint,
+		keiwiv1.RegisterCommandServiceHandlerFromEndpoint,
+		keiwiv1.RegisterGroupServiceHandlerFromEndpoint,
+		keiwiv1.RegisterCheckServiceHandlerFromEndpoint,
+		keiwiv1.RegisterUserServiceHandlerFromEndpoint,
+	} {
+		if err := register(ctx, mux, endpoint, opts); err != nil {
+			log.Fatalf("unable to register the gRPC gateway: %v", err)
+		}
+	}
+
+	address := cfg.StringDefault("api.grpc.gateway.address", ":8081")
+	gateway = &http.Server{Addr: address, Handler: mux}
+	go func() {
+		if err := gateway.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Errorf("the gRPC gateway stopped: %v", err)
+		}
+	}()
+	log.Infof("gRPC gateway listening on %s", address)
+}
+
+// forwardHeader forwards the headers selecting the organization and
+// identifying the request as metadata, besides the `Authorization` header
+// the gateway always forwards
+func forwardHeader(key string) (string, bool) {
+	switch http.CanonicalHeaderKey(key) {
+	case service.OrgHeader, http.CanonicalHeaderKey(aah.AppConfig().StringDefault("request.id.header", "X-Request-Id")):
+		return strings.ToLower(key), true
+	}
+	return runtime.DefaultHeaderMatcher(key)
+}
+
+// Stop stops the gateway and gracefully stops the gRPC server
+func Stop(_ *aah.Event) {
+	if gateway != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := gateway.Shutdown(ctx); err != nil {
+			log.Errorf("error stopping the gRPC gateway: %v", err)
+		}
+	}
+	if server != nil {
+		server.GracefulStop()
+	}
+}
@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"encoding/json"
+
+	"github.com/keiwi/api/app/patch"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/fieldmaskpb"
+)
+
+// mergePatch returns the JSON Merge Patch setting the fields of the mask to
+// their values in the message, every field of the schema is set without a
+// mask. The fields are named like in the HTTP API, and empty ids of nested
+// entities are left out so that new ones are generated.
+func mergePatch(m proto.Message, mask *fieldmaskpb.FieldMask, schema patch.Schema) ([]byte, error) {
+	data, err := protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true}.Marshal(m)
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+
+	paths := mask.GetPaths()
+	if len(paths) <= 0 {
+		for name := range schema {
+			paths = append(paths, name)
+		}
+	}
+
+	set := make(map[string]interface{}, len(paths))
+	for _, name := range paths {
+		if _, found := schema[name]; !found {
+			return nil, status.Errorf(codes.InvalidArgument, "The field %s can't be updated", name)
+		}
+		set[name] = withoutEmptyIDs(doc[name])
+	}
+	return json.Marshal(set)
+}
+
+// withoutEmptyIDs removes the empty `id` fields of the objects in the value
+func withoutEmptyIDs(v interface{}) interface{} {
+	switch v := v.(type) {
+	case []interface{}:
+		for i := range v {
+			v[i] = withoutEmptyIDs(v[i])
+		}
+	case map[string]interface{}:
+		if id, ok := v["id"].(string); ok && id == "" {
+			delete(v, "id")
+		}
+		for name := range v {
+			v[name] = withoutEmptyIDs(v[name])
+		}
+	}
+	return v
+}
@@ -0,0 +1,66 @@
+package rpc
+
+import (
+	"context"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/rpc/keiwiv1"
+	"github.com/keiwi/api/app/service"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+// userServer implements UserService with the service layer
+type userServer struct {
+	keiwiv1.UnimplementedUserServiceServer
+}
+
+func (userServer) GetCurrentUser(ctx context.Context, _ *emptypb.Empty) (*keiwiv1.User, error) {
+	user, err := service.CurrentUser(callerOf(ctx))
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (userServer) ListUsers(ctx context.Context, req *keiwiv1.ListUsersRequest) (*keiwiv1.ListUsersResponse, error) {
+	page, err := service.ListUsers(callerOf(ctx), models.UserList{After: req.GetPageToken(), Limit: int(req.GetPageSize())})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+
+	res := &keiwiv1.ListUsersResponse{Users: make([]*keiwiv1.User, 0, len(page.Users)), NextPageToken: page.Next}
+	for _, u := range page.Users {
+		res.Users = append(res.Users, toUser(u))
+	}
+	return res, nil
+}
+
+func (userServer) SetUserDisabled(ctx context.Context, req *keiwiv1.SetUserDisabledRequest) (*keiwiv1.User, error) {
+	user, err := service.SetUserDisabled(callerOf(ctx), models.UserDisable{ID: req.GetId(), Disabled: req.GetDisabled()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (userServer) SetUserRoles(ctx context.Context, req *keiwiv1.SetUserRolesRequest) (*keiwiv1.User, error) {
+	user, err := service.SetUserRoles(callerOf(ctx), models.UserRoles{ID: req.GetId(), Roles: req.GetRoles()})
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return toUser(user), nil
+}
+
+func (userServer) ResetUserPassword(ctx context.Context, req *keiwiv1.ResetUserPasswordRequest) (*emptypb.Empty, error) {
+	if err := service.ResetUserPassword(callerOf(ctx), models.UserPasswordReset{ID: req.GetId(), Password: req.GetPassword()}); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (userServer) DeleteUser(ctx context.Context, req *keiwiv1.DeleteRequest) (*emptypb.Empty, error) {
+	if err := service.DeleteUser(callerOf(ctx), req.GetId()); err != nil {
+		return nil, toStatus(err)
+	}
+	return &emptypb.Empty{}, nil
+}
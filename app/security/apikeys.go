@@ -0,0 +1,74 @@
+package security
+
+import (
+	"errors"
+	"strings"
+
+	"aahframework.org/log.v0"
+	"aahframework.org/security.v0/authc"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+)
+
+// APIKeyClaim is the principal claim holding the id of the api key a request
+// authenticated with
+const APIKeyClaim = "apikey"
+
+// apiKeyPrefix is prepended to every generated key so leaked keys are easy to find
+const apiKeyPrefix = "kw_"
+
+// GenerateAPIKey returns a new random api key, its display prefix and its hash
+func GenerateAPIKey() (key, prefix, hash string, err error) {
+	token, err := RandomToken(32)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	key = apiKeyPrefix + token
+	return key, key[:len(apiKeyPrefix)+8], HashToken(key), nil
+}
+
+// PrincipalClaim returns the value of the principal with the claim, or an
+// empty string if the subject has no such principal
+func PrincipalClaim(authcInfo *authc.AuthenticationInfo, claim string) string {
+	if authcInfo == nil {
+		return ""
+	}
+	for _, p := range authcInfo.Principals {
+		if p.Claim == claim {
+			return p.Value
+		}
+	}
+	return ""
+}
+
+// userFromAPIKey looks up the api key and the user owning it, and records
+// that the key has been used
+func userFromAPIKey(key string) (*storageModel.User, *models.APIKey, error) {
+	if !strings.HasPrefix(key, apiKeyPrefix) {
+		return nil, nil, authc.ErrAuthenticationFailed
+	}
+
+	keys, err := models.FindAPIKeys(utils.Filter{"hash": HashToken(key)})
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(keys) <= 0 {
+		return nil, nil, authc.ErrAuthenticationFailed
+	}
+	apiKey := keys[0]
+	if apiKey.IsExpired() {
+		return nil, nil, errors.New("api key has expired")
+	}
+
+	user, err := models.FindUserWithID(apiKey.UserID.Hex())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := models.TouchAPIKey(apiKey.ID); err != nil {
+		log.Errorf("error updating last use of api key: %v", err)
+	}
+	return user, &apiKey, nil
+}
@@ -0,0 +1,244 @@
+package security
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"aahframework.org/config.v0"
+	"github.com/keiwi/api/app/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Logins limits failed login attempts per user and client IP
+var Logins = &LoginLimiter{
+	Store:        NewMemoryAttemptStore(time.Hour),
+	FreeAttempts: 3,
+	BaseDelay:    time.Second,
+	Lockout:      15 * time.Minute,
+}
+
+// Attempts is the failed login attempts of a key, attempts count as failed
+// from when they are reserved until they succeed
+type Attempts struct {
+	Key         string    `json:"key"`
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// AttemptStore counts failed login attempts per key. Keys are prefixed with
+// what they count, e.g. `user:` or `ip:`.
+type AttemptStore interface {
+	// Attempt atomically records an attempt for the key and returns the
+	// attempts before it
+	Attempt(key string) (Attempts, error)
+
+	// Release takes back an attempt of the key that succeeded
+	Release(key string) error
+
+	// Reset clears the failed attempts of the key
+	Reset(key string) error
+}
+
+// LoginLimiter applies exponential backoff to failed logins, once a key has
+// failed more than FreeAttempts times it has to wait BaseDelay, doubling on
+// every further failure up to the Lockout
+type LoginLimiter struct {
+	Store        AttemptStore
+	FreeAttempts int
+	BaseDelay    time.Duration
+	Lockout      time.Duration
+}
+
+// UserKey returns the attempt key of a user, shared by its username and
+// email
+func UserKey(id bson.ObjectId) string {
+	return "user:" + id.Hex()
+}
+
+// LoginKey returns the attempt key of a login that isn't a local user
+func LoginKey(login string) string {
+	return "login:" + strings.ToLower(login)
+}
+
+// IPKey returns the attempt key of a client IP
+func IPKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loadLoginLimiter configures the limiter from `security.login_limit`
+func loadLoginLimiter(cfg *config.Config) error {
+	window, err := time.ParseDuration(cfg.StringDefault("security.login_limit.window", "1h"))
+	if err != nil {
+		return err
+	}
+	if Logins.BaseDelay, err = time.ParseDuration(cfg.StringDefault("security.login_limit.base_delay", "1s")); err != nil {
+		return err
+	}
+	if Logins.Lockout, err = time.ParseDuration(cfg.StringDefault("security.login_limit.lockout", "15m")); err != nil {
+		return err
+	}
+	Logins.FreeAttempts = cfg.IntDefault("security.login_limit.free_attempts", 3)
+
+	switch cfg.StringDefault("security.login_limit.store", "memory") {
+	case "nats":
+		Logins.Store = &NatsAttemptStore{}
+	default:
+		Logins.Store = NewMemoryAttemptStore(window)
+	}
+	return nil
+}
+
+// Reserve records an attempt for all keys before it is verified and returns
+// how long the keys had to wait, the attempt is blocked when it is more than
+// zero. Attempts count as failed until they are released, so concurrent
+// attempts can't all pass the limit, and blocked attempts count as well.
+func (l *LoginLimiter) Reserve(keys ...string) (time.Duration, error) {
+	var wait time.Duration
+	for _, key := range keys {
+		attempts, err := l.Store.Attempt(key)
+		if err != nil {
+			return 0, err
+		}
+		if d := l.delay(attempts); d > wait {
+			wait = d
+		}
+	}
+	return wait, nil
+}
+
+// Release takes back the reserved attempt of all keys once it succeeded
+func (l *LoginLimiter) Release(keys ...string) error {
+	for _, key := range keys {
+		if err := l.Store.Release(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Reset clears the failed attempts of all keys
+func (l *LoginLimiter) Reset(keys ...string) error {
+	for _, key := range keys {
+		if err := l.Store.Reset(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *LoginLimiter) delay(attempts Attempts) time.Duration {
+	over := attempts.Failures - l.FreeAttempts
+	if over < 0 {
+		return 0
+	}
+
+	// Doubled step by step, shifting overflows for long base delays
+	delay := l.BaseDelay
+	for ; over > 0 && delay > 0 && delay < l.Lockout; over-- {
+		delay *= 2
+	}
+	if delay > l.Lockout {
+		delay = l.Lockout
+	}
+
+	remaining := time.Until(attempts.LastFailure.Add(delay))
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// MemoryAttemptStore keeps the attempts in memory, attempts are forgotten
+// when a key has not failed within the window. Keys that are never read again
+// are swept once per window.
+type MemoryAttemptStore struct {
+	window    time.Duration
+	mu        sync.Mutex
+	attempts  map[string]Attempts
+	lastSweep time.Time
+}
+
+// NewMemoryAttemptStore creates an empty in memory store
+func NewMemoryAttemptStore(window time.Duration) *MemoryAttemptStore {
+	return &MemoryAttemptStore{window: window, attempts: make(map[string]Attempts), lastSweep: time.Now()}
+}
+
+// Attempt is `AttemptStore` interface
+func (m *MemoryAttemptStore) Attempt(key string) (Attempts, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.sweep()
+	before := m.get(key)
+	attempts := before
+	attempts.Failures++
+	attempts.LastFailure = time.Now()
+	m.attempts[key] = attempts
+	return before, nil
+}
+
+// Release is `AttemptStore` interface
+func (m *MemoryAttemptStore) Release(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	attempts := m.get(key)
+	if attempts.Failures > 0 {
+		attempts.Failures--
+		m.attempts[key] = attempts
+	}
+	return nil
+}
+
+// Reset is `AttemptStore` interface
+func (m *MemoryAttemptStore) Reset(key string) error {
+	m.mu.Lock()
+	delete(m.attempts, key)
+	m.mu.Unlock()
+	return nil
+}
+
+// sweep deletes the expired attempts, at most once per window
+func (m *MemoryAttemptStore) sweep() {
+	if time.Since(m.lastSweep) < m.window {
+		return
+	}
+	m.lastSweep = time.Now()
+	for key, attempts := range m.attempts {
+		if time.Since(attempts.LastFailure) > m.window {
+			delete(m.attempts, key)
+		}
+	}
+}
+
+func (m *MemoryAttemptStore) get(key string) Attempts {
+	attempts, ok := m.attempts[key]
+	if !ok || time.Since(attempts.LastFailure) > m.window {
+		delete(m.attempts, key)
+		return Attempts{Key: key}
+	}
+	return attempts
+}
+
+// NatsAttemptStore shares the attempts between API instances through the
+// storage service, which is expected to expire idle keys itself
+type NatsAttemptStore struct{}
+
+// Attempt is `AttemptStore` interface, the storage service has to increment
+// the attempts atomically and reply with the attempts before
+func (n *NatsAttemptStore) Attempt(key string) (Attempts, error) {
+	attempts := Attempts{Key: key}
+	err := models.Request("login_attempts.attempt", Attempts{Key: key}, &attempts)
+	return attempts, err
+}
+
+// Release is `AttemptStore` interface
+func (n *NatsAttemptStore) Release(key string) error {
+	return models.Request("login_attempts.release", Attempts{Key: key}, nil)
+}
+
+// Reset is `AttemptStore` interface
+func (n *NatsAttemptStore) Reset(key string) error {
+	return models.Request("login_attempts.reset", Attempts{Key: key}, nil)
+}
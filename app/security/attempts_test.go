@@ -0,0 +1,110 @@
+package security
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func testLimiter() *LoginLimiter {
+	return &LoginLimiter{
+		Store:        NewMemoryAttemptStore(time.Hour),
+		FreeAttempts: 3,
+		BaseDelay:    time.Minute,
+		Lockout:      15 * time.Minute,
+	}
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	limiter := testLimiter()
+
+	// Parallel guesses all reserve before any of them is verified, only the
+	// free attempts may pass
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	passed := 0
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, err := limiter.Reserve("user:a", "ip:203.0.113.7")
+			if err != nil {
+				t.Errorf("Reserve failed: %v", err)
+				return
+			}
+			if wait == 0 {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if passed != limiter.FreeAttempts {
+		t.Errorf("%d parallel attempts passed, want %d", passed, limiter.FreeAttempts)
+	}
+}
+
+func TestReserveRelease(t *testing.T) {
+	limiter := testLimiter()
+
+	// Successful attempts are released and never block
+	for i := 0; i < 10; i++ {
+		wait, err := limiter.Reserve("ip:203.0.113.7")
+		if err != nil || wait > 0 {
+			t.Fatalf("attempt %d = %v, %v, want it to pass", i, wait, err)
+		}
+		if err := limiter.Release("ip:203.0.113.7"); err != nil {
+			t.Fatalf("Release failed: %v", err)
+		}
+	}
+
+	for i := 0; i < limiter.FreeAttempts; i++ {
+		if wait, _ := limiter.Reserve("user:a"); wait > 0 {
+			t.Fatalf("free attempt %d has to wait %s", i, wait)
+		}
+	}
+	wait, _ := limiter.Reserve("user:a")
+	if wait <= 0 || wait > limiter.BaseDelay {
+		t.Errorf("attempt after the free attempts has to wait %s, want up to %s", wait, limiter.BaseDelay)
+	}
+
+	// Keys are limited separately, and a reset clears the key
+	if wait, _ := limiter.Reserve("user:b"); wait > 0 {
+		t.Errorf("another key has to wait %s", wait)
+	}
+	if err := limiter.Reset("user:a"); err != nil {
+		t.Fatalf("Reset failed: %v", err)
+	}
+	if wait, _ := limiter.Reserve("user:a"); wait > 0 {
+		t.Errorf("attempt after a reset has to wait %s", wait)
+	}
+}
+
+func TestLoginDelay(t *testing.T) {
+	limiter := testLimiter()
+	now := time.Now()
+
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{0, 0},
+		{3, time.Minute},
+		{4, 2 * time.Minute},
+		{7, limiter.Lockout},
+		{40, limiter.Lockout},
+		{100, limiter.Lockout},
+	}
+	for _, test := range tests {
+		delay := limiter.delay(Attempts{Failures: test.failures, LastFailure: now})
+		if delay > test.delay || delay < test.delay-time.Second {
+			t.Errorf("delay after %d failures = %s, want %s", test.failures, delay, test.delay)
+		}
+	}
+
+	if delay := limiter.delay(Attempts{Failures: 5, LastFailure: now.Add(-time.Hour)}); delay != 0 {
+		t.Errorf("delay after the lockout passed = %s, want 0", delay)
+	}
+}
@@ -0,0 +1,33 @@
+package security
+
+import (
+	"strings"
+
+	"aahframework.org/security.v0/authc"
+	"aahframework.org/security.v0/authz"
+)
+
+// authorizer is the AuthorizationProvider initialized by aah, kept to
+// authorize calls that don't go through aah
+var authorizer *AuthorizationProvider
+
+// Authenticate authenticates the value of an `Authorization` header outside
+// of aah, e.g. for the gRPC server, and returns the authorization of the
+// subject. Client certificates are only verified by ClientCertMiddleware, so
+// the `Cert` scheme is refused. The ip is the client IP of the call.
+func Authenticate(header, ip string) (*authc.AuthenticationInfo, *authz.AuthorizationInfo, error) {
+	scheme, _ := splitAuthorization(header)
+	if strings.EqualFold(scheme, "cert") {
+		return nil, nil, authc.ErrAuthenticationFailed
+	}
+
+	authcInfo, err := (&AuthenticationProvider{}).GetAuthenticationInfo(&authc.AuthenticationToken{Scheme: Realm, Identity: header, Credential: ip})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if authorizer == nil {
+		return authcInfo, authz.NewAuthorizationInfo(), nil
+	}
+	return authcInfo, authorizer.GetAuthorizationInfo(authcInfo), nil
+}
@@ -1,13 +1,33 @@
 package security
 
 import (
+	"encoding/base64"
+	"errors"
+	"strings"
+
 	"aahframework.org/aah.v0"
 	"aahframework.org/config.v0"
+	"aahframework.org/log.v0"
 	"aahframework.org/security.v0/authc"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/keiwi/api/app/models"
+	storageModel "github.com/keiwi/utils/models"
 )
 
 var _ authc.Authenticator = (*AuthenticationProvider)(nil)
 
+// Realm is the realm of every principal created by the providers
+const Realm = "keiwi"
+
+// MFAPendingClaim is the principal claim set when a request authenticated
+// with a token of a login that still has to complete two-factor authentication
+const MFAPendingClaim = "mfa_pending"
+
+// ClientIPHeader is set by ClientIPMiddleware to the client IP of the request,
+// it is configured as the credential header of the auth scheme so the IP
+// reaches the provider as the credential of the token
+const ClientIPHeader = "X-Keiwi-Client-IP"
+
 // AuthenticationProvider struct implements `authc.Authenticator` interface.
 type AuthenticationProvider struct {
 }
@@ -15,47 +35,173 @@ type AuthenticationProvider struct {
 // Init method initializes the AuthenticationProvider, this method gets called
 // during server start up.
 func (a *AuthenticationProvider) Init(cfg *config.Config) error {
-
-	// NOTE: Init is called on application startup
-
-	return nil
+	if err := LoadKeys(cfg); err != nil {
+		return err
+	}
+	if err := loadTokenConfig(cfg); err != nil {
+		return err
+	}
+	if err := loadMFAConfig(cfg); err != nil {
+		return err
+	}
+	if err := loadOIDCConfig(cfg); err != nil {
+		return err
+	}
+	if err := loadLoginBackends(cfg); err != nil {
+		return err
+	}
+	if err := loadClientCertConfig(cfg); err != nil {
+		return err
+	}
+	if err := loadProxyConfig(cfg); err != nil {
+		return err
+	}
+	return loadLoginLimiter(cfg)
 }
 
 // GetAuthenticationInfo method is `authc.Authenticator` interface
+//
+// The identity is the raw `Authorization` header, either a `Bearer` token
+// issued by the users controller, an `ApiKey`, `Basic` credentials or the
+// `Cert` set by ClientCertMiddleware for a verified client certificate. The
+// credential is the client IP, which limits failed `Basic` logins.
 func (a *AuthenticationProvider) GetAuthenticationInfo(authcToken *authc.AuthenticationToken) (*authc.AuthenticationInfo, error) {
+	scheme, value := splitAuthorization(authcToken.Identity)
 
-	//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
-	// This code snippet provided as a reference
-	//
-	// Call your appropriate datasource here (such as DB, API, LDAP, etc)
-	// to get the subject (aka user) authentication information.
-	//
-	// Form Auth Values from authcToken
-	// 		authcToken.Identity => username
-	// 		authcToken.Credential => passowrd
-	//_____________________________________________________________________
-
-	// user := models.FindUserByEmail(authcToken.Identity)
-	// if user == nil {
-	// 	// No subject exists, return nil and error
-	// 	return nil, authc.ErrSubjectNotExists
-	// }
+	var user *storageModel.User
+	var principals []*authc.Principal
+	var err error
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		var claims jwt.MapClaims
+		user, claims, err = userFromToken(value)
+		if err == nil {
+			if claims["typ"] == MFAToken {
+				principals = append(principals, &authc.Principal{Claim: MFAPendingClaim, Value: "true", Realm: Realm})
+			} else if sid, _ := claims["sid"].(string); sid != "" {
+				principals = append(principals, &authc.Principal{Claim: SessionClaim, Value: sid, Realm: Realm})
+			}
+		}
+	case "apikey":
+		var key *models.APIKey
+		user, key, err = userFromAPIKey(value)
+		if err == nil {
+			principals = append(principals, &authc.Principal{Claim: APIKeyClaim, Value: key.ID.Hex(), Realm: Realm})
+		}
+	case "basic":
+		user, err = userFromBasic(value, authcToken.Credential)
+	case "cert":
+		var p *CertPrincipal
+		var subject string
+		user, p, subject, err = userFromCert(value)
+		if err == nil {
+			principals = append(principals,
+				&authc.Principal{Claim: CertClaim, Value: p.Name, Realm: Realm},
+				&authc.Principal{Claim: CertSubjectClaim, Value: subject, Realm: Realm})
+		}
+	default:
+		return nil, authc.ErrAuthenticationFailed
+	}
+	if err == models.ErrUserNotFound {
+		return nil, authc.ErrSubjectNotExists
+	}
+	if err != nil {
+		log.Debugf("authentication failed: %v", err)
+		return nil, authc.ErrAuthenticationFailed
+	}
 
 	// User found, now create authentication info and return to the framework
 	authcInfo := authc.NewAuthenticationInfo()
-	// authcInfo.Principals = append(authcInfo.Principals,
-	// 	&authc.Principal{
-	// 		Value:     user.Email,
-	// 		IsPrimary: true,
-	// 		Realm:     "inmemory",
-	// 	})
-	// authcInfo.Credential = []byte(user.Password)
-	// authcInfo.IsLocked = user.IsLocked
-	// authcInfo.IsExpired = user.IsExpried
+	authcInfo.Principals = append(authcInfo.Principals,
+		&authc.Principal{
+			Value:     user.ID.Hex(),
+			IsPrimary: true,
+			Realm:     Realm,
+		},
+		&authc.Principal{
+			Claim: "username",
+			Value: user.Username,
+			Realm: Realm,
+		})
+	authcInfo.Principals = append(authcInfo.Principals, principals...)
+	authcInfo.IsLocked = user.IsLocked
+	authcInfo.IsExpired = user.IsExpired
 
 	return authcInfo, nil
 }
 
+// userFromToken validates a jwt token and looks up the user it was issued
+// for, the claims of the token are returned as well
+func userFromToken(signed string) (*storageModel.User, jwt.MapClaims, error) {
+	claims, err := ParseToken(signed, AccessToken, MFAToken)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, ok := claims["uuid"].(string)
+	if !ok {
+		return nil, nil, authc.ErrAuthenticationFailed
+	}
+
+	user, err := models.FindUserWithID(id)
+	return user, claims, err
+}
+
+// userFromBasic looks up the user from basic credentials and verifies the
+// password, failed attempts are limited for the client IP as well when it is
+// known
+func userFromBasic(encoded, ip string) (*storageModel.User, error) {
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	creds := strings.SplitN(string(decoded), ":", 2)
+	if len(creds) != 2 || creds[0] == "" {
+		return nil, authc.ErrAuthenticationFailed
+	}
+
+	var keys []string
+	if ip != "" {
+		keys = append(keys, IPKey(ip))
+	}
+	user, err := Login(creds[0], creds[1], keys...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Basic credentials can't carry a second factor
+	if user.TOTPEnabled || MFARequired(user) {
+		return nil, errors.New("basic authentication is not allowed with two-factor authentication")
+	}
+	return user, nil
+}
+
+// ClientIPMiddleware sets the ClientIPHeader of the request to its client IP,
+// replacing any value sent by the client
+func ClientIPMiddleware(ctx *aah.Context, m *aah.Middleware) {
+	ctx.Req.Header.Set(ClientIPHeader, RequestIP(ctx))
+	m.Next(ctx)
+}
+
+// BearerToken returns the token of a `Bearer` authorization header
+func BearerToken(header string) string {
+	scheme, value := splitAuthorization(header)
+	if !strings.EqualFold(scheme, "bearer") {
+		return ""
+	}
+	return value
+}
+
+// splitAuthorization splits an `Authorization` header value into scheme and value
+func splitAuthorization(header string) (scheme, value string) {
+	parts := strings.SplitN(strings.TrimSpace(header), " ", 2)
+	if len(parts) != 2 {
+		return "", ""
+	}
+	return parts[0], strings.TrimSpace(parts[1])
+}
+
 func postAuthEvent(e *aah.Event) {
 	ctx := e.Data.(*aah.Context)
 
@@ -2,22 +2,43 @@ package security
 
 import (
 	"aahframework.org/config.v0"
+	"aahframework.org/log.v0"
 	"aahframework.org/security.v0/authc"
 	"aahframework.org/security.v0/authz"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
 )
 
 var _ authz.Authorizer = (*AuthorizationProvider)(nil)
 
+const (
+	// SelfPermission is granted to every fully authenticated user, for
+	// managing their own account
+	SelfPermission = "user:self"
+
+	// MFAEnrollPermission is granted to every user, and is the only permission
+	// of a login pending two-factor authentication
+	MFAEnrollPermission = "mfa:enroll"
+)
+
 // AuthorizationProvider struct implements `authz.Authorizer` interface.
 type AuthorizationProvider struct {
+	// rolePermissions holds the permissions granted by each role
+	rolePermissions map[string][]string
 }
 
 // Init method initializes the AuthorizationProvider, this method gets called
 // during server start up.
 func (a *AuthorizationProvider) Init(cfg *config.Config) error {
+	a.rolePermissions = make(map[string][]string)
+	for _, role := range cfg.KeysByPath("security.roles") {
+		permissions, _ := cfg.StringList("security.roles." + role)
+		a.rolePermissions[role] = permissions
+	}
 
-	// NOTE: Init is called on application startup
-
+	authorizer = a
 	return nil
 }
 
@@ -26,18 +47,63 @@ func (a *AuthorizationProvider) Init(cfg *config.Config) error {
 // GetAuthorizationInfo method gets called after authentication is successful
 // to get Subject's (aka User) access control information such as roles and permissions.
 func (a *AuthorizationProvider) GetAuthorizationInfo(authcInfo *authc.AuthenticationInfo) *authz.AuthorizationInfo {
-	//‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾‾
-	// This code snippet provided as a reference
-	//
-	// Call your appropriate datasource here (such as DB, API, etc)
-	// to get the subject (aka user) authorization details (roles, permissions)
-	//__________________________________________________________________________
+	user, err := models.FindUserWithID(authcInfo.PrimaryPrincipal().Value)
+	if err != nil {
+		// Subject without any roles or permissions, every protected route is denied
+		log.Errorf("unable to load authorization info: %v", err)
+		return authz.NewAuthorizationInfo()
+	}
+
+	// Logins pending two-factor authentication may only enroll
+	if PrincipalClaim(authcInfo, MFAPendingClaim) != "" {
+		authzInfo := authz.NewAuthorizationInfo()
+		authzInfo.AddPermissionString(MFAEnrollPermission)
+		return authzInfo
+	}
+
+	authzInfo := a.userAuthorizationInfo(user)
+
+	// Requests authenticated with an api key only get the permissions of the
+	// key, limited to what the user itself is permitted
+	if id := PrincipalClaim(authcInfo, APIKeyClaim); id != "" {
+		return apiKeyAuthorizationInfo(id, authzInfo)
+	}
+	if name := PrincipalClaim(authcInfo, CertClaim); name != "" {
+		return certAuthorizationInfo(name, authzInfo)
+	}
 
-	// authorities := models.FindUserByEmail(authcInfo.PrimaryPrincipal().Value)
+	return authzInfo
+}
 
+// userAuthorizationInfo returns the roles of the user and the permissions
+// granted by those roles and the user itself
+func (a *AuthorizationProvider) userAuthorizationInfo(user *storageModel.User) *authz.AuthorizationInfo {
 	authzInfo := authz.NewAuthorizationInfo()
-	// authzInfo.AddRole(authorities.Roles...)
-	// authzInfo.AddPermissionString(authorities.Permissions...)
+	authzInfo.AddRole(user.Roles...)
+	for _, role := range user.Roles {
+		authzInfo.AddPermissionString(a.rolePermissions[role]...)
+	}
+	authzInfo.AddPermissionString(user.Permissions...)
+	authzInfo.AddPermissionString(SelfPermission, MFAEnrollPermission)
+	return authzInfo
+}
+
+func apiKeyAuthorizationInfo(id string, userInfo *authz.AuthorizationInfo) *authz.AuthorizationInfo {
+	authzInfo := authz.NewAuthorizationInfo()
+	if !bson.IsObjectIdHex(id) {
+		return authzInfo
+	}
+
+	keys, err := models.FindAPIKeys(utils.Filter{"_id": bson.ObjectIdHex(id)})
+	if err != nil || len(keys) <= 0 {
+		log.Errorf("unable to load api key '%s': %v", id, err)
+		return authzInfo
+	}
 
+	for _, permission := range keys[0].Permissions {
+		if userInfo.IsPermitted(permission) {
+			authzInfo.AddPermissionString(permission)
+		}
+	}
 	return authzInfo
 }
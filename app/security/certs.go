@@ -0,0 +1,169 @@
+package security
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/config.v0"
+	"aahframework.org/log.v0"
+	"aahframework.org/security.v0/authz"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+)
+
+const (
+	// CertClaim is the principal claim holding the name of the certificate
+	// principal a request authenticated as
+	CertClaim = "cert"
+
+	// CertSubjectClaim is the principal claim holding the certificate
+	// subject, common name or SAN, that matched the certificate principal
+	CertSubjectClaim = "cert_subject"
+
+	// certScheme is the authorization scheme set by ClientCertMiddleware for
+	// requests with a verified client certificate
+	certScheme = "Cert"
+)
+
+// CertPrincipal maps client certificates to a keiwi user, requests
+// authenticated with a certificate only get the permissions of the principal
+type CertPrincipal struct {
+	Name        string
+	Subjects    []string // Patterns matched against the common name and SANs
+	User        string   // Username of the user the certificates act as
+	Permissions []string
+}
+
+var certPrincipals []*CertPrincipal
+
+// loadClientCertConfig reads the principals of `security.client_certs`
+func loadClientCertConfig(cfg *config.Config) error {
+	names := cfg.KeysByPath("security.client_certs.principals")
+	sort.Strings(names)
+
+	certPrincipals = nil
+	for _, name := range names {
+		prefix := "security.client_certs.principals." + name + "."
+		p := &CertPrincipal{Name: name, User: cfg.StringDefault(prefix+"user", "")}
+		p.Subjects, _ = cfg.StringList(prefix + "subjects")
+		p.Permissions, _ = cfg.StringList(prefix + "permissions")
+		certPrincipals = append(certPrincipals, p)
+	}
+	return nil
+}
+
+// ConfigureClientCerts makes the server ask for client certificates signed
+// by the CA of `security.client_certs.ca_file`. Certificates are optional, so
+// clients using tokens are unaffected. It only applies with `server.ssl`.
+func ConfigureClientCerts(_ *aah.Event) {
+	cfg := aah.AppConfig()
+	if !cfg.BoolDefault("security.client_certs.enable", false) {
+		return
+	}
+
+	data, err := ioutil.ReadFile(cfg.StringDefault("security.client_certs.ca_file", ""))
+	if err != nil {
+		log.Fatalf("client certs: %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		log.Fatal("client certs: no certificates found in ca_file")
+	}
+
+	aah.SetTLSConfig(&tls.Config{
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		ClientCAs:  pool,
+	})
+}
+
+// ClientCertMiddleware sets the `Cert` authorization of requests with a
+// verified client certificate matching a principal, unless the request has
+// another authorization. A `Cert` authorization sent by the client itself
+// is always removed.
+func ClientCertMiddleware(ctx *aah.Context, m *aah.Middleware) {
+	if scheme, _ := splitAuthorization(ctx.Req.Header.Get("Authorization")); strings.EqualFold(scheme, certScheme) {
+		ctx.Req.Header.Del("Authorization")
+	}
+
+	if state := ctx.Req.Unwrap().TLS; state != nil && len(state.VerifiedChains) > 0 && ctx.Req.Header.Get("Authorization") == "" {
+		if p, subject := matchCertPrincipal(state.VerifiedChains[0][0]); p != nil {
+			ctx.Req.Header.Set("Authorization", certScheme+" "+p.Name+" "+subject)
+		}
+	}
+
+	m.Next(ctx)
+}
+
+// matchCertPrincipal returns the first principal matching the common name or
+// a SAN of the certificate, and the subject that matched
+func matchCertPrincipal(cert *x509.Certificate) (*CertPrincipal, string) {
+	subjects := []string{cert.Subject.CommonName}
+	subjects = append(subjects, cert.DNSNames...)
+	subjects = append(subjects, cert.EmailAddresses...)
+	for _, u := range cert.URIs {
+		subjects = append(subjects, u.String())
+	}
+
+	for _, p := range certPrincipals {
+		for _, pattern := range p.Subjects {
+			for _, subject := range subjects {
+				if subject == "" {
+					continue
+				}
+				if ok, _ := path.Match(pattern, subject); ok {
+					return p, subject
+				}
+			}
+		}
+	}
+	return nil, ""
+}
+
+// userFromCert looks up the user of the certificate principal set by
+// ClientCertMiddleware, the value is the principal name and the subject
+func userFromCert(value string) (*storageModel.User, *CertPrincipal, string, error) {
+	parts := strings.SplitN(value, " ", 2)
+	if len(parts) != 2 {
+		return nil, nil, "", ErrInvalidLogin
+	}
+
+	p := findCertPrincipal(parts[0])
+	if p == nil {
+		return nil, nil, "", ErrInvalidLogin
+	}
+
+	user, err := models.FindUser(utils.Filter{"username": p.User})
+	return user, p, parts[1], err
+}
+
+func findCertPrincipal(name string) *CertPrincipal {
+	for _, p := range certPrincipals {
+		if p.Name == name {
+			return p
+		}
+	}
+	return nil
+}
+
+// certAuthorizationInfo returns the permissions of the certificate principal
+// that the user itself is permitted
+func certAuthorizationInfo(name string, userInfo *authz.AuthorizationInfo) *authz.AuthorizationInfo {
+	authzInfo := authz.NewAuthorizationInfo()
+	p := findCertPrincipal(name)
+	if p == nil {
+		return authzInfo
+	}
+
+	for _, permission := range p.Permissions {
+		if userInfo.IsPermitted(permission) {
+			authzInfo.AddPermissionString(permission)
+		}
+	}
+	return authzInfo
+}
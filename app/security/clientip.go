@@ -0,0 +1,88 @@
+package security
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"aahframework.org/aah.v0"
+	"aahframework.org/config.v0"
+)
+
+// trustedProxies are the networks of the proxies whose `X-Forwarded-For` is
+// trusted, from `security.trusted_proxies`
+var trustedProxies []*net.IPNet
+
+// loadProxyConfig reads `security.trusted_proxies`, a list of IPs and CIDR
+// networks
+func loadProxyConfig(cfg *config.Config) error {
+	proxies, _ := cfg.StringList("security.trusted_proxies")
+
+	trustedProxies = nil
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return fmt.Errorf("trusted proxy '%s' is not an IP or CIDR", proxy)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			trustedProxies = append(trustedProxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return fmt.Errorf("trusted proxy '%s' is not an IP or CIDR", proxy)
+		}
+		trustedProxies = append(trustedProxies, network)
+	}
+	return nil
+}
+
+// ClientIP returns the IP of the client from the address of the connection
+// and the `X-Forwarded-For` values of the request. The hops are followed from
+// the last one only while they were added by a trusted proxy, so clients
+// can't choose their IP by sending the header themselves.
+func ClientIP(remoteAddr string, forwarded ...string) string {
+	ip := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		ip = host
+	}
+
+	var hops []string
+	for _, value := range forwarded {
+		for _, hop := range strings.Split(value, ",") {
+			if hop = strings.TrimSpace(hop); hop != "" {
+				hops = append(hops, hop)
+			}
+		}
+	}
+	for i := len(hops) - 1; i >= 0 && isTrustedProxy(ip); i-- {
+		if net.ParseIP(hops[i]) == nil {
+			break
+		}
+		ip = hops[i]
+	}
+	return ip
+}
+
+// RequestIP returns the client IP of the request, see ClientIP
+func RequestIP(ctx *aah.Context) string {
+	return ClientIP(ctx.Req.Unwrap().RemoteAddr, ctx.Req.Header["X-Forwarded-For"]...)
+}
+
+func isTrustedProxy(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package security
+
+import (
+	"net"
+	"testing"
+)
+
+func TestClientIP(t *testing.T) {
+	defer func(proxies []*net.IPNet) { trustedProxies = proxies }(trustedProxies)
+	_, network, _ := net.ParseCIDR("10.0.0.0/8")
+	trustedProxies = []*net.IPNet{network}
+
+	tests := []struct {
+		remoteAddr string
+		forwarded  []string
+		ip         string
+	}{
+		{"203.0.113.7:51234", nil, "203.0.113.7"},
+		{"[2001:db8::1]:443", nil, "2001:db8::1"},
+		{"203.0.113.7", nil, "203.0.113.7"},
+		// Forwarded headers of untrusted clients are ignored
+		{"203.0.113.7:51234", []string{"198.51.100.1"}, "203.0.113.7"},
+		{"203.0.113.7:51234", []string{"10.0.0.2"}, "203.0.113.7"},
+		{"10.0.0.1:80", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"10.0.0.1:80", []string{"198.51.100.1, 10.0.0.2"}, "198.51.100.1"},
+		{"10.0.0.1:80", []string{"198.51.100.1", "10.0.0.2"}, "198.51.100.1"},
+		// A client spoofing a hop before the trusted proxies isn't followed
+		{"10.0.0.1:80", []string{"192.0.2.9, 198.51.100.1"}, "198.51.100.1"},
+		{"10.0.0.1:80", []string{"10.0.0.3, 10.0.0.2"}, "10.0.0.3"},
+		{"10.0.0.1:80", []string{"unknown"}, "10.0.0.1"},
+		{"10.0.0.1:80", []string{"198.51.100.1, unknown"}, "10.0.0.1"},
+		{"10.0.0.1:80", []string{""}, "10.0.0.1"},
+	}
+
+	for _, test := range tests {
+		if ip := ClientIP(test.remoteAddr, test.forwarded...); ip != test.ip {
+			t.Errorf("ClientIP(%s, %q) = %s, want %s", test.remoteAddr, test.forwarded, ip, test.ip)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package security
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+var (
+	// ErrIdentityDenied is returned when none of the groups of an external
+	// identity grant a role
+	ErrIdentityDenied = errors.New("the account is not allowed to log in")
+
+	// ErrIdentityUserExists is returned when a new external identity would
+	// take the username or email of an existing local user
+	ErrIdentityUserExists = errors.New("a user with this username or email already exists")
+)
+
+// ExternalIdentity is an account at an external identity provider, such as
+// an OpenID Connect provider or a directory
+type ExternalIdentity struct {
+	Provider      string
+	Subject       string
+	Username      string
+	Email         string
+	EmailVerified bool
+
+	// Roles granted by the groups of the account
+	Roles []string
+}
+
+// externalUser returns the local user of the identity, creating it on the
+// first login. When the groups grant roles, they replace the roles of the
+// user on every login, so they are managed at the identity provider.
+func externalUser(identity ExternalIdentity, defaultRole string) (*storageModel.User, error) {
+	roles := identity.Roles
+
+	linked, err := models.FindIdentity(identity.Provider, identity.Subject)
+	if err == models.ErrUserNotFound {
+		if len(roles) <= 0 {
+			if defaultRole == "" {
+				return nil, ErrIdentityDenied
+			}
+			roles = []string{defaultRole}
+		}
+		return createExternalUser(identity, roles)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := models.FindUserWithID(linked.UserID.Hex())
+	if err != nil {
+		return nil, err
+	}
+	if len(roles) <= 0 && defaultRole == "" {
+		return nil, ErrIdentityDenied
+	}
+	if len(roles) > 0 && !equalStrings(user.Roles, roles) {
+		if err := models.UpdateUser(user.ID, bson.M{"roles": roles}); err != nil {
+			return nil, err
+		}
+		user.Roles = roles
+	}
+	return user, nil
+}
+
+// createExternalUser creates the local user of a new identity and links them
+func createExternalUser(identity ExternalIdentity, roles []string) (*storageModel.User, error) {
+	if identity.Username == "" {
+		identity.Username = identity.Subject
+	}
+
+	filter := []interface{}{bson.M{"username": identity.Username}}
+	if identity.Email != "" {
+		filter = append(filter, bson.M{"email": identity.Email})
+	}
+	has, err := models.HasUser(utils.Filter{"$or": filter})
+	if err != nil {
+		return nil, err
+	}
+	if has {
+		return nil, ErrIdentityUserExists
+	}
+
+	// Users of external identities have no password, so they can only log
+	// in through the identity provider
+	user := &storageModel.User{
+		ID:            bson.NewObjectId(),
+		Username:      identity.Username,
+		Email:         identity.Email,
+		EmailVerified: identity.EmailVerified,
+		Roles:         roles,
+	}
+	if err := models.CreateUser(user); err != nil {
+		return nil, err
+	}
+
+	if err := models.CreateIdentity(identity.Provider, identity.Subject, user.ID); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// mapGroups returns the roles of which any group is in groups
+func mapGroups(mapping map[string][]string, groups []string) []string {
+	var roles []string
+	for role, granting := range mapping {
+		for _, g := range granting {
+			if containsString(groups, g) {
+				roles = append(roles, role)
+				break
+			}
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// equalStrings checks if both lists hold the same strings, ignoring the order
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		if !containsString(b, s) {
+			return false
+		}
+	}
+	return true
+}
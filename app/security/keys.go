@@ -0,0 +1,239 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"strings"
+
+	"aahframework.org/config.v0"
+	"github.com/dgrijalva/jwt-go"
+)
+
+// Keys holds the keys used to sign and verify tokens, loaded on startup
+var Keys = &KeySet{keys: make(map[string]*Key)}
+
+// Key is a token signing key identified by its key id (kid)
+type Key struct {
+	ID     string
+	Method jwt.SigningMethod
+
+	// signKey is nil for keys that are only kept around to verify tokens
+	// issued before a rotation
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+// KeySet is the set of keys that are valid for verifying tokens
+type KeySet struct {
+	signing string
+	keys    map[string]*Key
+}
+
+// JWK is a public key in the JSON Web Key format (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// LoadKeys reads the keys from `security.jwt` in the application config
+func LoadKeys(cfg *config.Config) error {
+	keys := make(map[string]*Key)
+	for _, kid := range cfg.KeysByPath("security.jwt.keys") {
+		key, err := loadKey(cfg, kid)
+		if err != nil {
+			return fmt.Errorf("jwt key '%s': %v", kid, err)
+		}
+		keys[kid] = key
+	}
+
+	signing := cfg.StringDefault("security.jwt.signing_key", "")
+	key, ok := keys[signing]
+	if !ok {
+		return fmt.Errorf("jwt signing key '%s' is not configured", signing)
+	}
+	if key.signKey == nil {
+		return fmt.Errorf("jwt signing key '%s' has no private key", signing)
+	}
+
+	Keys.signing = signing
+	Keys.keys = keys
+	return nil
+}
+
+func loadKey(cfg *config.Config, kid string) (*Key, error) {
+	prefix := "security.jwt.keys." + kid + "."
+	alg := cfg.StringDefault(prefix+"algorithm", "HS256")
+
+	key := &Key{ID: kid, Method: jwt.GetSigningMethod(alg)}
+	switch key.Method.(type) {
+	case *jwt.SigningMethodHMAC:
+		secret := cfg.StringDefault(prefix+"secret", "")
+		if file := cfg.StringDefault(prefix+"file", ""); file != "" {
+			data, err := ioutil.ReadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			secret = string(data)
+		}
+		if len(secret) < 32 {
+			return nil, errors.New("secret must be at least 32 characters")
+		}
+		key.signKey = []byte(secret)
+		key.verifyKey = key.signKey
+	case *jwt.SigningMethodRSA:
+		data, err := readKeyFile(cfg, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.signKey = private
+			key.verifyKey = &private.PublicKey
+		} else if key.verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+			return nil, err
+		}
+	case *jwt.SigningMethodECDSA:
+		data, err := readKeyFile(cfg, prefix)
+		if err != nil {
+			return nil, err
+		}
+		if private, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+			key.signKey = private
+			key.verifyKey = &private.PublicKey
+		} else if key.verifyKey, err = jwt.ParseECPublicKeyFromPEM(data); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported algorithm '%s'", alg)
+	}
+
+	return key, nil
+}
+
+func readKeyFile(cfg *config.Config, prefix string) ([]byte, error) {
+	file := cfg.StringDefault(prefix+"file", "")
+	if file == "" {
+		return nil, errors.New("file is missing")
+	}
+	return ioutil.ReadFile(file)
+}
+
+// Sign signs the token with the active signing key and sets its kid header
+func (k *KeySet) Sign(token *jwt.Token) (string, error) {
+	key, ok := k.keys[k.signing]
+	if !ok {
+		return "", errors.New("no signing key loaded")
+	}
+
+	token.Method = key.Method
+	token.Header["alg"] = key.Method.Alg()
+	token.Header["kid"] = key.ID
+	return token.SignedString(key.signKey)
+}
+
+// Verify is a `jwt.Keyfunc` resolving the verification key from the kid header
+func (k *KeySet) Verify(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := k.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	if token.Method.Alg() != key.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return key.verifyKey, nil
+}
+
+// JWKS returns the public keys of the set, symmetric keys are never published
+func (k *KeySet) JWKS() JWKS {
+	set := JWKS{Keys: []JWK{}}
+	for _, key := range k.keys {
+		jwk := JWK{Kid: key.ID, Use: "sig", Alg: key.Method.Alg()}
+		switch pub := key.verifyKey.(type) {
+		case *rsa.PublicKey:
+			jwk.Kty = "RSA"
+			jwk.N = encodeBase64(pub.N.Bytes())
+			jwk.E = encodeBase64(big.NewInt(int64(pub.E)).Bytes())
+		case *ecdsa.PublicKey:
+			size := (pub.Curve.Params().BitSize + 7) / 8
+			jwk.Kty = "EC"
+			jwk.Crv = pub.Curve.Params().Name
+			jwk.X = encodeBase64(padBytes(pub.X.Bytes(), size))
+			jwk.Y = encodeBase64(padBytes(pub.Y.Bytes(), size))
+		default:
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+func encodeBase64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func padBytes(b []byte, size int) []byte {
+	if len(b) >= size {
+		return b
+	}
+	return append(make([]byte, size-len(b)), b...)
+}
+
+// PublicKey returns the RSA or ECDSA public key of the JWK
+func (j JWK) PublicKey() (interface{}, error) {
+	switch j.Kty {
+	case "RSA":
+		n, err := decodeBase64(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeBase64(j.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", j.Crv)
+		}
+		x, err := decodeBase64(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeBase64(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}, nil
+	}
+	return nil, fmt.Errorf("unsupported key type '%s'", j.Kty)
+}
+
+func decodeBase64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,199 @@
+package security
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+func testKeys(t *testing.T) (hmacKey, rsaKey, ecKey *Key) {
+	t.Helper()
+
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	hmacKey = &Key{ID: "hmac", Method: jwt.SigningMethodHS256, signKey: secret, verifyKey: secret}
+
+	rsaPrivate, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+	rsaKey = &Key{ID: "rsa", Method: jwt.SigningMethodRS256, signKey: rsaPrivate, verifyKey: &rsaPrivate.PublicKey}
+
+	ecPrivate, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("error generating ecdsa key: %v", err)
+	}
+	ecKey = &Key{ID: "ec", Method: jwt.SigningMethodES256, signKey: ecPrivate, verifyKey: &ecPrivate.PublicKey}
+	return hmacKey, rsaKey, ecKey
+}
+
+// verifyOnly returns a copy of the key without its private key, as it is
+// kept after a rotation
+func verifyOnly(key *Key) *Key {
+	return &Key{ID: key.ID, Method: key.Method, verifyKey: key.verifyKey}
+}
+
+func sign(t *testing.T, set *KeySet) string {
+	t.Helper()
+	signed, err := set.Sign(jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "user"}))
+	if err != nil {
+		t.Fatalf("error signing with key '%s': %v", set.signing, err)
+	}
+	return signed
+}
+
+func TestKeyRotation(t *testing.T) {
+	hmacKey, rsaKey, ecKey := testKeys(t)
+
+	// Tokens signed before each rotation, and the kid header they carry
+	before := &KeySet{signing: "hmac", keys: map[string]*Key{"hmac": hmacKey}}
+	hmacToken := sign(t, before)
+	rotated := &KeySet{signing: "rsa", keys: map[string]*Key{"hmac": verifyOnly(hmacKey), "rsa": rsaKey}}
+	rsaToken := sign(t, rotated)
+	again := &KeySet{signing: "ec", keys: map[string]*Key{"rsa": verifyOnly(rsaKey), "ec": ecKey}}
+	ecToken := sign(t, again)
+
+	tests := []struct {
+		name  string
+		set   *KeySet
+		token string
+		kid   string
+		valid bool
+	}{
+		{"signed with the signing key", before, hmacToken, "hmac", true},
+		{"signed before the rotation", rotated, hmacToken, "hmac", true},
+		{"signed after the rotation", rotated, rsaToken, "rsa", true},
+		{"signed by a later key", before, rsaToken, "rsa", false},
+		{"signed by a removed key", again, hmacToken, "hmac", false},
+		{"signed after the second rotation", again, ecToken, "ec", true},
+		{"signed by a verify only key", again, rsaToken, "rsa", true},
+	}
+
+	for _, test := range tests {
+		token, err := jwt.Parse(test.token, test.set.Verify)
+		if token == nil {
+			t.Errorf("%s: the token can't be parsed: %v", test.name, err)
+			continue
+		}
+		if kid := token.Header["kid"]; kid != test.kid {
+			t.Errorf("%s: kid = %v, want %s", test.name, kid, test.kid)
+		}
+		if valid := err == nil && token.Valid; valid != test.valid {
+			t.Errorf("%s: valid = %v (%v), want %v", test.name, valid, err, test.valid)
+		}
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	hmacKey, rsaKey, _ := testKeys(t)
+	set := &KeySet{signing: "rsa", keys: map[string]*Key{"hmac": hmacKey, "rsa": rsaKey}}
+
+	tests := []struct {
+		name   string
+		method jwt.SigningMethod
+		kid    interface{}
+		key    interface{}
+	}{
+		{"missing kid", jwt.SigningMethodHS256, nil, hmacKey.signKey},
+		{"unknown kid", jwt.SigningMethodHS256, "other", hmacKey.signKey},
+		{"kid of a number", jwt.SigningMethodHS256, 1, hmacKey.signKey},
+		{"other algorithm than the key", jwt.SigningMethodHS384, "hmac", hmacKey.signKey},
+		// The public RSA key used as an HMAC secret must not verify
+		{"algorithm confusion", jwt.SigningMethodHS256, "rsa", []byte("public key")},
+	}
+
+	for _, test := range tests {
+		token := jwt.NewWithClaims(test.method, jwt.MapClaims{"sub": "user"})
+		if test.kid != nil {
+			token.Header["kid"] = test.kid
+		}
+		signed, err := token.SignedString(test.key)
+		if err != nil {
+			t.Fatalf("%s: error signing: %v", test.name, err)
+		}
+
+		if _, err := jwt.Parse(signed, set.Verify); err == nil {
+			t.Errorf("%s: the token was accepted", test.name)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	hmacKey, rsaKey, ecKey := testKeys(t)
+	set := &KeySet{signing: "ec", keys: map[string]*Key{
+		"hmac": hmacKey,
+		"rsa":  verifyOnly(rsaKey),
+		"ec":   ecKey,
+	}}
+
+	jwks := set.JWKS()
+	sort.Slice(jwks.Keys, func(i, j int) bool { return jwks.Keys[i].Kid < jwks.Keys[j].Kid })
+
+	var kids []string
+	for _, jwk := range jwks.Keys {
+		kids = append(kids, jwk.Kid)
+	}
+	if !reflect.DeepEqual(kids, []string{"ec", "rsa"}) {
+		t.Fatalf("JWKS has the keys %v, want the public keys [ec rsa]", kids)
+	}
+
+	tests := []struct {
+		jwk JWK
+		key *Key
+		kty string
+		alg string
+	}{
+		{jwks.Keys[0], ecKey, "EC", "ES256"},
+		{jwks.Keys[1], rsaKey, "RSA", "RS256"},
+	}
+	for _, test := range tests {
+		if test.jwk.Kty != test.kty || test.jwk.Alg != test.alg || test.jwk.Use != "sig" {
+			t.Errorf("JWK %s = %+v, want kty %s, alg %s and use sig", test.key.ID, test.jwk, test.kty, test.alg)
+		}
+
+		public, err := test.jwk.PublicKey()
+		if err != nil {
+			t.Errorf("JWK %s has no public key: %v", test.key.ID, err)
+			continue
+		}
+		if !reflect.DeepEqual(public, test.key.verifyKey) {
+			t.Errorf("JWK %s doesn't round-trip its public key", test.key.ID)
+		}
+	}
+
+	// EC coordinates are padded to the size of the curve
+	for _, coordinate := range []string{jwks.Keys[0].X, jwks.Keys[0].Y} {
+		if b, err := base64.RawURLEncoding.DecodeString(coordinate); err != nil || len(b) != 32 {
+			t.Errorf("P-256 coordinate %s isn't 32 bytes", coordinate)
+		}
+	}
+	if jwks.Keys[1].E != "AQAB" {
+		t.Errorf("RSA exponent = %s, want AQAB", jwks.Keys[1].E)
+	}
+
+	empty := (&KeySet{keys: map[string]*Key{"hmac": hmacKey}}).JWKS()
+	if empty.Keys == nil || len(empty.Keys) != 0 {
+		t.Errorf("JWKS of symmetric keys = %+v, want an empty set", empty)
+	}
+}
+
+func TestJWKPublicKeyRejects(t *testing.T) {
+	tests := []JWK{
+		{Kty: "oct"},
+		{Kty: "EC", Crv: "P-192", X: "AA", Y: "AA"},
+		{Kty: "EC", Crv: "P-256", X: "!", Y: "AA"},
+		{Kty: "RSA", N: "!", E: "AQAB"},
+	}
+
+	for _, jwk := range tests {
+		if key, err := jwk.PublicKey(); err == nil {
+			t.Errorf("PublicKey of %+v = %v, want an error", jwk, key)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package security
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"aahframework.org/config.v0"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/ldap.v2"
+)
+
+// LDAPBackend authenticates directory users by searching for their entry with
+// a service account and binding as it with their password
+type LDAPBackend struct {
+	URL                string
+	StartTLS           bool
+	InsecureSkipVerify bool
+	Timeout            time.Duration
+
+	BindDN       string
+	BindPassword string
+
+	UserBase   string
+	UserFilter string // `{login}` is replaced with the escaped login
+
+	UsernameAttribute string
+	EmailAttribute    string
+
+	// GroupBase and GroupFilter look up the groups of the user, `{dn}` is
+	// replaced with the escaped DN of the user. When GroupBase is empty the
+	// `memberOf` attribute of the user is used, as on Active Directory.
+	GroupBase   string
+	GroupFilter string
+
+	// Groups maps each keiwi role to the group DNs granting it
+	Groups map[string][]string
+	// DefaultRole is given to new users none of whose groups grant a role
+	DefaultRole string
+
+	// connect opens a connection to the directory, b.dial when nil
+	connect func() (ldapDirectory, error)
+}
+
+// ldapDirectory is the connection to the directory the backend uses,
+// implemented by `*ldap.Conn`
+type ldapDirectory interface {
+	Bind(username, password string) error
+	Search(request *ldap.SearchRequest) (*ldap.SearchResult, error)
+	Close()
+}
+
+// loadLDAPBackend reads `security.ldap` from the application config
+func loadLDAPBackend(cfg *config.Config) (*LDAPBackend, error) {
+	b := &LDAPBackend{
+		URL:                cfg.StringDefault("security.ldap.url", ""),
+		StartTLS:           cfg.BoolDefault("security.ldap.start_tls", false),
+		InsecureSkipVerify: cfg.BoolDefault("security.ldap.insecure_skip_verify", false),
+		BindDN:             cfg.StringDefault("security.ldap.bind_dn", ""),
+		BindPassword:       cfg.StringDefault("security.ldap.bind_password", ""),
+		UserBase:           cfg.StringDefault("security.ldap.user_base", ""),
+		UserFilter:         cfg.StringDefault("security.ldap.user_filter", "(&(objectClass=person)(uid={login}))"),
+		UsernameAttribute:  cfg.StringDefault("security.ldap.username_attribute", "uid"),
+		EmailAttribute:     cfg.StringDefault("security.ldap.email_attribute", "mail"),
+		GroupBase:          cfg.StringDefault("security.ldap.group_base", ""),
+		GroupFilter:        cfg.StringDefault("security.ldap.group_filter", "(&(objectClass=groupOfNames)(member={dn}))"),
+		Groups:             make(map[string][]string),
+		DefaultRole:        cfg.StringDefault("security.ldap.default_role", ""),
+	}
+	if b.URL == "" || b.UserBase == "" {
+		return nil, errors.New("ldap: url and user_base are required")
+	}
+
+	timeout, err := time.ParseDuration(cfg.StringDefault("security.ldap.timeout", "5s"))
+	if err != nil {
+		return nil, fmt.Errorf("ldap: timeout: %v", err)
+	}
+	b.Timeout = timeout
+
+	// DNs are compared case-insensitively
+	for _, role := range cfg.KeysByPath("security.ldap.groups") {
+		dns, _ := cfg.StringList("security.ldap.groups." + role)
+		for _, dn := range dns {
+			b.Groups[role] = append(b.Groups[role], strings.ToLower(dn))
+		}
+	}
+	return b, nil
+}
+
+// Authenticate is `LoginBackend` interface
+func (b *LDAPBackend) Authenticate(login, password string) (*storageModel.User, error) {
+	// An empty password would be an unauthenticated bind, which most
+	// servers accept for any DN
+	if login == "" || password == "" {
+		return nil, ErrInvalidLogin
+	}
+
+	connect := b.connect
+	if connect == nil {
+		connect = b.dial
+	}
+	conn, err := connect()
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	identity, err := b.identity(conn, login, password)
+	if err != nil {
+		return nil, err
+	}
+	return externalUser(identity, b.DefaultRole)
+}
+
+// identity verifies the password of the login and returns its identity with
+// the roles granted by its groups
+func (b *LDAPBackend) identity(conn ldapDirectory, login, password string) (ExternalIdentity, error) {
+	if err := b.bindService(conn); err != nil {
+		return ExternalIdentity{}, err
+	}
+
+	result, err := conn.Search(ldap.NewSearchRequest(
+		b.UserBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 2, int(b.Timeout.Seconds()), false,
+		b.userFilter(login),
+		[]string{b.UsernameAttribute, b.EmailAttribute, "memberOf"},
+		nil,
+	))
+	if err != nil && !ldap.IsErrorWithCode(err, ldap.LDAPResultSizeLimitExceeded) {
+		return ExternalIdentity{}, err
+	}
+	if result == nil || len(result.Entries) != 1 {
+		return ExternalIdentity{}, ErrInvalidLogin
+	}
+	entry := result.Entries[0]
+
+	if err := conn.Bind(entry.DN, password); err != nil {
+		if ldap.IsErrorWithCode(err, ldap.LDAPResultInvalidCredentials) {
+			return ExternalIdentity{}, ErrInvalidLogin
+		}
+		return ExternalIdentity{}, err
+	}
+
+	groups, err := b.groups(conn, entry)
+	if err != nil {
+		return ExternalIdentity{}, err
+	}
+
+	return ExternalIdentity{
+		Provider: b.URL,
+		Subject:  strings.ToLower(entry.DN),
+		Username: entry.GetAttributeValue(b.UsernameAttribute),
+		Email:    entry.GetAttributeValue(b.EmailAttribute),
+		Roles:    b.roles(groups),
+	}, nil
+}
+
+// userFilter returns the filter searching for the entry of the login
+func (b *LDAPBackend) userFilter(login string) string {
+	return strings.Replace(b.UserFilter, "{login}", ldap.EscapeFilter(login), -1)
+}
+
+// groupFilter returns the filter searching for the groups of the user DN
+func (b *LDAPBackend) groupFilter(dn string) string {
+	return strings.Replace(b.GroupFilter, "{dn}", ldap.EscapeFilter(dn), -1)
+}
+
+// roles returns the roles granted by the lower cased group DNs
+func (b *LDAPBackend) roles(groups []string) []string {
+	return mapGroups(b.Groups, groups)
+}
+
+// groups returns the lower cased DNs of the groups of the user
+func (b *LDAPBackend) groups(conn ldapDirectory, entry *ldap.Entry) ([]string, error) {
+	var dns []string
+	if b.GroupBase == "" {
+		dns = entry.GetAttributeValues("memberOf")
+	} else {
+		// The user may not be allowed to search for groups
+		if err := b.bindService(conn); err != nil {
+			return nil, err
+		}
+
+		result, err := conn.Search(ldap.NewSearchRequest(
+			b.GroupBase, ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, int(b.Timeout.Seconds()), false,
+			b.groupFilter(entry.DN),
+			[]string{"dn"},
+			nil,
+		))
+		if err != nil {
+			return nil, err
+		}
+		for _, group := range result.Entries {
+			dns = append(dns, group.DN)
+		}
+	}
+
+	for i := range dns {
+		dns[i] = strings.ToLower(dns[i])
+	}
+	return dns, nil
+}
+
+// dial connects to the directory, `ldaps://` urls use TLS from the start
+func (b *LDAPBackend) dial() (ldapDirectory, error) {
+	u, err := url.Parse(b.URL)
+	if err != nil {
+		return nil, err
+	}
+
+	host := u.Hostname()
+	tlsConfig := &tls.Config{ServerName: host, InsecureSkipVerify: b.InsecureSkipVerify}
+	dialer := &net.Dialer{Timeout: b.Timeout}
+
+	var conn *ldap.Conn
+	switch u.Scheme {
+	case "ldaps":
+		port := u.Port()
+		if port == "" {
+			port = "636"
+		}
+		c, err := tls.DialWithDialer(dialer, "tcp", net.JoinHostPort(host, port), tlsConfig)
+		if err != nil {
+			return nil, err
+		}
+		conn = ldap.NewConn(c, true)
+	case "ldap":
+		port := u.Port()
+		if port == "" {
+			port = "389"
+		}
+		c, err := dialer.Dial("tcp", net.JoinHostPort(host, port))
+		if err != nil {
+			return nil, err
+		}
+		conn = ldap.NewConn(c, false)
+	default:
+		return nil, fmt.Errorf("ldap: unsupported url scheme '%s'", u.Scheme)
+	}
+	conn.Start()
+	conn.SetTimeout(b.Timeout)
+
+	if b.StartTLS && u.Scheme == "ldap" {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// bindService binds as the service account, or stays anonymous if none is configured
+func (b *LDAPBackend) bindService(conn ldapDirectory) error {
+	if b.BindDN == "" {
+		return nil
+	}
+	return conn.Bind(b.BindDN, b.BindPassword)
+}
@@ -0,0 +1,192 @@
+package security
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/ldap.v2"
+)
+
+// fakeDirectory is a directory of users with passwords, the entries of
+// searches are looked up by their filter
+type fakeDirectory struct {
+	passwords map[string]string        // by DN
+	entries   map[string][]*ldap.Entry // by search filter
+	bound     string                   // DN of the last successful bind
+	searches  []string                 // filters searched, with the bound DN
+}
+
+func (d *fakeDirectory) Bind(username, password string) error {
+	if p, found := d.passwords[username]; !found || p != password {
+		return ldap.NewError(ldap.LDAPResultInvalidCredentials, nil)
+	}
+	d.bound = username
+	return nil
+}
+
+func (d *fakeDirectory) Search(request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	d.searches = append(d.searches, d.bound+" "+request.Filter)
+	return &ldap.SearchResult{Entries: d.entries[request.Filter]}, nil
+}
+
+func (d *fakeDirectory) Close() {}
+
+const (
+	testServiceDN = "cn=keiwi,ou=services,dc=example,dc=com"
+	testUserDN    = "uid=jdoe,ou=people,dc=example,dc=com"
+	testAdminsDN  = "cn=admins,ou=groups,dc=example,dc=com"
+	testOpsDN     = "cn=ops,ou=groups,dc=example,dc=com"
+)
+
+func testLDAPBackend() *LDAPBackend {
+	return &LDAPBackend{
+		URL:               "ldap://directory.example",
+		BindDN:            testServiceDN,
+		BindPassword:      "service",
+		UserBase:          "ou=people,dc=example,dc=com",
+		UserFilter:        "(&(objectClass=person)(uid={login}))",
+		UsernameAttribute: "uid",
+		EmailAttribute:    "mail",
+		GroupFilter:       "(&(objectClass=groupOfNames)(member={dn}))",
+		Groups: map[string][]string{
+			"admin":    {testAdminsDN},
+			"operator": {testAdminsDN, testOpsDN},
+		},
+	}
+}
+
+func testDirectory(b *LDAPBackend, memberOf ...string) *fakeDirectory {
+	user := ldap.NewEntry(testUserDN, map[string][]string{
+		"uid":      {"jdoe"},
+		"mail":     {"jdoe@example.com"},
+		"memberOf": memberOf,
+	})
+	return &fakeDirectory{
+		passwords: map[string]string{testServiceDN: "service", testUserDN: "secret"},
+		entries:   map[string][]*ldap.Entry{b.userFilter("jdoe"): {user}},
+	}
+}
+
+func TestLDAPFilters(t *testing.T) {
+	b := testLDAPBackend()
+
+	tests := []struct {
+		login, filter string
+	}{
+		{"jdoe", "(&(objectClass=person)(uid=jdoe))"},
+		{"*", `(&(objectClass=person)(uid=\2a))`},
+		{"jdoe)(uid=*", `(&(objectClass=person)(uid=jdoe\29\28uid=\2a))`},
+		{`a\b`, `(&(objectClass=person)(uid=a\5cb))`},
+		{"a\x00b", `(&(objectClass=person)(uid=a\00b))`},
+	}
+	for _, test := range tests {
+		if filter := b.userFilter(test.login); filter != test.filter {
+			t.Errorf("userFilter(%q) = %s, want %s", test.login, filter, test.filter)
+		}
+	}
+
+	dn := "cn=a (b)*,dc=example"
+	if filter, want := b.groupFilter(dn), `(&(objectClass=groupOfNames)(member=cn=a \28b\29\2a,dc=example))`; filter != want {
+		t.Errorf("groupFilter(%q) = %s, want %s", dn, filter, want)
+	}
+}
+
+func TestLDAPRoles(t *testing.T) {
+	b := testLDAPBackend()
+
+	tests := []struct {
+		groups []string
+		roles  []string
+	}{
+		{[]string{testAdminsDN}, []string{"admin", "operator"}},
+		{[]string{testOpsDN, "cn=other,dc=example,dc=com"}, []string{"operator"}},
+		{[]string{"cn=other,dc=example,dc=com"}, nil},
+		{nil, nil},
+	}
+	for _, test := range tests {
+		roles := b.roles(test.groups)
+		if len(roles) != len(test.roles) || len(roles) > 0 && !reflect.DeepEqual(roles, test.roles) {
+			t.Errorf("roles of %v = %v, want %v", test.groups, roles, test.roles)
+		}
+	}
+}
+
+func TestLDAPIdentity(t *testing.T) {
+	b := testLDAPBackend()
+
+	// memberOf DNs are compared case-insensitively
+	identity, err := b.identity(testDirectory(b, strings.ToUpper(testOpsDN)), "jdoe", "secret")
+	if err != nil {
+		t.Fatalf("identity failed: %v", err)
+	}
+	want := ExternalIdentity{
+		Provider: b.URL,
+		Subject:  testUserDN,
+		Username: "jdoe",
+		Email:    "jdoe@example.com",
+		Roles:    []string{"operator"},
+	}
+	if !reflect.DeepEqual(identity, want) {
+		t.Errorf("identity = %+v, want %+v", identity, want)
+	}
+
+	// Groups are searched as the service account
+	b.GroupBase = "ou=groups,dc=example,dc=com"
+	dir := testDirectory(b)
+	dir.entries[b.groupFilter(testUserDN)] = []*ldap.Entry{ldap.NewEntry(testAdminsDN, nil)}
+	identity, err = b.identity(dir, "jdoe", "secret")
+	if err != nil {
+		t.Fatalf("identity with group search failed: %v", err)
+	}
+	if !reflect.DeepEqual(identity.Roles, []string{"admin", "operator"}) {
+		t.Errorf("roles from the group search = %v, want [admin operator]", identity.Roles)
+	}
+	if last := dir.searches[len(dir.searches)-1]; last != testServiceDN+" "+b.groupFilter(testUserDN) {
+		t.Errorf("groups were searched with %q, want as the service account", last)
+	}
+}
+
+func TestLDAPIdentityRejects(t *testing.T) {
+	b := testLDAPBackend()
+	other := ldap.NewEntry("uid=other,ou=people,dc=example,dc=com", nil)
+
+	tests := []struct {
+		name            string
+		login, password string
+		setup           func(dir *fakeDirectory)
+	}{
+		{"wrong password", "jdoe", "wrong", nil},
+		{"unknown user", "nobody", "secret", nil},
+		{"wildcard login", "*", "secret", nil},
+		{"ambiguous login", "jdoe", "secret", func(dir *fakeDirectory) {
+			filter := b.userFilter("jdoe")
+			dir.entries[filter] = append(dir.entries[filter], other)
+		}},
+	}
+	for _, test := range tests {
+		dir := testDirectory(b)
+		if test.setup != nil {
+			test.setup(dir)
+		}
+		if identity, err := b.identity(dir, test.login, test.password); err != ErrInvalidLogin {
+			t.Errorf("%s: identity = %+v, %v, want ErrInvalidLogin", test.name, identity, err)
+		}
+	}
+
+	// A wrong service password is an error of the backend, not of the login
+	b.BindPassword = "wrong"
+	if _, err := b.identity(testDirectory(b), "jdoe", "secret"); err == nil || err == ErrInvalidLogin {
+		t.Errorf("identity with a wrong service password = %v, want a bind error", err)
+	}
+
+	// Empty passwords would be unauthenticated binds, they are rejected
+	// before connecting
+	b.connect = func() (ldapDirectory, error) {
+		t.Error("connected for an empty password")
+		return testDirectory(b), nil
+	}
+	if _, err := b.Authenticate("jdoe", ""); err != ErrInvalidLogin {
+		t.Errorf("Authenticate with an empty password = %v, want ErrInvalidLogin", err)
+	}
+}
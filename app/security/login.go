@@ -0,0 +1,150 @@
+package security
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"aahframework.org/config.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	storageModel "github.com/keiwi/utils/models"
+)
+
+// ErrInvalidLogin is the only error given for an unknown user or a wrong
+// password, so accounts can't be enumerated
+var ErrInvalidLogin = errors.New("invalid username or password")
+
+// dummyHash is compared against when the user does not exist, so unknown
+// users take as long to reject as wrong passwords
+var dummyHash = models.HashPassword("", "keiwi")
+
+// LoginBackend verifies the password of a login and returns the local user,
+// ErrInvalidLogin is returned for an unknown user or a wrong password
+type LoginBackend interface {
+	Authenticate(login, password string) (*storageModel.User, error)
+}
+
+// LoginBackends are tried in order until one accepts the login, they are
+// configured in `security.login.backends`
+var LoginBackends = []LoginBackend{&LocalBackend{}}
+
+// loadLoginBackends reads `security.login` and the config of the backends
+func loadLoginBackends(cfg *config.Config) error {
+	names, found := cfg.StringList("security.login.backends")
+	if !found {
+		names = []string{"local"}
+	}
+
+	backends := make([]LoginBackend, 0, len(names))
+	for _, name := range names {
+		switch name {
+		case "local":
+			backends = append(backends, &LocalBackend{})
+		case "ldap":
+			backend, err := loadLDAPBackend(cfg)
+			if err != nil {
+				return err
+			}
+			backends = append(backends, backend)
+		default:
+			return fmt.Errorf("unknown login backend '%s'", name)
+		}
+	}
+	if len(backends) <= 0 {
+		return errors.New("no login backend configured")
+	}
+
+	LoginBackends = backends
+	return nil
+}
+
+// TooManyAttemptsError is returned while a login is blocked by the limiter
+type TooManyAttemptsError struct {
+	RetryAfter time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many failed login attempts, retry after %s", e.RetryAfter)
+}
+
+// Login verifies the password of the user with the username or email. The
+// login limits are applied to the user and any extra keys, such as the
+// client IP.
+func Login(login, password string, keys ...string) (*storageModel.User, error) {
+	key, err := loginKey(login)
+	if err != nil {
+		return nil, err
+	}
+	keys = append(keys, key)
+
+	// The attempt is reserved before the password is verified, so parallel
+	// guesses count against each other
+	wait, err := Logins.Reserve(keys...)
+	if err != nil {
+		return nil, err
+	}
+	if wait > 0 {
+		return nil, &TooManyAttemptsError{RetryAfter: wait}
+	}
+
+	var user *storageModel.User
+	for _, backend := range LoginBackends {
+		user, err = backend.Authenticate(login, password)
+		if err == nil {
+			break
+		}
+		// An unavailable backend falls through to the next one, so local
+		// users can still log in when the directory is down
+		if err != ErrInvalidLogin && err != ErrIdentityDenied {
+			log.Errorf("error logging in: %v", err)
+		}
+	}
+
+	if user == nil {
+		return nil, ErrInvalidLogin
+	}
+
+	// Only the user is reset, a valid login must not clear the failures of
+	// other logins from the same IP
+	if err := Logins.Reset(key); err != nil {
+		log.Errorf("error resetting failed logins: %v", err)
+	}
+	if err := Logins.Release(keys[:len(keys)-1]...); err != nil {
+		log.Errorf("error releasing login attempt: %v", err)
+	}
+	return user, nil
+}
+
+// loginKey returns the attempt key of a username or email, the username and
+// email of a user share the key of the user
+func loginKey(login string) (string, error) {
+	user, err := models.FindUserWithLogin(login)
+	if err == models.ErrUserNotFound {
+		return LoginKey(login), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return UserKey(user.ID), nil
+}
+
+// LocalBackend verifies the password stored on the user
+type LocalBackend struct{}
+
+// Authenticate is `LoginBackend` interface
+func (LocalBackend) Authenticate(login, password string) (*storageModel.User, error) {
+	user, err := models.FindUserWithLogin(login)
+	if err != nil && err != models.ErrUserNotFound {
+		return nil, err
+	}
+
+	if user == nil {
+		models.CheckPassword(dummyHash, password)
+		return nil, ErrInvalidLogin
+	}
+	if !models.CheckPassword(user.Password, password) {
+		return nil, ErrInvalidLogin
+	}
+	return user, nil
+}
@@ -0,0 +1,384 @@
+package security
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"aahframework.org/config.v0"
+	"aahframework.org/log.v0"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/keiwi/api/app/models"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SSO is the OpenID Connect provider users can log in with, it is nil when
+// single sign-on is disabled
+var SSO *OIDCProvider
+
+// ssoLoginTTL is how long a user has to log in at the identity provider
+const ssoLoginTTL = 10 * time.Minute
+
+// OIDCProvider logs users in with the OpenID Connect authorization code flow
+// with PKCE, the endpoints and keys are discovered from the issuer
+type OIDCProvider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	UsernameClaim string
+	GroupsClaim   string
+
+	// Groups maps each keiwi role to the groups at the identity provider granting it
+	Groups map[string][]string
+	// DefaultRole is given to new users none of whose groups grant a role
+	DefaultRole string
+
+	Client *http.Client
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]interface{}
+	keysAt    time.Time
+}
+
+// oidcDiscovery is the part of the provider metadata that is used
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// loadOIDCConfig reads `security.oidc` from the application config
+func loadOIDCConfig(cfg *config.Config) error {
+	SSO = nil
+	if !cfg.BoolDefault("security.oidc.enabled", false) {
+		return nil
+	}
+
+	p := &OIDCProvider{
+		Issuer:        strings.TrimSuffix(cfg.StringDefault("security.oidc.issuer", ""), "/"),
+		ClientID:      cfg.StringDefault("security.oidc.client_id", ""),
+		ClientSecret:  cfg.StringDefault("security.oidc.client_secret", ""),
+		RedirectURL:   cfg.StringDefault("security.oidc.redirect_url", ""),
+		UsernameClaim: cfg.StringDefault("security.oidc.username_claim", "preferred_username"),
+		GroupsClaim:   cfg.StringDefault("security.oidc.groups_claim", "groups"),
+		Groups:        make(map[string][]string),
+		DefaultRole:   cfg.StringDefault("security.oidc.default_role", ""),
+		Client:        &http.Client{Timeout: 10 * time.Second},
+	}
+	if p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+		return errors.New("oidc: issuer, client_id and redirect_url are required")
+	}
+
+	scopes, found := cfg.StringList("security.oidc.scopes")
+	if !found {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	p.Scopes = scopes
+	if !containsString(p.Scopes, "openid") {
+		p.Scopes = append([]string{"openid"}, p.Scopes...)
+	}
+
+	for _, role := range cfg.KeysByPath("security.oidc.groups") {
+		p.Groups[role], _ = cfg.StringList("security.oidc.groups." + role)
+	}
+
+	SSO = p
+	return nil
+}
+
+// StartLogin stores a new pending login and returns the URL of the identity
+// provider to send the user to
+func (p *OIDCProvider) StartLogin() (string, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	var values [3]string
+	for i := range values {
+		if values[i], err = RandomToken(32); err != nil {
+			return "", err
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+
+	err = models.Create(models.SSOLoginsCollection, models.SSOLogin{
+		ID:        bson.NewObjectId(),
+		StateHash: HashToken(state),
+		Nonce:     nonce,
+		Verifier:  verifier,
+		ExpiresAt: time.Now().Add(ssoLoginTTL),
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return p.authURL(d, state, nonce, verifier), nil
+}
+
+// authURL returns the URL of the authorization endpoint for a login, the
+// PKCE challenge is derived from the verifier
+func (p *OIDCProvider) authURL(d *oidcDiscovery, state, nonce, verifier string) string {
+	challenge := sha256.Sum256([]byte(verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.ClientID},
+		"redirect_uri":          {p.RedirectURL},
+		"scope":                 {strings.Join(p.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {encodeBase64(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	sep := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return d.AuthorizationEndpoint + sep + query.Encode()
+}
+
+// FinishLogin exchanges the code the identity provider redirected back with
+// and returns the local user of the identity
+func (p *OIDCProvider) FinishLogin(code, state string) (*storageModel.User, error) {
+	login, err := models.UseSSOLogin(HashToken(state))
+	if err != nil {
+		return nil, err
+	}
+
+	idToken, err := p.exchange(code, login.Verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, err := p.verifyIDToken(idToken, login.Nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.user(claims)
+}
+
+// exchange redeems the authorization code at the token endpoint for an ID token
+func (p *OIDCProvider) exchange(code, verifier string) (string, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.RedirectURL},
+		"client_id":     {p.ClientID},
+		"code_verifier": {verifier},
+	}
+	if p.ClientSecret != "" {
+		form.Set("client_secret", p.ClientSecret)
+	}
+
+	resp, err := p.Client.PostForm(d.TokenEndpoint, form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var reply struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&reply); err != nil {
+		return "", fmt.Errorf("oidc token endpoint: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("oidc token endpoint: %s %s", reply.Error, reply.ErrorDescription)
+	}
+	if reply.IDToken == "" {
+		return "", errors.New("oidc token endpoint: no id_token in the reply")
+	}
+	return reply.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, expiry and nonce of the ID token
+func (p *OIDCProvider) verifyIDToken(raw, nonce string) (jwt.MapClaims, error) {
+	d, err := p.getDiscovery()
+	if err != nil {
+		return nil, err
+	}
+
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(raw, claims, p.verifyKey); err != nil {
+		return nil, err
+	}
+
+	if !claims.VerifyIssuer(d.Issuer, true) {
+		return nil, errors.New("id token has another issuer")
+	}
+	if !hasAudience(claims, p.ClientID) {
+		return nil, errors.New("id token is for another client")
+	}
+	if n, _ := claims["nonce"].(string); n != nonce {
+		return nil, errors.New("id token nonce does not match")
+	}
+	if sub, _ := claims["sub"].(string); sub == "" {
+		return nil, errors.New("id token has no subject")
+	}
+	return claims, nil
+}
+
+// verifyKey is a `jwt.Keyfunc` resolving the key of the identity provider,
+// the keys are fetched again when the token is signed with an unknown key
+func (p *OIDCProvider) verifyKey(token *jwt.Token) (interface{}, error) {
+	switch token.Method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodECDSA:
+	default:
+		return nil, errors.New("unexpected signing method")
+	}
+	kid, _ := token.Header["kid"].(string)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	key, ok := p.findKey(kid)
+	if !ok && time.Since(p.keysAt) > time.Minute {
+		if err := p.fetchKeys(); err != nil {
+			return nil, err
+		}
+		key, ok = p.findKey(kid)
+	}
+	if !ok {
+		return nil, errors.New("unknown key id")
+	}
+	return key, nil
+}
+
+// findKey returns the key with the kid, tokens without a kid can only be
+// verified when the provider has a single key
+func (p *OIDCProvider) findKey(kid string) (interface{}, bool) {
+	if kid == "" && len(p.keys) == 1 {
+		for _, key := range p.keys {
+			return key, true
+		}
+	}
+	key, ok := p.keys[kid]
+	return key, ok
+}
+
+// fetchKeys loads the keys of the identity provider, p.mu has to be held
+func (p *OIDCProvider) fetchKeys() error {
+	var set JWKS
+	if err := p.getJSON(p.discovery.JWKSURI, &set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.PublicKey()
+		if err != nil {
+			log.Debugf("skipping oidc key '%s': %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+
+	p.keys = keys
+	p.keysAt = time.Now()
+	return nil
+}
+
+// getDiscovery returns the provider metadata, it is fetched on first use so
+// the API starts while the identity provider is unavailable
+func (p *OIDCProvider) getDiscovery() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var d oidcDiscovery
+	if err := p.getJSON(p.Issuer+"/.well-known/openid-configuration", &d); err != nil {
+		return nil, err
+	}
+	if strings.TrimSuffix(d.Issuer, "/") != p.Issuer {
+		return nil, fmt.Errorf("oidc discovery: issuer '%s' does not match '%s'", d.Issuer, p.Issuer)
+	}
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, errors.New("oidc discovery: missing endpoints")
+	}
+
+	p.discovery = &d
+	return p.discovery, nil
+}
+
+func (p *OIDCProvider) getJSON(u string, out interface{}) error {
+	resp, err := p.Client.Get(u)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", u, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out)
+}
+
+// user returns the local user of the identity in the claims
+func (p *OIDCProvider) user(claims jwt.MapClaims) (*storageModel.User, error) {
+	identity := ExternalIdentity{Provider: p.Issuer, Roles: p.roles(claims)}
+	identity.Subject, _ = claims["sub"].(string)
+	identity.Username, _ = claims[p.UsernameClaim].(string)
+	identity.Email, _ = claims["email"].(string)
+	identity.EmailVerified, _ = claims["email_verified"].(bool)
+
+	return externalUser(identity, p.DefaultRole)
+}
+
+// roles returns the keiwi roles granted by the groups claim
+func (p *OIDCProvider) roles(claims jwt.MapClaims) []string {
+	var groups []string
+	switch v := claims[p.GroupsClaim].(type) {
+	case string:
+		groups = []string{v}
+	case []interface{}:
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+	}
+	return mapGroups(p.Groups, groups)
+}
+
+// hasAudience checks the aud claim, which is either a string or a list
+func hasAudience(claims jwt.MapClaims, aud string) bool {
+	switch v := claims["aud"].(type) {
+	case string:
+		return v == aud
+	case []interface{}:
+		for _, a := range v {
+			if a == aud {
+				return true
+			}
+		}
+	}
+	return false
+}
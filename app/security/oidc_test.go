@@ -0,0 +1,276 @@
+package security
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dgrijalva/jwt-go"
+)
+
+// testIdP is an identity provider serving discovery, keys, authorization and
+// tokens. The authorization endpoint approves every login and the token
+// endpoint signs the claims of the code with signer, changed by modify when
+// it is set.
+type testIdP struct {
+	*httptest.Server
+	t      *testing.T
+	keys   *KeySet // published
+	signer *KeySet
+	modify func(claims jwt.MapClaims)
+
+	mu    sync.Mutex
+	codes map[string]url.Values // the authorization request of each code
+}
+
+const (
+	testClientID    = "keiwi"
+	testRedirectURL = "https://keiwi.example/sso/callback"
+)
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+	idp := &testIdP{
+		t:     t,
+		keys:  &KeySet{signing: "idp", keys: map[string]*Key{"idp": {ID: "idp", Method: jwt.SigningMethodRS256, signKey: private, verifyKey: &private.PublicKey}}},
+		codes: make(map[string]url.Values),
+	}
+	idp.signer = idp.keys
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(oidcDiscovery{
+			Issuer:                idp.URL,
+			AuthorizationEndpoint: idp.URL + "/authorize",
+			TokenEndpoint:         idp.URL + "/token",
+			JWKSURI:               idp.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(idp.keys.JWKS())
+	})
+	mux.HandleFunc("/authorize", idp.authorize)
+	mux.HandleFunc("/token", idp.token)
+	idp.Server = httptest.NewServer(mux)
+	t.Cleanup(idp.Close)
+	return idp
+}
+
+func (idp *testIdP) provider() *OIDCProvider {
+	return &OIDCProvider{
+		Issuer:        idp.URL,
+		ClientID:      testClientID,
+		RedirectURL:   testRedirectURL,
+		Scopes:        []string{"openid", "profile"},
+		UsernameClaim: "preferred_username",
+		GroupsClaim:   "groups",
+		Client:        idp.Client(),
+	}
+}
+
+// authorize approves the login and redirects back with a code and the state
+func (idp *testIdP) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != testClientID || q.Get("redirect_uri") != testRedirectURL {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+	if q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "invalid_request", http.StatusBadRequest)
+		return
+	}
+
+	code, err := RandomToken(16)
+	if err != nil {
+		idp.t.Errorf("error generating code: %v", err)
+	}
+	idp.mu.Lock()
+	idp.codes[code] = q
+	idp.mu.Unlock()
+
+	back := url.Values{"code": {code}, "state": {q.Get("state")}}
+	http.Redirect(w, r, testRedirectURL+"?"+back.Encode(), http.StatusFound)
+}
+
+// token redeems a code once, when the verifier matches its challenge
+func (idp *testIdP) token(w http.ResponseWriter, r *http.Request) {
+	reject := func(e string) {
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(map[string]string{"error": e})
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("grant_type") != "authorization_code" {
+		reject("invalid_request")
+		return
+	}
+
+	idp.mu.Lock()
+	q, found := idp.codes[r.PostForm.Get("code")]
+	delete(idp.codes, r.PostForm.Get("code"))
+	idp.mu.Unlock()
+	if !found || r.PostForm.Get("client_id") != q.Get("client_id") || r.PostForm.Get("redirect_uri") != q.Get("redirect_uri") {
+		reject("invalid_grant")
+		return
+	}
+	challenge := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if encodeBase64(challenge[:]) != q.Get("code_challenge") {
+		reject("invalid_grant")
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"iss":                idp.URL,
+		"sub":                "user-1",
+		"aud":                testClientID,
+		"exp":                time.Now().Add(time.Minute).Unix(),
+		"iat":                time.Now().Unix(),
+		"nonce":              q.Get("nonce"),
+		"preferred_username": "jdoe",
+	}
+	if idp.modify != nil {
+		idp.modify(claims)
+	}
+	signed, err := idp.signer.Sign(jwt.NewWithClaims(jwt.SigningMethodRS256, claims))
+	if err != nil {
+		idp.t.Errorf("error signing id token: %v", err)
+	}
+	json.NewEncoder(w).Encode(map[string]string{"id_token": signed, "token_type": "Bearer"})
+}
+
+// login walks the authorization code flow up to the redirect back, it
+// returns the code and state of the redirect
+func login(t *testing.T, p *OIDCProvider, state, nonce, verifier string) (code, returnedState string) {
+	t.Helper()
+
+	d, err := p.getDiscovery()
+	if err != nil {
+		t.Fatalf("discovery failed: %v", err)
+	}
+
+	client := *p.Client
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	resp, err := client.Get(p.authURL(d, state, nonce, verifier))
+	if err != nil {
+		t.Fatalf("error requesting authorization: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("authorization replied %s, want a redirect", resp.Status)
+	}
+
+	back, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatalf("invalid redirect: %v", err)
+	}
+	return back.Query().Get("code"), back.Query().Get("state")
+}
+
+func TestOIDCRoundTrip(t *testing.T) {
+	idp := newTestIdP(t)
+	p := idp.provider()
+
+	code, state := login(t, p, "state-1", "nonce-1", "verifier-1")
+	if state != "state-1" {
+		t.Errorf("redirected back with state %q, want state-1", state)
+	}
+
+	raw, err := p.exchange(code, "verifier-1")
+	if err != nil {
+		t.Fatalf("exchange failed: %v", err)
+	}
+	claims, err := p.verifyIDToken(raw, "nonce-1")
+	if err != nil {
+		t.Fatalf("id token rejected: %v", err)
+	}
+	if claims["sub"] != "user-1" || claims["preferred_username"] != "jdoe" {
+		t.Errorf("id token claims = %v", claims)
+	}
+
+	// Codes are redeemed once, and only with the verifier of their challenge
+	if _, err := p.exchange(code, "verifier-1"); err == nil {
+		t.Error("a code was redeemed twice")
+	}
+	code, _ = login(t, p, "state-2", "nonce-2", "verifier-2")
+	if _, err := p.exchange(code, "verifier-1"); err == nil {
+		t.Error("a code was redeemed with the verifier of another login")
+	}
+}
+
+func TestOIDCRejects(t *testing.T) {
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("error generating rsa key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		nonce  string
+		modify func(claims jwt.MapClaims)
+		signer *KeySet
+	}{
+		{name: "bad nonce", nonce: "other-nonce"},
+		{name: "missing nonce", nonce: "nonce", modify: func(c jwt.MapClaims) { delete(c, "nonce") }},
+		{name: "wrong aud", nonce: "nonce", modify: func(c jwt.MapClaims) { c["aud"] = "other-client" }},
+		{name: "wrong aud in a list", nonce: "nonce", modify: func(c jwt.MapClaims) { c["aud"] = []string{"a", "b"} }},
+		{name: "wrong iss", nonce: "nonce", modify: func(c jwt.MapClaims) { c["iss"] = "https://other.example" }},
+		{name: "expired", nonce: "nonce", modify: func(c jwt.MapClaims) { c["exp"] = time.Now().Add(-time.Minute).Unix() }},
+		{name: "missing sub", nonce: "nonce", modify: func(c jwt.MapClaims) { delete(c, "sub") }},
+		{name: "unknown kid", nonce: "nonce", signer: &KeySet{signing: "other", keys: map[string]*Key{
+			"other": {ID: "other", Method: jwt.SigningMethodRS256, signKey: other, verifyKey: &other.PublicKey},
+		}}},
+	}
+
+	for _, test := range tests {
+		idp := newTestIdP(t)
+		p := idp.provider()
+		idp.modify = test.modify
+		if test.signer != nil {
+			idp.signer = test.signer
+		}
+
+		code, _ := login(t, p, "state", "nonce", "verifier")
+		raw, err := p.exchange(code, "verifier")
+		if err != nil {
+			t.Errorf("%s: exchange failed: %v", test.name, err)
+			continue
+		}
+		if claims, err := p.verifyIDToken(raw, test.nonce); err == nil {
+			t.Errorf("%s: the id token was accepted: %v", test.name, claims)
+		}
+	}
+}
+
+func TestOIDCRoles(t *testing.T) {
+	p := &OIDCProvider{GroupsClaim: "groups", Groups: map[string][]string{
+		"admin":  {"ops"},
+		"viewer": {"staff", "ops"},
+	}}
+
+	tests := []struct {
+		groups interface{}
+		roles  []string
+	}{
+		{[]interface{}{"ops"}, []string{"admin", "viewer"}},
+		{"staff", []string{"viewer"}},
+		{[]interface{}{"other", 1}, nil},
+		{nil, nil},
+	}
+	for _, test := range tests {
+		roles := p.roles(jwt.MapClaims{"groups": test.groups})
+		if len(roles) != len(test.roles) || len(roles) > 0 && !reflect.DeepEqual(roles, test.roles) {
+			t.Errorf("roles of the groups %v = %v, want %v", test.groups, roles, test.roles)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package security
+
+import (
+	"errors"
+	"time"
+
+	"aahframework.org/log.v0"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// SessionClaim is the principal claim holding the session id of a request
+// authenticated with an access token
+const SessionClaim = "sid"
+
+// sessionTouchInterval limits how often the last use of a session is stored
+const sessionTouchInterval = time.Minute
+
+var (
+	// ErrSessionRevoked is returned for tokens of a revoked or expired session
+	ErrSessionRevoked = errors.New("session has been revoked or has expired")
+
+	// ErrRefreshTokenReused is returned when a refresh token is used after it
+	// has been exchanged, its session has been revoked
+	ErrRefreshTokenReused = errors.New("refresh token has already been used")
+)
+
+// StartSession stores a new session of the user and returns its id along with
+// the id of its first refresh token, the session lasts as long as its
+// refresh token
+func StartSession(user bson.ObjectId, method, ip, userAgent string) (sid, refreshID string, err error) {
+	now := time.Now()
+	session := models.Session{
+		ID:         bson.NewObjectId(),
+		UserID:     user,
+		Method:     method,
+		IP:         ip,
+		UserAgent:  userAgent,
+		ExpiresAt:  now.Add(refreshTTL),
+		LastUsedAt: now,
+		RefreshID:  bson.NewObjectId().Hex(),
+		CreatedAt:  now,
+	}
+	if err := models.Create(models.SessionsCollection, session); err != nil {
+		return "", "", err
+	}
+	return session.ID.Hex(), session.RefreshID, nil
+}
+
+// RefreshSession exchanges the refresh token of the claims for the id of a new
+// one, extends the session and records the client using it. A refresh token
+// can only be exchanged once, using it again means it has been stolen, so
+// the whole session is revoked and ErrRefreshTokenReused is returned.
+func RefreshSession(claims jwt.MapClaims, ip, userAgent string) (string, error) {
+	sid, _ := claims["sid"].(string)
+	jti, _ := claims["jti"].(string)
+	if !bson.IsObjectIdHex(sid) || jti == "" {
+		return "", ErrSessionRevoked
+	}
+
+	now := time.Now()
+	refreshID := bson.NewObjectId().Hex()
+	rotated, err := models.RotateSession(bson.ObjectIdHex(sid), jti, bson.M{
+		"refresh_id":   refreshID,
+		"ip":           ip,
+		"user_agent":   userAgent,
+		"expires_at":   now.Add(refreshTTL),
+		"last_used_at": now,
+	})
+	if err != nil {
+		return "", err
+	}
+	if !rotated {
+		log.Warnf("refresh token of session %s was used again, revoking the session", sid)
+		if err := models.RevokeSessions(utils.Filter{"_id": bson.ObjectIdHex(sid)}); err != nil {
+			return "", err
+		}
+		return "", ErrRefreshTokenReused
+	}
+	return refreshID, nil
+}
+
+// checkSession verifies that the session of the token is active and records its use
+func checkSession(claims jwt.MapClaims) error {
+	sid, _ := claims["sid"].(string)
+	session, err := models.FindSession(sid)
+	if err == models.ErrSessionNotFound {
+		return ErrSessionRevoked
+	}
+	if err != nil {
+		return err
+	}
+	if !session.IsActive() {
+		return ErrSessionRevoked
+	}
+
+	if time.Since(session.LastUsedAt) > sessionTouchInterval {
+		if err := models.UpdateSession(session.ID, bson.M{"last_used_at": time.Now()}); err != nil {
+			log.Errorf("error updating last use of session: %v", err)
+		}
+	}
+	return nil
+}
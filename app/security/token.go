@@ -0,0 +1,172 @@
+package security
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+
+	"aahframework.org/config.v0"
+	"github.com/dgrijalva/jwt-go"
+	"github.com/keiwi/api/app/models"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Token types, stored in the `typ` claim
+const (
+	AccessToken  = "access"
+	RefreshToken = "refresh"
+
+	// MFAToken is issued after the password of a user with two-factor
+	// authentication has been verified, it is exchanged for a token pair
+	// with a TOTP or recovery code
+	MFAToken = "mfa"
+)
+
+var (
+	// ErrTokenRevoked is returned when a token id has been revoked
+	ErrTokenRevoked = errors.New("token has been revoked")
+
+	// ErrTokenType is returned when a token is used for something it was not issued for
+	ErrTokenType = errors.New("unexpected token type")
+
+	accessTTL  = 15 * time.Minute
+	refreshTTL = 30 * 24 * time.Hour
+	mfaTTL     = 5 * time.Minute
+)
+
+// TokenPair is the reply to a successful login or refresh
+type TokenPair struct {
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// loadTokenConfig reads the token lifetimes from `security.jwt`
+func loadTokenConfig(cfg *config.Config) error {
+	var err error
+	if accessTTL, err = time.ParseDuration(cfg.StringDefault("security.jwt.access_ttl", "15m")); err != nil {
+		return err
+	}
+	if refreshTTL, err = time.ParseDuration(cfg.StringDefault("security.jwt.refresh_ttl", "720h")); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GetToken create a jwt token of the type with user claims, signed with the
+// active key. Access and refresh tokens belong to the session sid.
+func GetToken(user *storageModel.User, typ, sid string, ttl time.Duration) (string, error) {
+	return signToken(user, typ, sid, bson.NewObjectId().Hex(), ttl)
+}
+
+// signToken creates a token like GetToken with the token id jti
+func signToken(user *storageModel.User, typ, sid, jti string, ttl time.Duration) (string, error) {
+	token := jwt.New(jwt.SigningMethodHS256)
+	claims := token.Claims.(jwt.MapClaims)
+	claims["uuid"] = user.ID
+	claims["typ"] = typ
+	if sid != "" {
+		claims["sid"] = sid
+	}
+	claims["jti"] = jti
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = time.Now().Add(ttl).Unix()
+	return Keys.Sign(token)
+}
+
+// GetTokenPair creates a short-lived access token and a refresh token with the
+// refresh ID, as stored on the session, for the session
+func GetTokenPair(user *storageModel.User, sid, refreshID string) (*TokenPair, error) {
+	access, err := GetToken(user, AccessToken, sid, accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	refresh, err := signToken(user, RefreshToken, sid, refreshID, refreshTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		IDToken:      access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(accessTTL / time.Second),
+	}, nil
+}
+
+// GetMFAToken creates a short-lived token for completing a two-factor login
+func GetMFAToken(user *storageModel.User) (string, error) {
+	return GetToken(user, MFAToken, "", mfaTTL)
+}
+
+// GetJSONToken create a JSON token string for the session
+func GetJSONToken(user *storageModel.User, sid, refreshID string) (string, error) {
+	pair, err := GetTokenPair(user, sid, refreshID)
+	if err != nil {
+		return "", err
+	}
+
+	jsontoken, err := json.Marshal(pair)
+	if err != nil {
+		return "", err
+	}
+	return string(jsontoken), nil
+}
+
+// ParseToken validates a signed token of one of the types and returns its
+// claims, revoked tokens are rejected
+func ParseToken(signed string, types ...string) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(signed, Keys.Verify)
+	if err != nil {
+		return nil, err
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+	if !hasTokenType(claims, types) {
+		return nil, ErrTokenType
+	}
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return nil, errors.New("token is missing an id")
+	}
+	revoked, err := models.IsTokenRevoked(jti)
+	if err != nil {
+		return nil, err
+	}
+	if revoked {
+		return nil, ErrTokenRevoked
+	}
+
+	// MFA tokens are issued before the session starts
+	if typ, _ := claims["typ"].(string); typ != MFAToken {
+		if err := checkSession(claims); err != nil {
+			return nil, err
+		}
+	}
+
+	return claims, nil
+}
+
+// RevokeToken revokes the token id of the claims until the token expires
+func RevokeToken(claims jwt.MapClaims) error {
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims["exp"].(float64)
+	return models.RevokeToken(jti, time.Unix(int64(exp), 0))
+}
+
+func hasTokenType(claims jwt.MapClaims, types []string) bool {
+	typ, _ := claims["typ"].(string)
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package security
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"aahframework.org/config.v0"
+	"github.com/keiwi/api/app/models"
+	storageModel "github.com/keiwi/utils/models"
+)
+
+const (
+	// totpPeriod is the time step of the codes (RFC 6238)
+	totpPeriod = 30
+
+	// totpSkew is the number of steps before and after the current one that
+	// are accepted, to allow for clock drift
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+var (
+	mfaIssuer        = "keiwi"
+	mfaRequiredRoles []string
+)
+
+// loadMFAConfig reads `security.mfa` from the application config
+func loadMFAConfig(cfg *config.Config) error {
+	mfaIssuer = cfg.StringDefault("security.mfa.issuer", "keiwi")
+	mfaRequiredRoles, _ = cfg.StringList("security.mfa.required_roles")
+	return nil
+}
+
+// MFARequired checks if one of the roles of the user requires two-factor authentication
+func MFARequired(user *storageModel.User) bool {
+	for _, required := range mfaRequiredRoles {
+		for _, role := range user.Roles {
+			if role == required {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// GenerateTOTPSecret returns a new random base32 encoded TOTP secret
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(b), nil
+}
+
+// TOTPURI returns the provisioning URI of the secret, usually shown as a QR code
+func TOTPURI(account, secret string) string {
+	v := url.Values{}
+	v.Set("secret", secret)
+	v.Set("issuer", mfaIssuer)
+	v.Set("algorithm", "SHA1")
+	v.Set("digits", "6")
+	v.Set("period", fmt.Sprint(totpPeriod))
+
+	label := url.PathEscape(mfaIssuer + ":" + account)
+	return "otpauth://totp/" + label + "?" + v.Encode()
+}
+
+// ValidateTOTP checks the code against the TOTP secret of the user at the
+// current time. A code is rejected when its time step is not later than the
+// last one accepted for the user, so it can't be replayed.
+func ValidateTOTP(user *storageModel.User, code string) (bool, error) {
+	step, ok := matchTOTP(user.TOTPSecret, code, time.Now())
+	if !ok {
+		return false, nil
+	}
+	return models.UseTOTPStep(user.ID, step)
+}
+
+// matchTOTP returns the time step around t that the code of the secret
+// belongs to
+func matchTOTP(secret, code string, t time.Time) (int64, bool) {
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(strings.ToUpper(secret))
+	if err != nil {
+		return 0, false
+	}
+
+	code = strings.TrimSpace(code)
+	step := t.Unix() / totpPeriod
+	for i := -totpSkew; i <= totpSkew; i++ {
+		expected := totpCode(key, uint64(step+int64(i)))
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + int64(i), true
+		}
+	}
+	return 0, false
+}
+
+// totpCode computes the 6 digit HOTP value of the counter (RFC 4226)
+func totpCode(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%06d", value%1000000)
+}
+
+// GenerateRecoveryCodes returns new one-time recovery codes and their hashes
+func GenerateRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		code := base32.StdEncoding.EncodeToString(b)
+		code = strings.ToLower(code[:4] + "-" + code[4:])
+		codes = append(codes, code)
+		hashes = append(hashes, HashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// HashRecoveryCode returns the hash of a recovery code as it is stored
+func HashRecoveryCode(code string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(strings.TrimSpace(code))))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifyMFA checks a TOTP code or a recovery code of the user, a recovery
+// code is removed once it has been used
+func VerifyMFA(user *storageModel.User, code string) (bool, error) {
+	if !user.TOTPEnabled {
+		return false, nil
+	}
+	if step, ok := matchTOTP(user.TOTPSecret, code, time.Now()); ok {
+		return models.UseTOTPStep(user.ID, step)
+	}
+
+	hash := HashRecoveryCode(code)
+	for i, h := range user.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) != 1 {
+			continue
+		}
+
+		// Only the request removing the code may use it
+		ok, err := models.UseRecoveryCode(user.ID, hash)
+		if err != nil || !ok {
+			return false, err
+		}
+		user.RecoveryCodes = append(append([]string{}, user.RecoveryCodes[:i]...), user.RecoveryCodes[i+1:]...)
+		return true, nil
+	}
+	return false, nil
+}
@@ -0,0 +1,115 @@
+package security
+
+import (
+	"encoding/base32"
+	"strings"
+	"testing"
+	"time"
+)
+
+// The SHA1 secret of the RFC 6238 test vectors, base32 encoded
+var rfc6238Secret = base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString([]byte("12345678901234567890"))
+
+// The SHA1 test vectors of RFC 6238 appendix B, the codes are the last 6 of
+// the 8 digits in the RFC
+var rfc6238Vectors = []struct {
+	unix int64
+	code string
+}{
+	{59, "287082"},
+	{1111111109, "081804"},
+	{1111111111, "050471"},
+	{1234567890, "005924"},
+	{2000000000, "279037"},
+	{20000000000, "353130"},
+}
+
+func TestTOTPCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+	for _, v := range rfc6238Vectors {
+		if code := totpCode(key, uint64(v.unix/totpPeriod)); code != v.code {
+			t.Errorf("totpCode at %d = %s, want %s", v.unix, code, v.code)
+		}
+	}
+}
+
+func TestMatchTOTP(t *testing.T) {
+	for _, v := range rfc6238Vectors {
+		step := v.unix / totpPeriod
+		tests := []struct {
+			secret, code string
+			at           int64
+			ok           bool
+		}{
+			{rfc6238Secret, v.code, v.unix, true},
+			{strings.ToLower(rfc6238Secret), v.code, v.unix, true},
+			{rfc6238Secret, " " + v.code + " ", v.unix, true},
+			{rfc6238Secret, v.code, v.unix - totpPeriod, true},
+			{rfc6238Secret, v.code, v.unix + totpPeriod, true},
+			{rfc6238Secret, v.code, v.unix - 2*totpPeriod, false},
+			{rfc6238Secret, v.code, v.unix + 2*totpPeriod, false},
+			{rfc6238Secret, v.code[1:], v.unix, false},
+			{rfc6238Secret, "", v.unix, false},
+			{"not base32!", v.code, v.unix, false},
+		}
+
+		for _, test := range tests {
+			// Steps are only counted from the epoch
+			if test.at < 0 {
+				continue
+			}
+			matched, ok := matchTOTP(test.secret, test.code, time.Unix(test.at, 0))
+			if ok != test.ok {
+				t.Errorf("matchTOTP(%s, %q) at %d = %v, want %v", test.secret, test.code, test.at, ok, test.ok)
+				continue
+			}
+			if ok && matched != step {
+				t.Errorf("matchTOTP(%s, %q) at %d matched step %d, want %d", test.secret, test.code, test.at, matched, step)
+			}
+		}
+	}
+}
+
+func TestGenerateTOTPSecret(t *testing.T) {
+	secret, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatalf("GenerateTOTPSecret failed: %v", err)
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil || len(key) != 20 {
+		t.Errorf("GenerateTOTPSecret = %s, want 20 base32 encoded bytes", secret)
+	}
+
+	code := totpCode(key, uint64(time.Now().Unix()/totpPeriod))
+	if _, ok := matchTOTP(secret, code, time.Now()); !ok {
+		t.Errorf("the current code %s of the generated secret doesn't match", code)
+	}
+}
+
+func TestRecoveryCodes(t *testing.T) {
+	codes, hashes, err := GenerateRecoveryCodes()
+	if err != nil {
+		t.Fatalf("GenerateRecoveryCodes failed: %v", err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("GenerateRecoveryCodes returned %d codes and %d hashes, want %d", len(codes), len(hashes), recoveryCodeCount)
+	}
+
+	seen := make(map[string]bool)
+	for i, code := range codes {
+		if len(code) != 9 || code[4] != '-' || code != strings.ToLower(code) {
+			t.Errorf("recovery code %q isn't formatted as xxxx-xxxx", code)
+		}
+		if seen[code] {
+			t.Errorf("recovery code %q is returned twice", code)
+		}
+		seen[code] = true
+
+		if hashes[i] != HashRecoveryCode(code) {
+			t.Errorf("hash %d doesn't match its recovery code", i)
+		}
+		if HashRecoveryCode(" "+strings.ToUpper(code)+" ") != hashes[i] {
+			t.Errorf("the hash of recovery code %q depends on case or spaces", code)
+		}
+	}
+}
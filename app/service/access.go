@@ -0,0 +1,189 @@
+package service
+
+import (
+	"time"
+
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	storageModel "github.com/keiwi/utils/models"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Level returns the access level of the caller on an entity with the access
+// lists, a client has its own and those of its groups. Callers permitted to
+// administrate the entities, e.g. `clients:admin`, have every level and
+// owners are admins. Once an access list has entries only they grant access,
+// otherwise the permissions of the caller apply as before access lists
+// existed.
+func Level(c *Caller, entity string, acls ...*models.ACL) int {
+	if c.Subject.IsPermitted(entity + "s:" + models.ACLAdmin) {
+		return models.ACLLevel(models.ACLAdmin)
+	}
+
+	level, restricted := 0, false
+	for _, acl := range acls {
+		if acl == nil {
+			continue
+		}
+		restricted = restricted || len(acl.Entries) > 0
+		if l := acl.Level(c.UserID, c.Subject.HasRole); l > level {
+			level = l
+		}
+	}
+	if restricted {
+		return level
+	}
+
+	for _, l := range []string{models.ACLWrite, models.ACLRead} {
+		if c.Subject.IsPermitted(entity+"s:"+l) && models.ACLLevel(l) > level {
+			return models.ACLLevel(l)
+		}
+	}
+	return level
+}
+
+// Permit checks that the access lists grant the caller the level on the
+// entity
+func Permit(c *Caller, entity, level string, acls ...*models.ACL) error {
+	if Level(c, entity, acls...) >= models.ACLLevel(level) {
+		return nil
+	}
+	return forbidden("You don't have " + level + " access to this " + entity)
+}
+
+// CheckACL checks that the caller has the level on each of the entities
+func CheckACL(c *Caller, entity, level string, ids ...bson.ObjectId) error {
+	acls, err := models.FindEntityACLs(entity, ids)
+	if err != nil {
+		return internal("error finding access lists: %v", err)
+	}
+
+	for _, id := range ids {
+		if err := Permit(c, entity, level, acls[id]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckClientACL checks that the caller has the level on the client, through
+// its own access list or those of its groups
+func CheckClientACL(c *Caller, client storageModel.Client, level string) error {
+	acls, err := ClientACLs([]storageModel.Client{client})
+	if err != nil {
+		return err
+	}
+	return Permit(c, models.ACLClient, level, acls[client.ID]...)
+}
+
+// ClientACLs returns the access list of each client followed by those of its
+// groups
+func ClientACLs(clients []storageModel.Client) (map[bson.ObjectId][]*models.ACL, error) {
+	var ids, groupIDs []bson.ObjectId
+	for _, c := range clients {
+		ids = append(ids, c.ID)
+		groupIDs = append(groupIDs, c.GroupIDs...)
+	}
+
+	own, err := models.FindEntityACLs(models.ACLClient, ids)
+	if err != nil {
+		return nil, internal("error finding access lists: %v", err)
+	}
+	groups, err := models.FindEntityACLs(models.ACLGroup, groupIDs)
+	if err != nil {
+		return nil, internal("error finding access lists: %v", err)
+	}
+
+	acls := make(map[bson.ObjectId][]*models.ACL, len(clients))
+	for _, c := range clients {
+		list := []*models.ACL{own[c.ID]}
+		for _, g := range c.GroupIDs {
+			list = append(list, groups[g])
+		}
+		acls[c.ID] = list
+	}
+	return acls, nil
+}
+
+// ReadableClients returns the clients the caller can read
+func ReadableClients(c *Caller, clients []storageModel.Client) ([]storageModel.Client, error) {
+	acls, err := ClientACLs(clients)
+	if err != nil {
+		return nil, err
+	}
+
+	readable := make([]storageModel.Client, 0, len(clients))
+	for _, client := range clients {
+		if Level(c, models.ACLClient, acls[client.ID]...) >= models.ACLLevel(models.ACLRead) {
+			readable = append(readable, client)
+		}
+	}
+	return readable, nil
+}
+
+// Readable returns which of the entities the caller can read
+func Readable(c *Caller, entity string, ids []bson.ObjectId) (map[bson.ObjectId]bool, error) {
+	acls, err := models.FindEntityACLs(entity, ids)
+	if err != nil {
+		return nil, internal("error finding access lists: %v", err)
+	}
+
+	readable := make(map[bson.ObjectId]bool, len(ids))
+	for _, id := range ids {
+		readable[id] = Level(c, entity, acls[id]) >= models.ACLLevel(models.ACLRead)
+	}
+	return readable, nil
+}
+
+// CreateACL makes the caller the owner of a new entity
+func CreateACL(c *Caller, entity string, id bson.ObjectId) {
+	acl := models.ACL{
+		Entity:   entity,
+		EntityID: id,
+		OwnerID:  c.UserID,
+		Entries:  []models.ACLEntry{},
+	}
+	if err := models.SaveACL(&acl); err != nil {
+		log.Errorf("error creating access list of %s %s: %v", entity, id.Hex(), err)
+	}
+}
+
+// DeleteACLs removes the access lists of deleted entities
+func DeleteACLs(entity string, ids ...bson.ObjectId) {
+	if err := models.DeleteACLs(entity, ids...); err != nil {
+		log.Errorf("error deleting access lists of %s: %v", entity, err)
+	}
+}
+
+// Audit records a change made by the caller, before is nil for created
+// entities and after is nil for deleted ones. Failing to record is logged
+// but doesn't fail the operation, the change has already been made.
+func Audit(c *Caller, action, entity, id string, before, after interface{}) {
+	changes, err := models.AuditDiff(before, after)
+	if err != nil {
+		log.Errorf("error diffing %s %s for the audit log: %v", entity, id, err)
+	}
+
+	record := models.AuditRecord{
+		ID:        bson.NewObjectId(),
+		IP:        c.IP,
+		RequestID: c.RequestID,
+		Action:    action,
+		Entity:    entity,
+		EntityID:  id,
+		Changes:   changes,
+		CreatedAt: time.Now(),
+	}
+	if c.UserID != "" {
+		record.Actor = c.UserID.Hex()
+		record.ActorName = c.Username
+		record.Via = c.Via
+	}
+	if c.OrgID != "" {
+		record.OrgID = c.OrgID.Hex()
+	}
+
+	if err := models.Create(models.AuditCollection, record); err != nil {
+		log.Errorf("error recording %s of %s %s in the audit log: %v", action, entity, id, err)
+	}
+}
@@ -0,0 +1,220 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ListChecks returns the page of the checks of the clients the caller can
+// read
+func ListChecks(c *Caller, q ListQuery) (interface{}, *models.Page, error) {
+	n, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scope, err := readableChecks(c, n, utils.Filter{})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return findPage(models.CheckFields, scope, q, checkFinder(n), nil)
+}
+
+// GetCheck returns a check of a client the caller can read
+func GetCheck(c *Caller, id string) (storageModel.Check, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Check{}, err
+	}
+	return findCheck(c, n, id, models.ACLRead)
+}
+
+// DeleteCheck deletes a check of a client the caller can write to
+func DeleteCheck(c *Caller, id string) error {
+	n, err := conn()
+	if err != nil {
+		return err
+	}
+
+	// keep the check for the audit log
+	check, err := findCheck(c, n, id, models.ACLWrite)
+	if err != nil {
+		return err
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{Filter: utils.Filter{"_id": check.ID}})
+	if err != nil {
+		return internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.DeleteCheck(n, data); err != nil {
+		return internal("error deleting the check: %v", err)
+	}
+	Audit(c, models.AuditDelete, "check", check.ID.Hex(), check, nil)
+
+	return nil
+}
+
+// ClientChecks returns the page of the checks of a client the caller can
+// read
+func ClientChecks(c *Caller, clientID string, q ListQuery) (interface{}, *models.Page, error) {
+	n, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := findClient(c, n, clientID, models.ACLRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return findPage(models.CheckFields, utils.Filter{"client_id": client.ID}, q, checkFinder(n), nil)
+}
+
+// ChecksBetween returns the page of the checks of a command on a client the
+// caller can read, created between from and to
+func ChecksBetween(c *Caller, clientID, commandID string, from, to time.Time, q ListQuery) (interface{}, *models.Page, error) {
+	command, err := objectID(commandID, "Command ID")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	n, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	client, err := findClient(c, n, clientID, models.ACLRead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	filter := utils.Filter{"client_id": client.ID, "command_id": command, "created_at": bson.M{"$gte": from, "$lte": to}}
+	return findPage(models.CheckFields, filter, q, checkFinder(n), nil)
+}
+
+// LatestChecks returns the latest check of a client the caller can read for
+// each of the commands, commands without checks are left out
+func LatestChecks(c *Caller, clientID string, commandIDs []string) ([]storageModel.Check, error) {
+	if _, err := objectID(clientID, "Client ID"); err != nil {
+		return nil, err
+	}
+	for _, id := range commandIDs {
+		if !bson.IsObjectIdHex(id) {
+			return nil, invalid(fmt.Sprintf("The command id \"%s\" is not a valid ObjectId", id))
+		}
+	}
+
+	n, err := conn()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := findClient(c, n, clientID, models.ACLRead)
+	if err != nil {
+		return nil, err
+	}
+
+	var checks []storageModel.Check
+	for _, cmd := range commandIDs {
+		data, err := bson.MarshalJSON(utils.FindOptions{
+			Filter: utils.Filter{"client_id": client.ID, "command_id": bson.ObjectIdHex(cmd)},
+			Sort:   utils.Sort{"-created_at"},
+			Limit:  1,
+		})
+		if err != nil {
+			return nil, internal("error marshaling data: %v", err)
+		}
+
+		found, err := utilNats.FindCheck(n, data)
+		if err != nil {
+			return nil, internal("error finding check with client and command id: %v", err)
+		}
+		if len(found) >= 1 {
+			checks = append(checks, found[0])
+		}
+	}
+	return checks, nil
+}
+
+// findCheck returns the check if the caller has the level on its client
+func findCheck(c *Caller, n *nats.Conn, id, level string) (storageModel.Check, error) {
+	oid, err := objectID(id, "ID")
+	if err != nil {
+		return storageModel.Check{}, err
+	}
+
+	filter, err := c.CheckScope(n, utils.Filter{"_id": oid})
+	if err != nil {
+		return storageModel.Check{}, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: filter, Limit: 1})
+	if err != nil {
+		return storageModel.Check{}, internal("error marshaling data: %v", err)
+	}
+
+	checks, err := utilNats.FindCheck(n, data)
+	if err != nil {
+		return storageModel.Check{}, internal("error finding the check: %v", err)
+	}
+	if len(checks) <= 0 {
+		return storageModel.Check{}, notFound("Could not find any checks")
+	}
+
+	if _, err := findClient(c, n, checks[0].ClientID.Hex(), level); err != nil {
+		if e, ok := err.(*Error); ok && e.Kind == NotFound {
+			return storageModel.Check{}, notFound("Could not find any checks")
+		}
+		return storageModel.Check{}, err
+	}
+	return checks[0], nil
+}
+
+// checkFinder finds the checks of a page
+func checkFinder(n *nats.Conn) pageFinder {
+	return func(find utils.FindOptions) (interface{}, error) {
+		data, err := bson.MarshalJSON(find)
+		if err != nil {
+			return nil, err
+		}
+		return utilNats.FindCheck(n, data)
+	}
+}
+
+// readableChecks scopes the filter to the checks of the clients the caller
+// can read
+func readableChecks(c *Caller, n *nats.Conn, filter utils.Filter) (utils.Filter, error) {
+	// admins can read every client of the organization
+	if c.Subject.IsPermitted(models.ACLClient + "s:" + models.ACLAdmin) {
+		return c.CheckScope(n, filter)
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: c.Scope(utils.Filter{})})
+	if err != nil {
+		return nil, internal("error marshaling data: %v", err)
+	}
+
+	clients, err := utilNats.FindClient(n, data)
+	if err != nil {
+		return nil, internal("error finding clients: %v", err)
+	}
+	readable, err := ReadableClients(c, clients)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]bson.ObjectId, 0, len(readable))
+	for _, client := range readable {
+		ids = append(ids, client.ID)
+	}
+	return utils.Filter{"$and": []utils.Filter{filter, {"client_id": bson.M{"$in": ids}}}}, nil
+}
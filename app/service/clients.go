@@ -0,0 +1,253 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ListClients returns the page of the clients the caller can read
+func ListClients(c *Caller, q ListQuery) (interface{}, *models.Page, error) {
+	n, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return findPage(models.ClientFields, c.Scope(utils.Filter{}), q, func(find utils.FindOptions) (interface{}, error) {
+		data, err := bson.MarshalJSON(find)
+		if err != nil {
+			return nil, err
+		}
+		return utilNats.FindClient(n, data)
+	}, func(items interface{}) (interface{}, error) {
+		return ReadableClients(c, items.([]storageModel.Client))
+	})
+}
+
+// GetClient returns a client the caller can read
+func GetClient(c *Caller, id string) (storageModel.Client, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Client{}, err
+	}
+	return findClient(c, n, id, models.ACLRead)
+}
+
+// CreateClient creates a client owned by the caller
+func CreateClient(c *Caller, create models.ClientCreate) (storageModel.Client, error) {
+	if create.IP == "" {
+		return storageModel.Client{}, invalid("IP is missing")
+	}
+	if create.Name == "" {
+		return storageModel.Client{}, invalid("Name is missing")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return storageModel.Client{}, err
+	}
+
+	client := storageModel.Client{
+		ID:   bson.NewObjectId(),
+		Name: create.Name,
+		IP:   create.IP,
+	}
+
+	data, err := models.MarshalWithOrg(client, c.OrgID)
+	if err != nil {
+		return storageModel.Client{}, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.CreateClient(n, data); err != nil {
+		return storageModel.Client{}, internal("error creating the client: %v", err)
+	}
+	CreateACL(c, models.ACLClient, client.ID)
+	Audit(c, models.AuditCreate, "client", client.ID.Hex(), nil, client)
+
+	return client, nil
+}
+
+// PatchClient applies a JSON Merge Patch or JSON Patch to a client, every
+// changed field is saved at once. It returns the patched client and whether
+// it changed.
+func PatchClient(c *Caller, id, contentType string, data []byte) (storageModel.Client, bool, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Client{}, false, err
+	}
+
+	client, err := findClient(c, n, id, models.ACLWrite)
+	if err != nil {
+		return client, false, err
+	}
+	before := client
+	before.GroupIDs = append([]bson.ObjectId(nil), client.GroupIDs...)
+
+	set, err := Patch(&client, models.ClientSchema, contentType, data)
+	if err != nil {
+		return before, false, err
+	}
+	if len(set) <= 0 {
+		return client, false, nil
+	}
+
+	if _, found := set["group_ids"]; found {
+		if err := checkGroupChanges(c, n, before.GroupIDs, client.GroupIDs); err != nil {
+			return before, false, err
+		}
+	}
+
+	update, err := bson.MarshalJSON(utils.UpdateOptions{
+		Filter:  c.Scope(utils.Filter{"_id": client.ID}),
+		Updates: utils.Updates{"$set": set},
+	})
+	if err != nil {
+		return before, false, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.UpdateClient(n, update); err != nil {
+		return before, false, internal("error updating the client: %v", err)
+	}
+	Audit(c, models.AuditEdit, "client", client.ID.Hex(), before, client)
+
+	return client, true, nil
+}
+
+// EditClient sets the field named by the option of an edit request to its
+// value, groups are set from a comma separated list of their ids
+func EditClient(c *Caller, edit models.EditRequest) (storageModel.Client, error) {
+	v, ok := edit.Value.(string)
+	if !ok {
+		return storageModel.Client{}, invalid("Value is not a string")
+	}
+
+	var field string
+	var value interface{} = v
+	switch strings.ToLower(edit.Option) {
+	case "name", "namn":
+		field = "name"
+	case "ip":
+		field = "ip"
+	case "group", "groups":
+		ids := []string{}
+		for _, id := range strings.Split(v, ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+		field, value = "group_ids", ids
+	default:
+		return storageModel.Client{}, invalid("Please provide a correct column")
+	}
+
+	data, err := fieldPatch(field, value)
+	if err != nil {
+		return storageModel.Client{}, err
+	}
+	client, _, err := PatchClient(c, edit.ID, patch.MergePatchType, data)
+	return client, err
+}
+
+// DeleteClient deletes a client the caller can write
+func DeleteClient(c *Caller, id string) error {
+	n, err := conn()
+	if err != nil {
+		return err
+	}
+
+	// keep the client for the audit log
+	client, err := findClient(c, n, id, models.ACLWrite)
+	if err != nil {
+		return err
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{Filter: c.Scope(utils.Filter{"_id": client.ID})})
+	if err != nil {
+		return internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.DeleteClient(n, data); err != nil {
+		return internal("error deleting the client: %v", err)
+	}
+	DeleteACLs(models.ACLClient, client.ID)
+	Audit(c, models.AuditDelete, "client", client.ID.Hex(), client, nil)
+
+	return nil
+}
+
+// findClient returns the client of the organization of the caller, checking
+// that the caller has the level on it
+func findClient(c *Caller, n *nats.Conn, id, level string) (storageModel.Client, error) {
+	oid, err := objectID(id, "ID")
+	if err != nil {
+		return storageModel.Client{}, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: c.Scope(utils.Filter{"_id": oid}), Limit: 1})
+	if err != nil {
+		return storageModel.Client{}, internal("error marshaling data: %v", err)
+	}
+
+	clients, err := utilNats.FindClient(n, data)
+	if err != nil {
+		return storageModel.Client{}, internal("error finding the client: %v", err)
+	}
+	if len(clients) <= 0 {
+		return storageModel.Client{}, notFound("Can't find a client with this ID")
+	}
+	if err := CheckClientACL(c, clients[0], level); err != nil {
+		return storageModel.Client{}, err
+	}
+	return clients[0], nil
+}
+
+// checkGroupChanges checks that the groups added to a client exist in the
+// organization of the caller, and that the caller may write to the added and
+// removed groups
+func checkGroupChanges(c *Caller, n *nats.Conn, old, new []bson.ObjectId) error {
+	inOld := make(map[bson.ObjectId]bool)
+	for _, id := range old {
+		inOld[id] = true
+	}
+	inNew := make(map[bson.ObjectId]bool)
+	for _, id := range new {
+		inNew[id] = true
+	}
+	if len(inNew) != len(new) {
+		return invalid("A group can only be added once")
+	}
+
+	var changed []bson.ObjectId
+	for _, id := range old {
+		if !inNew[id] {
+			changed = append(changed, id)
+		}
+	}
+	for id := range inNew {
+		if inOld[id] {
+			continue
+		}
+		changed = append(changed, id)
+
+		data, err := bson.MarshalJSON(utils.HasOptions{Filter: c.Scope(utils.Filter{"_id": id})})
+		if err != nil {
+			return internal("error marshaling data: %v", err)
+		}
+
+		has, err := utilNats.HasGroup(n, data)
+		if err != nil {
+			return internal("error finding a group: %v", err)
+		}
+		if !has {
+			return invalid("Can't find a group with the id " + id.Hex())
+		}
+	}
+
+	return CheckACL(c, models.ACLGroup, models.ACLWrite, changed...)
+}
@@ -0,0 +1,240 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ListCommands returns the page of the commands the caller can read
+func ListCommands(c *Caller, q ListQuery) (interface{}, *models.Page, error) {
+	n, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return findPage(models.CommandFields, c.Scope(utils.Filter{}), q, func(find utils.FindOptions) (interface{}, error) {
+		data, err := bson.MarshalJSON(find)
+		if err != nil {
+			return nil, err
+		}
+		return utilNats.FindCommand(n, data)
+	}, func(items interface{}) (interface{}, error) {
+		return readableCommands(c, items.([]storageModel.Command))
+	})
+}
+
+// GetCommand returns a command the caller can read
+func GetCommand(c *Caller, id string) (storageModel.Command, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Command{}, err
+	}
+	return findCommand(c, n, id, models.ACLRead)
+}
+
+// CreateCommand creates a command owned by the caller
+func CreateCommand(c *Caller, create models.CommandCreate) (storageModel.Command, error) {
+	if create.Command == "" {
+		return storageModel.Command{}, invalid("Command is missing")
+	}
+	if create.Name == "" {
+		return storageModel.Command{}, invalid("Name is missing")
+	}
+	if create.Description == "" {
+		return storageModel.Command{}, invalid("Description is missing")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return storageModel.Command{}, err
+	}
+
+	cmd := storageModel.Command{
+		ID:          bson.NewObjectId(),
+		Command:     create.Command,
+		Name:        create.Name,
+		Description: create.Description,
+		Format:      create.Format,
+	}
+
+	data, err := models.MarshalWithOrg(cmd, c.OrgID)
+	if err != nil {
+		return storageModel.Command{}, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.CreateCommand(n, data); err != nil {
+		return storageModel.Command{}, internal("error creating the command: %v", err)
+	}
+	CreateACL(c, models.ACLCommand, cmd.ID)
+	Audit(c, models.AuditCreate, "command", cmd.ID.Hex(), nil, cmd)
+
+	return cmd, nil
+}
+
+// PatchCommand applies a JSON Merge Patch or JSON Patch to a command, every
+// changed field is saved at once. It returns the patched command and whether
+// it changed.
+func PatchCommand(c *Caller, id, contentType string, data []byte) (storageModel.Command, bool, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Command{}, false, err
+	}
+
+	cmd, err := findCommand(c, n, id, models.ACLWrite)
+	if err != nil {
+		return cmd, false, err
+	}
+	before := cmd
+
+	set, err := Patch(&cmd, models.CommandSchema, contentType, data)
+	if err != nil {
+		return before, false, err
+	}
+	if len(set) <= 0 {
+		return cmd, false, nil
+	}
+
+	update, err := bson.MarshalJSON(utils.UpdateOptions{
+		Filter:  c.Scope(utils.Filter{"_id": cmd.ID}),
+		Updates: utils.Updates{"$set": set},
+	})
+	if err != nil {
+		return before, false, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.UpdateCommand(n, update); err != nil {
+		return before, false, internal("error updating the command: %v", err)
+	}
+	Audit(c, models.AuditEdit, "command", cmd.ID.Hex(), before, cmd)
+
+	return cmd, true, nil
+}
+
+// EditCommand sets the field named by the option of an edit request to its
+// value
+func EditCommand(c *Caller, edit models.EditRequest) (storageModel.Command, error) {
+	v, ok := edit.Value.(string)
+	if !ok {
+		return storageModel.Command{}, invalid("Value is not a string")
+	}
+
+	var field string
+	switch strings.ToLower(edit.Option) {
+	case "command", "description", "format":
+		field = strings.ToLower(edit.Option)
+	case "name", "namn":
+		field = "name"
+	default:
+		return storageModel.Command{}, invalid("Please provide a correct column")
+	}
+
+	data, err := fieldPatch(field, v)
+	if err != nil {
+		return storageModel.Command{}, err
+	}
+	cmd, _, err := PatchCommand(c, edit.ID, patch.MergePatchType, data)
+	return cmd, err
+}
+
+// DeleteCommand deletes a command the caller can write
+func DeleteCommand(c *Caller, id string) error {
+	n, err := conn()
+	if err != nil {
+		return err
+	}
+
+	// keep the command for the audit log
+	cmd, err := findCommand(c, n, id, models.ACLWrite)
+	if err != nil {
+		return err
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{Filter: c.Scope(utils.Filter{"_id": cmd.ID})})
+	if err != nil {
+		return internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.DeleteCommand(n, data); err != nil {
+		return internal("error deleting the command: %v", err)
+	}
+	DeleteACLs(models.ACLCommand, cmd.ID)
+	Audit(c, models.AuditDelete, "command", cmd.ID.Hex(), cmd, nil)
+
+	return nil
+}
+
+// findCommand returns the command of the organization of the caller,
+// checking that the caller has the level on it
+func findCommand(c *Caller, n *nats.Conn, id, level string) (storageModel.Command, error) {
+	oid, err := objectID(id, "ID")
+	if err != nil {
+		return storageModel.Command{}, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: c.Scope(utils.Filter{"_id": oid}), Limit: 1})
+	if err != nil {
+		return storageModel.Command{}, internal("error marshaling data: %v", err)
+	}
+
+	commands, err := utilNats.FindCommand(n, data)
+	if err != nil {
+		return storageModel.Command{}, internal("error finding the command: %v", err)
+	}
+	if len(commands) <= 0 {
+		return storageModel.Command{}, notFound("Can't find a command with this ID")
+	}
+	if err := CheckACL(c, models.ACLCommand, level, commands[0].ID); err != nil {
+		return storageModel.Command{}, err
+	}
+	return commands[0], nil
+}
+
+// readableCommands leaves out the commands the caller can't read
+func readableCommands(c *Caller, commands []storageModel.Command) ([]storageModel.Command, error) {
+	ids := make([]bson.ObjectId, 0, len(commands))
+	for _, cmd := range commands {
+		ids = append(ids, cmd.ID)
+	}
+	readable, err := Readable(c, models.ACLCommand, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	visible := commands[:0]
+	for _, cmd := range commands {
+		if readable[cmd.ID] {
+			visible = append(visible, cmd)
+		}
+	}
+	return visible, nil
+}
+
+// hasCommand checks that the command exists in the organization of the
+// caller and that the caller can read it
+func hasCommand(c *Caller, n *nats.Conn, id string) error {
+	oid, err := objectID(id, "Command ID")
+	if err != nil {
+		return err
+	}
+
+	data, err := bson.MarshalJSON(utils.HasOptions{Filter: c.Scope(utils.Filter{"_id": oid})})
+	if err != nil {
+		return internal("error marshaling data: %v", err)
+	}
+
+	has, err := utilNats.HasCommand(n, data)
+	if err != nil {
+		return internal("error finding the command: %v", err)
+	}
+	if !has {
+		return invalid("Can't find a command with the id " + id)
+	}
+	return CheckACL(c, models.ACLCommand, models.ACLRead, oid)
+}
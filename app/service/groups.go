@@ -0,0 +1,499 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/patch"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ListGroups returns the page of the groups the caller can read
+func ListGroups(c *Caller, q ListQuery) (interface{}, *models.Page, error) {
+	n, err := conn()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return findPage(models.GroupFields, c.Scope(utils.Filter{}), q, func(find utils.FindOptions) (interface{}, error) {
+		data, err := bson.MarshalJSON(find)
+		if err != nil {
+			return nil, err
+		}
+		return utilNats.FindGroup(n, data)
+	}, func(items interface{}) (interface{}, error) {
+		return readableGroups(c, items.([]storageModel.Group))
+	})
+}
+
+// GetGroup returns a group the caller can read
+func GetGroup(c *Caller, id string) (storageModel.Group, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Group{}, err
+	}
+	return findGroup(c, n, id, models.ACLRead)
+}
+
+// CreateGroup adds a command to the group with the name, the group is
+// created and owned by the caller if there is none
+func CreateGroup(c *Caller, create models.GroupCreate) (storageModel.Group, error) {
+	if create.CommandID == "" {
+		return storageModel.Group{}, invalid("Command ID is missing")
+	}
+	if create.GroupName == "" {
+		return storageModel.Group{}, invalid("Group name is missing")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return storageModel.Group{}, err
+	}
+	if err := hasCommand(c, n, create.CommandID); err != nil {
+		return storageModel.Group{}, err
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{
+		Filter: c.Scope(utils.Filter{"name": create.GroupName}),
+		Sort:   utils.Sort{"-created_at"},
+		Limit:  1,
+	})
+	if err != nil {
+		return storageModel.Group{}, internal("error marshaling data: %v", err)
+	}
+
+	groups, err := utilNats.FindGroup(n, data)
+	if err != nil {
+		return storageModel.Group{}, internal("error finding the group: %v", err)
+	}
+
+	command := storageModel.GroupCommand{
+		ID:        bson.NewObjectId(),
+		CommandID: bson.ObjectIdHex(create.CommandID),
+	}
+
+	if len(groups) <= 0 {
+		group := storageModel.Group{
+			ID:       bson.NewObjectId(),
+			Name:     create.GroupName,
+			Commands: []storageModel.GroupCommand{command},
+		}
+
+		data, err := models.MarshalWithOrg(group, c.OrgID)
+		if err != nil {
+			return storageModel.Group{}, internal("error marshaling data: %v", err)
+		}
+
+		if err := utilNats.CreateGroup(n, data); err != nil {
+			return storageModel.Group{}, internal("error creating the group: %v", err)
+		}
+		CreateACL(c, models.ACLGroup, group.ID)
+		Audit(c, models.AuditCreate, "group", group.ID.Hex(), nil, group)
+		return group, nil
+	}
+
+	group := groups[0]
+	if err := CheckACL(c, models.ACLGroup, models.ACLWrite, group.ID); err != nil {
+		return storageModel.Group{}, err
+	}
+	before := group
+	before.Commands = append([]storageModel.GroupCommand(nil), group.Commands...)
+	group.Commands = append(group.Commands, command)
+
+	data, err = bson.MarshalJSON(utils.UpdateOptions{
+		Filter:  utils.Filter{"_id": group.ID},
+		Updates: utils.Updates{"$set": bson.M{"commands": group.Commands}},
+	})
+	if err != nil {
+		return storageModel.Group{}, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.UpdateGroup(n, data); err != nil {
+		return storageModel.Group{}, internal("error updating the group: %v", err)
+	}
+	Audit(c, models.AuditEdit, "group", group.ID.Hex(), before, group)
+
+	return group, nil
+}
+
+// PatchGroup applies a JSON Merge Patch or JSON Patch to a group, every
+// changed field, including its commands, is saved at once. It returns the
+// patched group and whether it changed.
+func PatchGroup(c *Caller, id, contentType string, data []byte) (storageModel.Group, bool, error) {
+	n, err := conn()
+	if err != nil {
+		return storageModel.Group{}, false, err
+	}
+
+	group, err := findGroup(c, n, id, models.ACLWrite)
+	if err != nil {
+		return group, false, err
+	}
+	before := group
+	before.Commands = append([]storageModel.GroupCommand(nil), group.Commands...)
+
+	set, err := Patch(&group, models.GroupSchema, contentType, data)
+	if err != nil {
+		return before, false, err
+	}
+	if len(set) <= 0 {
+		return group, false, nil
+	}
+
+	if _, found := set["name"]; found {
+		data, err := bson.MarshalJSON(utils.HasOptions{
+			Filter: c.Scope(utils.Filter{"name": group.Name, "_id": bson.M{"$ne": group.ID}}),
+		})
+		if err != nil {
+			return before, false, internal("error marshaling data: %v", err)
+		}
+
+		exists, err := utilNats.HasGroup(n, data)
+		if err != nil || exists {
+			return before, false, invalid("There is already an existing group with this name")
+		}
+	}
+
+	if _, found := set["commands"]; found {
+		if err := checkCommandChanges(c, n, before.Commands, group.Commands); err != nil {
+			return before, false, err
+		}
+		set["commands"] = group.Commands
+	}
+
+	update, err := bson.MarshalJSON(utils.UpdateOptions{
+		Filter:  c.Scope(utils.Filter{"_id": group.ID}),
+		Updates: utils.Updates{"$set": set},
+	})
+	if err != nil {
+		return before, false, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.UpdateGroup(n, update); err != nil {
+		return before, false, internal("error updating the group: %v", err)
+	}
+	Audit(c, models.AuditEdit, "group", group.ID.Hex(), before, group)
+
+	return group, true, nil
+}
+
+// EditGroupCommand sets the field named by the option of an edit request of
+// the command of a group with the id of the request
+func EditGroupCommand(c *Caller, edit models.EditRequest) (storageModel.Group, error) {
+	oid, err := objectID(edit.ID, "ID")
+	if err != nil {
+		return storageModel.Group{}, err
+	}
+
+	var field string
+	var value interface{}
+	switch strings.ToLower(edit.Option) {
+	case "command_id", "commandid":
+		v, ok := edit.Value.(string)
+		if !ok {
+			return storageModel.Group{}, invalid("Value is not a string")
+		}
+		field, value = "command_id", v
+	case "next_check", "nextcheck":
+		next, err := toInt(edit.Value)
+		if err != nil {
+			return storageModel.Group{}, invalid("Value is not a number")
+		}
+		field, value = "next_check", next
+	case "stop_error", "stoperror":
+		stop, ok := edit.Value.(bool)
+		if !ok {
+			return storageModel.Group{}, invalid("Value is not a boolean")
+		}
+		field, value = "stop_error", stop
+	default:
+		return storageModel.Group{}, invalid("Please provide a correct column")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return storageModel.Group{}, err
+	}
+
+	groups, err := FindGroups(n, c.Scope(utils.Filter{"commands.id": oid}))
+	if err != nil {
+		return storageModel.Group{}, internal("error finding the group: %v", err)
+	}
+	if len(groups) <= 0 {
+		return storageModel.Group{}, notFound("Can't find a group command with this ID")
+	}
+
+	group := groups[0]
+	i := 0
+	for i < len(group.Commands) && group.Commands[i].ID != oid {
+		i++
+	}
+
+	// the test fails the patch if the commands changed since they were read
+	path := "/commands/" + strconv.Itoa(i)
+	data, err := json.Marshal([]bson.M{
+		{"op": "test", "path": path + "/id", "value": oid.Hex()},
+		{"op": "replace", "path": path + "/" + field, "value": value},
+	})
+	if err != nil {
+		return storageModel.Group{}, internal("error marshaling the patch: %v", err)
+	}
+	group, _, err = PatchGroup(c, group.ID.Hex(), patch.JSONPatchType, data)
+	return group, err
+}
+
+// RenameGroups renames the groups with the old name and returns how many
+// were renamed
+func RenameGroups(c *Caller, rename models.GroupRename) (int, error) {
+	if rename.NewName == "" {
+		return 0, invalid("Please provide a new name for the group")
+	}
+	if rename.OldName == "" {
+		return 0, invalid("Please provide the name of the group you want to rename")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return 0, err
+	}
+
+	data, err := bson.MarshalJSON(utils.HasOptions{Filter: c.Scope(utils.Filter{"name": rename.NewName})})
+	if err != nil {
+		return 0, internal("error marshaling data: %v", err)
+	}
+
+	exists, err := utilNats.HasGroup(n, data)
+	if err != nil || exists {
+		return 0, invalid("There is already an existing group with this name")
+	}
+
+	// keep the groups for the audit log
+	groups, err := FindGroups(n, c.Scope(utils.Filter{"name": rename.OldName}))
+	if err != nil {
+		return 0, internal("error finding the groups: %v", err)
+	}
+	if len(groups) <= 0 {
+		return 0, nil
+	}
+	if err := CheckACL(c, models.ACLGroup, models.ACLWrite, GroupIDs(groups)...); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	data, err = bson.MarshalJSON(utils.UpdateOptions{
+		Filter:  c.Scope(utils.Filter{"_id": bson.M{"$in": GroupIDs(groups)}}),
+		Updates: utils.Updates{"$set": bson.M{"name": rename.NewName, "updated_at": now}},
+	})
+	if err != nil {
+		return 0, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.UpdateGroup(n, data); err != nil {
+		return 0, internal("error updating the groups: %v", err)
+	}
+	for _, group := range groups {
+		renamed := group
+		renamed.Name = rename.NewName
+		renamed.UpdatedAt = now
+		Audit(c, models.AuditEdit, "group", group.ID.Hex(), group, renamed)
+	}
+
+	return len(groups), nil
+}
+
+// DeleteGroup deletes a group the caller can write
+func DeleteGroup(c *Caller, id string) error {
+	n, err := conn()
+	if err != nil {
+		return err
+	}
+
+	// keep the group for the audit log
+	group, err := findGroup(c, n, id, models.ACLWrite)
+	if err != nil {
+		return err
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{Filter: c.Scope(utils.Filter{"_id": group.ID})})
+	if err != nil {
+		return internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.DeleteGroup(n, data); err != nil {
+		return internal("error deleting the group: %v", err)
+	}
+	DeleteACLs(models.ACLGroup, group.ID)
+	Audit(c, models.AuditDelete, "group", group.ID.Hex(), group, nil)
+
+	return nil
+}
+
+// DeleteGroupsWithName deletes the groups with the name and returns how many
+// were deleted, the caller has to be able to write all of them
+func DeleteGroupsWithName(c *Caller, name string) (int, error) {
+	if name == "" {
+		return 0, invalid("Name is missing")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return 0, err
+	}
+
+	// keep the groups for the audit log
+	groups, err := FindGroups(n, c.Scope(utils.Filter{"name": name}))
+	if err != nil {
+		return 0, internal("error finding the groups: %v", err)
+	}
+	if len(groups) <= 0 {
+		return 0, nil
+	}
+	if err := CheckACL(c, models.ACLGroup, models.ACLWrite, GroupIDs(groups)...); err != nil {
+		return 0, err
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{
+		Filter: c.Scope(utils.Filter{"_id": bson.M{"$in": GroupIDs(groups)}}),
+	})
+	if err != nil {
+		return 0, internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.DeleteGroup(n, data); err != nil {
+		return 0, internal("error deleting the groups: %v", err)
+	}
+	DeleteACLs(models.ACLGroup, GroupIDs(groups)...)
+	for _, group := range groups {
+		Audit(c, models.AuditDelete, "group", group.ID.Hex(), group, nil)
+	}
+
+	return len(groups), nil
+}
+
+// GroupExists returns whether the organization of the caller has a group
+// with the name
+func GroupExists(c *Caller, name string) (bool, error) {
+	n, err := conn()
+	if err != nil {
+		return false, err
+	}
+
+	data, err := bson.MarshalJSON(utils.HasOptions{Filter: c.Scope(utils.Filter{"name": name})})
+	if err != nil {
+		return false, internal("error marshaling data: %v", err)
+	}
+
+	has, err := utilNats.HasGroup(n, data)
+	if err != nil {
+		return false, internal("error finding the group: %v", err)
+	}
+	return has, nil
+}
+
+// GroupIDs returns the IDs of the groups
+func GroupIDs(groups []storageModel.Group) []bson.ObjectId {
+	ids := make([]bson.ObjectId, 0, len(groups))
+	for _, g := range groups {
+		ids = append(ids, g.ID)
+	}
+	return ids
+}
+
+// FindGroups returns the groups matching the filter
+func FindGroups(n *nats.Conn, filter utils.Filter) ([]storageModel.Group, error) {
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: filter})
+	if err != nil {
+		return nil, err
+	}
+	return utilNats.FindGroup(n, data)
+}
+
+// findGroup returns the group of the organization of the caller, checking
+// that the caller has the level on it
+func findGroup(c *Caller, n *nats.Conn, id, level string) (storageModel.Group, error) {
+	oid, err := objectID(id, "ID")
+	if err != nil {
+		return storageModel.Group{}, err
+	}
+
+	groups, err := FindGroups(n, c.Scope(utils.Filter{"_id": oid}))
+	if err != nil {
+		return storageModel.Group{}, internal("error finding the group: %v", err)
+	}
+	if len(groups) <= 0 {
+		return storageModel.Group{}, notFound("Can't find a group with this ID")
+	}
+	if err := CheckACL(c, models.ACLGroup, level, groups[0].ID); err != nil {
+		return storageModel.Group{}, err
+	}
+	return groups[0], nil
+}
+
+// readableGroups leaves out the groups the caller can't read
+func readableGroups(c *Caller, groups []storageModel.Group) ([]storageModel.Group, error) {
+	readable, err := Readable(c, models.ACLGroup, GroupIDs(groups))
+	if err != nil {
+		return nil, err
+	}
+
+	visible := groups[:0]
+	for _, g := range groups {
+		if readable[g.ID] {
+			visible = append(visible, g)
+		}
+	}
+	return visible, nil
+}
+
+// checkCommandChanges gives the commands added to a group without an id a
+// new one and checks that the added commands exist
+func checkCommandChanges(c *Caller, n *nats.Conn, old, new []storageModel.GroupCommand) error {
+	known := make(map[bson.ObjectId]bool)
+	for _, cmd := range old {
+		known[cmd.CommandID] = true
+	}
+
+	ids := make(map[bson.ObjectId]bool)
+	for i, cmd := range new {
+		if cmd.ID == "" {
+			new[i].ID = bson.NewObjectId()
+		} else if ids[cmd.ID] {
+			return invalid("The id " + cmd.ID.Hex() + " is used by several commands of the group")
+		}
+		ids[new[i].ID] = true
+
+		if !known[cmd.CommandID] {
+			if err := hasCommand(c, n, cmd.CommandID.Hex()); err != nil {
+				return err
+			}
+			known[cmd.CommandID] = true
+		}
+	}
+	return nil
+}
+
+// toInt converts a number of a JSON request to an int
+func toInt(v interface{}) (int64, error) {
+	switch n := v.(type) {
+	case int64:
+		return n, nil
+	case float64:
+		if n != math.Trunc(n) {
+			return 0, errors.New("not an integer")
+		}
+		return int64(n), nil
+	case string:
+		return strconv.ParseInt(n, 10, 64)
+	default:
+		return 0, errors.New("invalid number type")
+	}
+}
@@ -0,0 +1,291 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"aahframework.org/aah.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/api/app/query"
+	"github.com/keiwi/utils"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// pageMaxScans limits how many batches are scanned to fill a page when
+// entities the caller can't read are left out
+const pageMaxScans = 10
+
+// ListQuery is the filter, sort, field selection and pagination of a list
+// as requested by the caller. A limit of 0 picks the default page size.
+type ListQuery struct {
+	Filter string
+	Sort   string
+	Fields string
+	Cursor string
+	Limit  int
+	Total  bool
+}
+
+// pageFinder finds the entities matching the options, the result is a slice
+// of entity structs with an `ID` field
+type pageFinder func(find utils.FindOptions) (interface{}, error)
+
+// pageFilter leaves out the entities of the slice the caller can't read
+type pageFilter func(items interface{}) (interface{}, error)
+
+// listOptions is a list query parsed for the fields of the entities
+type listOptions struct {
+	filter utils.Filter
+	sort   []string
+	fields []string
+	limit  int
+	cursor *models.Cursor
+	total  bool
+}
+
+// parse parses the query, only the fields of the allowlist may be used
+func (q ListQuery) parse(fields query.Fields) (listOptions, error) {
+	cfg := aah.AppConfig()
+	o := listOptions{limit: cfg.IntDefault("api.pagination.default_limit", 100), sort: models.DefaultSort, total: q.Total}
+
+	var err error
+	if o.filter, err = fields.Filter(q.Filter); err != nil {
+		return o, invalid("Invalid filter: " + err.Error())
+	}
+	if q.Sort != "" {
+		if o.sort, err = fields.Sort(q.Sort); err != nil {
+			return o, invalid("Invalid sort: " + err.Error())
+		}
+	}
+	if o.fields, err = fields.Select(q.Fields); err != nil {
+		return o, invalid("Invalid fields: " + err.Error())
+	}
+
+	if q.Limit < 0 {
+		return o, invalid("Limit has to be a positive number")
+	}
+	if q.Limit > 0 {
+		o.limit = q.Limit
+	}
+	if max := cfg.IntDefault("api.pagination.max_limit", 1000); o.limit > max {
+		o.limit = max
+	}
+
+	if q.Cursor != "" {
+		cursor, err := models.DecodeCursor(q.Cursor, fields)
+		if err != nil {
+			return o, invalid("Invalid cursor")
+		}
+		if strings.Join(cursor.Sort, ",") != strings.Join(o.sort, ",") {
+			return o, invalid("The cursor was made for another sort")
+		}
+		o.cursor = cursor
+	}
+	return o, nil
+}
+
+// findPage finds the page requested by the query of the entities matching
+// the scope. visible may be nil if every entity can be read. The page is a
+// slice of entity structs, or of maps when fields are selected.
+func findPage(fields query.Fields, scope utils.Filter, q ListQuery, find pageFinder, visible pageFilter) (interface{}, *models.Page, error) {
+	o, err := q.parse(fields)
+	if err != nil {
+		return nil, nil, err
+	}
+	page := &models.Page{Limit: o.limit}
+	backward := o.cursor != nil && o.cursor.Before
+
+	filter := scope
+	if len(o.filter) > 0 {
+		filter = utils.Filter{"$and": []utils.Filter{scope, o.filter}}
+	}
+	order := models.Cursor{Sort: o.sort, Before: backward}
+
+	// scan batches from the cursor until the page is filled, the extra
+	// entity tells if there are more
+	var items reflect.Value
+	position := o.cursor
+	exhausted := false
+	for scans := 0; scans < pageMaxScans && !exhausted; scans++ {
+		f := filter
+		if position != nil {
+			f = utils.Filter{"$and": []utils.Filter{filter, position.Filter(fields)}}
+		}
+
+		batch, err := find(utils.FindOptions{Filter: f, Sort: order.StorageSort(fields), Limit: o.limit + 1})
+		if err != nil {
+			return nil, nil, internal("error finding a page: %v", err)
+		}
+
+		scanned := reflect.ValueOf(batch)
+		exhausted = scanned.Len() <= o.limit
+		if scanned.Len() > 0 {
+			last := cursorOf(scanned.Index(scanned.Len()-1), o.sort, backward)
+			position = &last
+		}
+
+		if visible != nil {
+			if batch, err = visible(batch); err != nil {
+				return nil, nil, err
+			}
+		}
+		if !items.IsValid() {
+			items = reflect.MakeSlice(reflect.TypeOf(batch), 0, o.limit+1)
+		}
+		items = reflect.AppendSlice(items, reflect.ValueOf(batch))
+		if items.Len() > o.limit {
+			break
+		}
+	}
+
+	more := items.Len() > o.limit || !exhausted
+	if items.Len() > o.limit {
+		last := cursorOf(items.Index(o.limit-1), o.sort, backward)
+		position = &last
+		items = items.Slice(0, o.limit)
+	}
+
+	// pages before a position are scanned in reverse
+	if backward {
+		swap := reflect.Swapper(items.Interface())
+		for i, j := 0, items.Len()-1; i < j; i, j = i+1, j-1 {
+			swap(i, j)
+		}
+	}
+
+	// the cursor leading away from the position the page was requested at
+	// continues at the last scanned entity, the other one at the cursor
+	if more {
+		if backward {
+			page.Prev = position.Encode()
+		} else {
+			page.Next = position.Encode()
+		}
+	}
+	if o.cursor != nil {
+		back := *o.cursor
+		back.Before = !back.Before
+		if items.Len() > 0 {
+			if backward {
+				back = cursorOf(items.Index(items.Len()-1), o.sort, false)
+			} else {
+				back = cursorOf(items.Index(0), o.sort, true)
+			}
+		}
+		if backward {
+			page.Next = back.Encode()
+		} else {
+			page.Prev = back.Encode()
+		}
+	}
+
+	if o.total {
+		cfg := aah.AppConfig()
+		total, capped, err := countVisible(filter, find, visible,
+			cfg.IntDefault("api.pagination.max_limit", 1000), cfg.IntDefault("api.pagination.max_total", 10000))
+		if err != nil {
+			return nil, nil, err
+		}
+		page.Total = &total
+		page.TotalCapped = capped
+	}
+
+	if len(o.fields) > 0 {
+		selected, err := selectFields(items.Interface(), o.fields)
+		if err != nil {
+			return nil, nil, internal("unable to select fields: %v", err)
+		}
+		return selected, page, nil
+	}
+	return items.Interface(), page, nil
+}
+
+// countVisible counts the entities matching the filter that the caller can
+// read, up to max. The storage service can't count, so the entities are
+// fetched in batches of the size in the order of their ids. It returns max
+// and true if there are more.
+func countVisible(filter utils.Filter, find pageFinder, visible pageFilter, batch, max int) (int, bool, error) {
+	count := 0
+	var last bson.ObjectId
+	for {
+		f := filter
+		if last != "" {
+			f = utils.Filter{"$and": []utils.Filter{filter, {"_id": bson.M{"$gt": last}}}}
+		}
+
+		found, err := find(utils.FindOptions{Filter: f, Sort: utils.Sort{"_id"}, Limit: batch})
+		if err != nil {
+			return 0, false, internal("error counting entities: %v", err)
+		}
+
+		// the last id is taken before filtering, which may reuse the slice
+		scanned := reflect.ValueOf(found).Len()
+		if scanned > 0 {
+			last, _ = reflect.ValueOf(found).Index(scanned - 1).FieldByName("ID").Interface().(bson.ObjectId)
+		}
+
+		if visible != nil {
+			if found, err = visible(found); err != nil {
+				return 0, false, err
+			}
+		}
+		count += reflect.ValueOf(found).Len()
+
+		if count > max {
+			return max, true, nil
+		}
+		if scanned < batch || last == "" {
+			return count, false, nil
+		}
+	}
+}
+
+// cursorOf returns the cursor at an entity for the sort, before marks the
+// cursor of the entities before it
+func cursorOf(entity reflect.Value, sort []string, before bool) models.Cursor {
+	c := models.Cursor{Sort: sort, Before: before}
+	if v := entity.FieldByName("ID"); v.IsValid() {
+		c.ID, _ = v.Interface().(bson.ObjectId)
+	}
+
+	for _, key := range sort {
+		name := strings.TrimPrefix(key, "-")
+		if name == "id" {
+			c.Values = append(c.Values, c.ID)
+			continue
+		}
+
+		var value interface{}
+		if v := FieldByJSONName(entity, name); v.IsValid() {
+			value = v.Interface()
+		}
+		c.Values = append(c.Values, value)
+	}
+	return c
+}
+
+// selectFields returns the entities of the slice with only the selected
+// fields and the id
+func selectFields(items interface{}, fields []string) ([]map[string]interface{}, error) {
+	var docs []map[string]interface{}
+	data, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &docs); err != nil {
+		return nil, err
+	}
+
+	selected := make([]map[string]interface{}, 0, len(docs))
+	for _, doc := range docs {
+		s := map[string]interface{}{"id": doc["id"]}
+		for _, name := range fields {
+			if v, found := doc[name]; found {
+				s[name] = v
+			}
+		}
+		selected = append(selected, s)
+	}
+	return selected, nil
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// OrgHeader is the request header, or the gRPC metadata, selecting the
+// active organization of a call, without it the oldest membership of the
+// user is used
+const OrgHeader = "X-Keiwi-Org"
+
+// ResolveOrg resolves the active organization of the caller, selected is the
+// value of OrgHeader. It is resolved once per caller, and is left empty when
+// organizations are disabled.
+func ResolveOrg(c *Caller, selected string) error {
+	if c.orgResolved || !aah.AppConfig().BoolDefault("security.orgs.enable", false) {
+		c.orgResolved = true
+		return nil
+	}
+
+	filter := utils.Filter{"user_id": c.UserID}
+	if selected != "" {
+		if !bson.IsObjectIdHex(selected) {
+			return invalid(OrgHeader + " is not a valid ObjectId")
+		}
+		filter["org_id"] = bson.ObjectIdHex(selected)
+	}
+
+	members, err := models.FindOrgMembers(filter)
+	if err != nil {
+		return internal("error finding organization members: %v", err)
+	}
+
+	switch {
+	case len(members) > 0:
+		c.OrgID = members[0].OrgID
+	case selected != "" && c.Subject.IsPermitted("orgs:write"):
+		// Users managing every organization may act in any of them
+		if _, err := models.FindOrg(selected); err != nil {
+			return invalid("Can't find an organization with this ID")
+		}
+		c.OrgID = bson.ObjectIdHex(selected)
+	case selected != "":
+		return forbidden("You are not a member of this organization")
+	default:
+		return forbidden("You are not a member of any organization")
+	}
+
+	c.orgResolved = true
+	return nil
+}
+
+// Scope scopes the filter to the active organization of the caller, which
+// has to be resolved first
+func (c *Caller) Scope(filter utils.Filter) utils.Filter {
+	if c.OrgID != "" {
+		filter["org_id"] = c.OrgID
+	}
+	return filter
+}
+
+// CheckScope scopes the filter to the checks of the clients of the active
+// organization of the caller, which has to be resolved first
+func (c *Caller) CheckScope(n *nats.Conn, filter utils.Filter) (utils.Filter, error) {
+	if c.OrgID == "" {
+		return filter, nil
+	}
+
+	data, err := bson.MarshalJSON(utils.FindOptions{Filter: utils.Filter{"org_id": c.OrgID}})
+	if err != nil {
+		return nil, internal("error marshaling data: %v", err)
+	}
+
+	clients, err := utilNats.FindClient(n, data)
+	if err != nil {
+		return nil, internal("error finding clients: %v", err)
+	}
+
+	ids := make([]bson.ObjectId, 0, len(clients))
+	for _, c := range clients {
+		ids = append(ids, c.ID)
+	}
+	return utils.Filter{"$and": []utils.Filter{filter, {"client_id": bson.M{"$in": ids}}}}, nil
+}
+
+// removeOrgMemberships removes a deleted user from every organization
+func removeOrgMemberships(user *storageModel.User) {
+	if err := models.Delete(models.OrgMembersCollection, utils.DeleteOptions{Filter: utils.Filter{"user_id": user.ID}}); err != nil {
+		log.Errorf("error removing organization members: %v", err)
+	}
+}
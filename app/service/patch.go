@@ -0,0 +1,86 @@
+package service
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/patch"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Patch applies the patch of the content type to the fields of the schema of
+// entity, a pointer to an entity struct, and returns the changed fields to
+// `$set`
+func Patch(entity interface{}, schema patch.Schema, contentType string, data []byte) (bson.M, error) {
+	doc, err := schema.Document(entity)
+	if err != nil {
+		log.Errorf("unable to convert the entity to a document: %v", err)
+		return nil, ErrInternal
+	}
+
+	patched, err := patch.Apply(contentType, doc, data)
+	if err == patch.ErrUnsupportedType {
+		return nil, &Error{Kind: Unsupported, Message: "The patch has to be of the type " + patch.MergePatchType + " or " + patch.JSONPatchType}
+	}
+	if err != nil {
+		return nil, invalid("Unable to apply the patch: " + err.Error())
+	}
+	if err := schema.Validate(patched); err != nil {
+		return nil, invalid("Invalid patched entity: " + err.Error())
+	}
+
+	// reset the changed fields, so that decoding doesn't merge the patched
+	// values with the old ones and removed fields are cleared
+	v := reflect.ValueOf(entity).Elem()
+	fields := make(map[string]reflect.Value)
+	for name := range schema {
+		if reflect.DeepEqual(doc[name], patched[name]) {
+			continue
+		}
+
+		field := FieldByJSONName(v, name)
+		if !field.IsValid() {
+			log.Errorf("the schema field '%s' is missing from %s", name, v.Type())
+			return nil, ErrInternal
+		}
+		field.Set(reflect.Zero(field.Type()))
+		fields[name] = field
+	}
+
+	data, err = json.Marshal(patched)
+	if err == nil {
+		err = json.Unmarshal(data, entity)
+	}
+	if err != nil {
+		return nil, invalid("Unable to apply the patch: " + err.Error())
+	}
+
+	set := bson.M{}
+	for name, field := range fields {
+		set[name] = field.Interface()
+	}
+	return set, nil
+}
+
+// FieldByJSONName returns the field of the struct with the JSON name
+func FieldByJSONName(v reflect.Value, name string) reflect.Value {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		tag := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]
+		if tag == name || (tag == "" && strings.EqualFold(t.Field(i).Name, name)) {
+			return v.Field(i)
+		}
+	}
+	return reflect.Value{}
+}
+
+// fieldPatch returns the JSON Merge Patch setting the field to the value
+func fieldPatch(field string, value interface{}) ([]byte, error) {
+	data, err := json.Marshal(map[string]interface{}{field: value})
+	if err != nil {
+		return nil, internal("error marshaling the patch: %v", err)
+	}
+	return data, nil
+}
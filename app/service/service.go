@@ -0,0 +1,105 @@
+// Package service holds the operations on clients, commands, groups, checks
+// and users shared by the HTTP controllers and the gRPC server. The
+// operations validate their input, scope the entities to the organization of
+// the caller, check the access lists and record the changes in the audit
+// log, the transports only map their requests and the errors.
+package service
+
+import (
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/nats-io/go-nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Kind classifies why an operation failed, the transports map it to their
+// status codes
+type Kind int
+
+const (
+	// Internal failures are logged, the caller only learns that it failed
+	Internal Kind = iota
+	// Invalid input of the caller
+	Invalid
+	// NotFound entities, or entities outside the organization of the caller
+	NotFound
+	// Forbidden operations, the access lists don't grant the caller the level
+	Forbidden
+	// Unsupported content types
+	Unsupported
+)
+
+// Error is the error of a failed operation, the message may be shown to the
+// caller
+type Error struct {
+	Kind    Kind
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// ErrInternal is returned for every internal failure
+var ErrInternal = &Error{Kind: Internal, Message: "internal error"}
+
+// Subject is what a caller is permitted, e.g. the aah security subject of a
+// request
+type Subject interface {
+	HasRole(role string) bool
+	IsPermitted(permission string) bool
+}
+
+// Caller is the authenticated user an operation is made by
+type Caller struct {
+	UserID   bson.ObjectId
+	Username string
+	Subject  Subject
+
+	// Via is the claim of the credential the caller authenticated with
+	// instead of a login, e.g. an api key or a client certificate
+	Via string
+
+	// OrgID is the active organization once resolved with ResolveOrg, it is
+	// empty when organizations are disabled
+	OrgID       bson.ObjectId
+	orgResolved bool
+
+	IP        string
+	RequestID string
+}
+
+func invalid(message string) error {
+	return &Error{Kind: Invalid, Message: message}
+}
+
+func notFound(message string) error {
+	return &Error{Kind: NotFound, Message: message}
+}
+
+func forbidden(message string) error {
+	return &Error{Kind: Forbidden, Message: message}
+}
+
+// internal logs why an operation failed and returns ErrInternal
+func internal(format string, args ...interface{}) error {
+	log.Debugf(format, args...)
+	return ErrInternal
+}
+
+// conn returns the connection to the storage service
+func conn() (*nats.Conn, error) {
+	if models.Conn == nil {
+		log.Error("nats is not initialized")
+		return nil, ErrInternal
+	}
+	return models.Conn, nil
+}
+
+// objectID parses the hex encoded ObjectId of the message, e.g. "Client ID"
+func objectID(id, name string) (bson.ObjectId, error) {
+	if !bson.IsObjectIdHex(id) {
+		return "", invalid(name + " is not a valid ObjectId")
+	}
+	return bson.ObjectIdHex(id), nil
+}
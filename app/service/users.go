@@ -0,0 +1,212 @@
+package service
+
+import (
+	"aahframework.org/aah.v0"
+	"aahframework.org/log.v0"
+	"github.com/keiwi/api/app/models"
+	"github.com/keiwi/utils"
+	storageModel "github.com/keiwi/utils/models"
+	utilNats "github.com/keiwi/utils/nats"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	defaultUserPageSize = 50
+	maxUserPageSize     = 500
+)
+
+// CurrentUser returns the caller
+func CurrentUser(c *Caller) (models.UserView, error) {
+	user, err := findUser(c.UserID.Hex())
+	if err != nil {
+		return models.UserView{}, err
+	}
+	return models.NewUserView(user), nil
+}
+
+// ListUsers returns a page of users ordered by ID
+func ListUsers(c *Caller, list models.UserList) (models.UserListPage, error) {
+	if list.Limit <= 0 {
+		list.Limit = defaultUserPageSize
+	}
+	if list.Limit > maxUserPageSize {
+		list.Limit = maxUserPageSize
+	}
+
+	n, err := conn()
+	if err != nil {
+		return models.UserListPage{}, err
+	}
+
+	filter := utils.Filter{}
+	if list.After != "" {
+		after, err := objectID(list.After, "After")
+		if err != nil {
+			return models.UserListPage{}, err
+		}
+		filter["_id"] = bson.M{"$gt": after}
+	}
+
+	// ask for one more user than the page holds to know if there is a next page
+	data, err := bson.MarshalJSON(utils.FindOptions{
+		Filter: filter,
+		Sort:   utils.Sort{"_id"},
+		Limit:  utils.Limit(list.Limit + 1),
+	})
+	if err != nil {
+		return models.UserListPage{}, internal("error marshaling data: %v", err)
+	}
+
+	users, err := utilNats.FindUser(n, data)
+	if err != nil {
+		return models.UserListPage{}, internal("error finding users: %v", err)
+	}
+
+	page := models.UserListPage{Users: []models.UserView{}}
+	for i := range users {
+		if i >= list.Limit {
+			page.Next = users[i-1].ID.Hex()
+			break
+		}
+		page.Users = append(page.Users, models.NewUserView(&users[i]))
+	}
+	return page, nil
+}
+
+// SetUserDisabled disables or enables a user, disabled users can't log in
+func SetUserDisabled(c *Caller, disable models.UserDisable) (models.UserView, error) {
+	user, err := findUser(disable.ID)
+	if err != nil {
+		return models.UserView{}, err
+	}
+	if disable.Disabled && user.ID == c.UserID {
+		return models.UserView{}, invalid("You can't disable your own account")
+	}
+
+	before := *user
+	user.IsLocked = disable.Disabled
+	if err := updateUser(c, before, user, bson.M{"is_locked": user.IsLocked}); err != nil {
+		return models.UserView{}, err
+	}
+	if disable.Disabled {
+		RevokeUserSessions(user.ID)
+	}
+	return models.NewUserView(user), nil
+}
+
+// ResetUserPassword sets a new password for a user
+func ResetUserPassword(c *Caller, reset models.UserPasswordReset) error {
+	if reset.Password == "" {
+		return invalid("Password is missing")
+	}
+
+	user, err := findUser(reset.ID)
+	if err != nil {
+		return err
+	}
+
+	before := *user
+	user.Password = models.HashPassword(user.Username, reset.Password)
+	if err := updateUser(c, before, user, bson.M{"password": user.Password}); err != nil {
+		return err
+	}
+	RevokeUserSessions(user.ID)
+	return nil
+}
+
+// SetUserRoles replaces the roles of a user, only the roles of the
+// configuration may be given
+func SetUserRoles(c *Caller, roles models.UserRoles) (models.UserView, error) {
+	known := aah.AppConfig().KeysByPath("security.roles")
+	for _, role := range roles.Roles {
+		if !containsString(known, role) {
+			return models.UserView{}, invalid("Unknown role " + role)
+		}
+	}
+
+	user, err := findUser(roles.ID)
+	if err != nil {
+		return models.UserView{}, err
+	}
+	if user.ID == c.UserID && containsString(user.Roles, "admin") && !containsString(roles.Roles, "admin") {
+		return models.UserView{}, invalid("You can't remove your own admin role")
+	}
+
+	before := *user
+	user.Roles = roles.Roles
+	if err := updateUser(c, before, user, bson.M{"roles": user.Roles}); err != nil {
+		return models.UserView{}, err
+	}
+	return models.NewUserView(user), nil
+}
+
+// DeleteUser deletes a user, its sessions and its organization memberships
+func DeleteUser(c *Caller, id string) error {
+	user, err := findUser(id)
+	if err != nil {
+		return err
+	}
+	if user.ID == c.UserID {
+		return invalid("You can't delete your own account")
+	}
+
+	n, err := conn()
+	if err != nil {
+		return err
+	}
+
+	data, err := bson.MarshalJSON(utils.DeleteOptions{Filter: utils.Filter{"_id": user.ID}})
+	if err != nil {
+		return internal("error marshaling data: %v", err)
+	}
+
+	if err := utilNats.DeleteUser(n, data); err != nil {
+		return internal("error deleting user: %v", err)
+	}
+	RevokeUserSessions(user.ID)
+	removeOrgMemberships(user)
+	Audit(c, models.AuditDelete, "user", user.ID.Hex(), user, nil)
+
+	return nil
+}
+
+// RevokeUserSessions revokes all sessions of the user, logging any error
+func RevokeUserSessions(user bson.ObjectId) {
+	if err := models.RevokeSessions(models.ActiveSessions(user)); err != nil {
+		log.Errorf("error revoking sessions: %v", err)
+	}
+}
+
+// findUser looks up the user with the hex encoded ID
+func findUser(id string) (*storageModel.User, error) {
+	if !bson.IsObjectIdHex(id) {
+		return nil, invalid("ID is not a valid ObjectId")
+	}
+
+	user, err := models.FindUserWithID(id)
+	if err == models.ErrUserNotFound {
+		return nil, notFound("Can't find a user with this ID")
+	}
+	if err != nil {
+		return nil, internal("error finding user: %v", err)
+	}
+	return user, nil
+}
+
+// updateUser sets the fields of the user that was before
+func updateUser(c *Caller, before storageModel.User, user *storageModel.User, set bson.M) error {
+	if err := models.UpdateUser(user.ID, set); err != nil {
+		return internal("error updating user: %v", err)
+	}
+	Audit(c, models.AuditEdit, "user", user.ID.Hex(), before, user)
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}